@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims son los claims que llevan todos los tokens de acceso emitidos por la API
+type Claims struct {
+	Username string `json:"username"`
+	Role     string `json:"rol"`
+	TokenID  string `json:"token_id"`          // Identificador de la sesión a la que pertenece el token
+	Purpose  string `json:"purpose,omitempty"` // Vacío en tokens de acceso; ver MFAPurposeVerify y MFAPurposeEnroll
+	jwt.StandardClaims
+}
+
+// claimsContextKey es la clave privada con la que se guardan los claims en el contexto
+type claimsContextKey struct{}
+
+// WithClaims devuelve una copia del contexto con los claims del usuario autenticado
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext obtiene los claims guardados por el middleware de autenticación
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
@@ -0,0 +1,165 @@
+package auth
+
+// Permission identifica una acción protegida con el formato "recurso:acción"
+type Permission string
+
+// Roles predefinidos de la API
+const (
+	RoleAdministracion = "Administracion"
+	RoleRecepcionista  = "Recepcionista"
+	RoleLimpieza       = "Limpieza"
+	RoleMantenimiento  = "Mantenimiento"
+	RoleContabilidad   = "Contabilidad"
+)
+
+// Permisos disponibles
+const (
+	PermProfileRead        Permission = "profile:read"        // Ver los datos del propio usuario
+	PermProfileWrite       Permission = "profile:write"       // Cambiar la propia contraseña y configurar la verificación en dos pasos
+	PermUsersRead          Permission = "users:read"          // Listar usuarios del sistema
+	PermUsersManage        Permission = "users:manage"        // Administrar usuarios del sistema
+	PermRolesManage        Permission = "roles:manage"        // Crear, editar y eliminar roles y sus permisos
+	PermSessionsManage     Permission = "sessions:manage"     // Consultar y revocar sesiones de cualquier usuario
+	PermSecurityManage     Permission = "security:manage"     // Auditar intentos de login y desbloquear cuentas
+	PermCURPsWrite         Permission = "curps:write"         // Registrar CURPs válidos para dar de alta administradores
+	PermInvitesManage      Permission = "invites:manage"      // Emitir, listar y revocar invitaciones de registro
+	PermClientsRead        Permission = "clients:read"        // Consultar y buscar clientes
+	PermClientsWrite       Permission = "clients:write"       // Crear y actualizar clientes
+	PermRoomsRead          Permission = "rooms:read"          // Consultar habitaciones y sus ocupantes
+	PermRoomsWrite         Permission = "rooms:write"         // Crear habitaciones
+	PermRoomsAssign        Permission = "rooms:assign"        // Registrar entradas, salidas y ocupantes de habitaciones
+	PermRoomsStatus        Permission = "rooms:status"        // Cambiar el estado de limpieza o servicio de las habitaciones
+	PermHousekeepingWork   Permission = "housekeeping:work"   // Ver el tablero de limpieza y empezar y terminar tareas
+	PermHousekeepingManage Permission = "housekeeping:manage" // Crear, asignar e inspeccionar tareas de limpieza
+	PermMaintenanceReport  Permission = "maintenance:report"  // Reportar fallas de habitaciones y consultar tickets
+	PermMaintenanceManage  Permission = "maintenance:manage"  // Atender, reasignar y cerrar tickets de mantenimiento
+	PermReservationsRead   Permission = "reservations:read"   // Consultar reservaciones y disponibilidad
+	PermReservationsWrite  Permission = "reservations:write"  // Crear, modificar y cancelar reservaciones
+	PermLeasesRead         Permission = "leases:read"         // Consultar contratos de inquilinos y sus cargos
+	PermLeasesManage       Permission = "leases:manage"       // Crear, renovar y terminar contratos de inquilinos
+	PermPaymentsRead       Permission = "payments:read"       // Consultar pagos y saldos de clientes
+	PermPaymentsWrite      Permission = "payments:write"      // Registrar pagos recibidos
+	PermPaymentsVoid       Permission = "payments:void"       // Anular pagos registrados
+	PermShiftsOperate      Permission = "shifts:operate"      // Abrir y cerrar el propio turno de caja
+	PermShiftsRead         Permission = "shifts:read"         // Consultar los turnos de caja y sus cortes
+	PermInvoicesRead       Permission = "invoices:read"       // Consultar facturas y descargar su XML
+	PermInvoicesIssue      Permission = "invoices:issue"      // Capturar datos fiscales de clientes y emitir facturas
+	PermRatesRead          Permission = "rates:read"          // Consultar planes de tarifa y cotizar estancias
+	PermRatesManage        Permission = "rates:manage"        // Crear, editar y eliminar planes de tarifa
+	PermAnalyticsRead      Permission = "analytics:read"      // Consultar métricas del negocio
+	PermReportsRead        Permission = "reports:read"        // Exportar reportes y descargar los generados
+	PermReportsManage      Permission = "reports:manage"      // Programar la generación periódica de reportes
+	PermDocumentsRead      Permission = "documents:read"      // Descargar INEs, contratos e imágenes subidas
+)
+
+// AllPermissions enumera todos los permisos que se pueden asignar a un rol
+var AllPermissions = []Permission{
+	PermProfileRead,
+	PermProfileWrite,
+	PermUsersRead,
+	PermUsersManage,
+	PermRolesManage,
+	PermSessionsManage,
+	PermSecurityManage,
+	PermCURPsWrite,
+	PermInvitesManage,
+	PermClientsRead,
+	PermClientsWrite,
+	PermRoomsRead,
+	PermRoomsWrite,
+	PermRoomsAssign,
+	PermRoomsStatus,
+	PermHousekeepingWork,
+	PermHousekeepingManage,
+	PermMaintenanceReport,
+	PermMaintenanceManage,
+	PermReservationsRead,
+	PermReservationsWrite,
+	PermRatesRead,
+	PermRatesManage,
+	PermLeasesRead,
+	PermLeasesManage,
+	PermPaymentsRead,
+	PermPaymentsWrite,
+	PermPaymentsVoid,
+	PermShiftsOperate,
+	PermShiftsRead,
+	PermInvoicesRead,
+	PermInvoicesIssue,
+	PermAnalyticsRead,
+	PermReportsRead,
+	PermReportsManage,
+	PermDocumentsRead,
+}
+
+// DefaultRolePermissions es la tabla que define qué puede hacer cada rol del sistema.
+// Se siembra en la colección de roles al arrancar; los permisos que falten se agregan
+// a los roles del sistema existentes, pero nunca se quitan los que un administrador haya añadido.
+var DefaultRolePermissions = map[string][]Permission{
+	RoleAdministracion: AllPermissions,
+	RoleRecepcionista: {
+		PermProfileRead,
+		PermProfileWrite,
+		PermUsersRead,
+		PermClientsRead,
+		PermClientsWrite,
+		PermRoomsRead,
+		PermRoomsAssign,
+		PermRoomsStatus,
+		PermHousekeepingWork,
+		PermMaintenanceReport,
+		PermReservationsRead,
+		PermReservationsWrite,
+		PermRatesRead,
+		PermLeasesRead,
+		PermPaymentsRead,
+		PermPaymentsWrite,
+		PermShiftsOperate,
+		PermInvoicesRead,
+		PermInvoicesIssue,
+		PermDocumentsRead,
+	},
+	RoleLimpieza: {
+		PermProfileRead,
+		PermProfileWrite,
+		PermRoomsRead,
+		PermRoomsStatus,
+		PermHousekeepingWork,
+		PermMaintenanceReport,
+	},
+	RoleMantenimiento: {
+		PermProfileRead,
+		PermProfileWrite,
+		PermRoomsRead,
+		PermRoomsStatus,
+		PermMaintenanceReport,
+		PermMaintenanceManage,
+	},
+	RoleContabilidad: {
+		PermProfileRead,
+		PermProfileWrite,
+		PermClientsRead,
+		PermRatesRead,
+		PermLeasesRead,
+		PermPaymentsRead,
+		PermPaymentsWrite,
+		PermPaymentsVoid,
+		PermShiftsOperate,
+		PermShiftsRead,
+		PermInvoicesRead,
+		PermInvoicesIssue,
+		PermAnalyticsRead,
+		PermReportsRead,
+		PermReportsManage,
+	},
+}
+
+// IsValidPermission indica si el permiso existe en AllPermissions
+func IsValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if string(p) == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// AccessTokenTTL es la vigencia de los tokens de acceso
+const AccessTokenTTL = 15 * time.Minute
+
+// MFATokenTTL es la vigencia del token intermedio entre la contraseña y el segundo factor
+const MFATokenTTL = 5 * time.Minute
+
+// Propósitos de los tokens intermedios de login con segundo factor
+const (
+	MFAPurposeVerify = "mfa"        // El usuario debe presentar un código TOTP o de recuperación
+	MFAPurposeEnroll = "mfa-enroll" // La política exige que el usuario registre TOTP antes de entrar
+)
+
+var (
+	// ErrTokenMissing indica que la solicitud no trae token
+	ErrTokenMissing = errors.New("token missing")
+	// ErrTokenExpired indica que el token es auténtico pero ya expiró
+	ErrTokenExpired = errors.New("token has expired")
+	// ErrTokenInvalid indica que el token está mal formado, mal firmado o usa otro algoritmo
+	ErrTokenInvalid = errors.New("token is not valid")
+)
+
+// signingMethod es el único algoritmo aceptado al emitir y verificar tokens
+var signingMethod = jwt.SigningMethodHS256
+
+// TokenManager emite y verifica los tokens de acceso de la API
+type TokenManager struct {
+	jwtKey []byte
+}
+
+// NewTokenManager crea un TokenManager que firma con la clave dada
+func NewTokenManager(jwtKey []byte) *TokenManager {
+	return &TokenManager{jwtKey: jwtKey}
+}
+
+// Issue firma un nuevo token de acceso para la sesión indicada
+func (m *TokenManager) Issue(username, role, tokenID string) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		Username: username,
+		Role:     role,
+		TokenID:  tokenID,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(signingMethod, claims).SignedString(m.jwtKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to sign token: %v", err)
+	}
+	return tokenString, claims, nil
+}
+
+// IssueMFA firma un token intermedio que solo sirve para completar el segundo paso del login
+func (m *TokenManager) IssueMFA(username, role, purpose string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		Username: username,
+		Role:     role,
+		Purpose:  purpose,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(MFATokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(signingMethod, claims).SignedString(m.jwtKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to sign token: %v", err)
+	}
+	return tokenString, nil
+}
+
+// ParseMFA verifica un token intermedio y que su propósito sea el esperado
+func (m *TokenManager) ParseMFA(tokenString, purpose string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose || claims.Username == "" {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// Parse verifica la firma, el algoritmo y la expiración del token y devuelve sus claims
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" || claims.TokenID == "" || claims.Role == "" {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+func (m *TokenManager) parse(tokenString string) (*Claims, error) {
+	if tokenString == "" {
+		return nil, ErrTokenMissing
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != signingMethod.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return m.jwtKey, nil
+	})
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors == jwt.ValidationErrorExpired {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// TokenFromRequest obtiene el token de la cookie "Authorize" o del encabezado Authorization
+func TokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("Authorize"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret es la clave SHA1 de los vectores de prueba del RFC 6238 ("12345678901234567890") en base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("secreto inválido: %v", err)
+	}
+
+	// Vectores del RFC 6238 truncados a 6 dígitos
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if code := totpCode(key, tt.unix/totpPeriod); code != tt.code {
+			t.Errorf("totpCode(t=%d) = %s, se esperaba %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		ok       bool
+	}{
+		{name: "código actual", secret: rfc6238Secret, code: "005924", wantStep: step, ok: true},
+		{name: "espacios y secreto en minúsculas", secret: strings.ToLower(rfc6238Secret), code: " 005924 ", wantStep: step, ok: true},
+		{name: "paso anterior dentro de la tolerancia", secret: rfc6238Secret, code: "", wantStep: step - 1, ok: true},
+		{name: "paso siguiente dentro de la tolerancia", secret: rfc6238Secret, code: "", wantStep: step + 1, ok: true},
+		{name: "fuera de la tolerancia", secret: rfc6238Secret, code: "", wantStep: step - 2, ok: false},
+		{name: "código ya usado", secret: rfc6238Secret, code: "005924", lastStep: step, ok: false},
+		{name: "código incorrecto", secret: rfc6238Secret, code: "000000", ok: false},
+		{name: "longitud inválida", secret: rfc6238Secret, code: "05924", ok: false},
+		{name: "secreto inválido", secret: "no-es-base32!", code: "005924", ok: false},
+	}
+
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := tt.code
+			if code == "" {
+				code = totpCode(key, tt.wantStep)
+			}
+			got, ok := ValidateTOTP(tt.secret, code, now, tt.lastStep)
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP = %v, se esperaba %v", ok, tt.ok)
+			}
+			if ok && got != tt.wantStep {
+				t.Errorf("paso = %d, se esperaba %d", got, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secreto %q no decodifica a 20 bytes: %v", secret, err)
+	}
+
+	uri := TOTPProvisioningURI("ana@example.com", secret)
+	for _, part := range []string{"otpauth://totp/Hotelman:ana@example.com?", "secret=" + secret, "issuer=Hotelman", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("la URI %q no contiene %q", uri, part)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("código %q sin formato xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("código %q repetido", code)
+		}
+		seen[code] = true
+	}
+
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToUpper(codes[0])+" ") {
+		t.Errorf("el hash no normaliza mayúsculas y espacios")
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Errorf("dos códigos distintos tienen el mismo hash")
+	}
+}
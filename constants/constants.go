@@ -26,14 +26,37 @@ var (
 	FrontendURL     string
 
 	// Collections
-	CollectionUsers      string
-	CollectionValidCURPs string
-	CollectionClients    string
-	CollectionRooms      string // Nueva colección agregada
+	CollectionUsers           string
+	CollectionValidCURPs      string
+	CollectionClients         string
+	CollectionRooms           string // Nueva colección agregada
+	CollectionSessions        string
+	CollectionRefreshTokens   string
+	CollectionRoles           string
+	CollectionPasswordResets  string
+	CollectionLoginThrottles  string
+	CollectionLoginAttempts   string
+	CollectionInvitations     string
+	CollectionReservations    string
+	CollectionRoomStatusLog   string
+	CollectionHousekeeping    string
+	CollectionMaintenance     string
+	CollectionRatePlans       string
+	CollectionLeases          string
+	CollectionCharges         string
+	CollectionPayments        string
+	CollectionInvoices        string
+	CollectionInvoiceFolios   string
+	CollectionShifts          string
+	CollectionReportSchedules string
+	CollectionReportRuns      string
 
 	// JWT
 	JWTSecretKey string
 
+	// RequireAdminTOTP obliga a los usuarios con rol Administracion a usar TOTP para iniciar sesión
+	RequireAdminTOTP bool
+
 	// Configuración de red
 	ServerAddress string
 	ServerPort    string
@@ -53,6 +76,40 @@ var (
 	// Storage Selector
 	StorageSelector string
 
+	// Correo: MailSender puede ser "smtp" o "log" (escribe los correos en MailOutboxFolder)
+	MailSender       string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	MailOutboxFolder string
+
+	// Rentas vencidas: días de gracia tras el vencimiento, recargo fijo más porcentaje de la renta,
+	// y cada cuántos minutos se revisan los cargos
+	RentGraceDays      int
+	LateFeeFixed       float64
+	LateFeePercent     float64
+	OverdueScanMinutes int
+
+	// Encabezado del hotel en recibos y estados de cuenta
+	HotelName    string
+	HotelAddress string
+	HotelPhone   string
+	HotelRFC     string
+
+	// Facturación CFDI 4.0: PACProvider elige el PAC que timbra ("fake" timbra localmente sin validez fiscal);
+	// el emisor es HotelRFC con el nombre, régimen fiscal y código postal registrados ante el SAT
+	PACProvider       string
+	CFDISerie         string
+	CFDIIssuerName    string
+	CFDIRegimenFiscal string
+	CFDIPostalCode    string
+	CFDITimeZone      string // Zona horaria del lugar de expedición para la Fecha del CFDI
+
+	// Cada cuántos minutos se buscan reportes programados pendientes de generar; 0 desactiva la programación
+	ReportScanMinutes int
+
 	// AllCollections contiene todos los nombres de colecciones definidos
 	AllCollections []string
 )
@@ -78,7 +135,28 @@ func loadConfig() {
 		"CollectionValidCURPs":       "valid_curps",
 		"CollectionClients":          "clients",
 		"CollectionRooms":            "rooms", // Nueva colección agregada
+		"CollectionSessions":         "sessions",
+		"CollectionRefreshTokens":    "refresh_tokens",
+		"CollectionRoles":            "roles",
+		"CollectionPasswordResets":   "password_resets",
+		"CollectionLoginThrottles":   "login_throttles",
+		"CollectionLoginAttempts":    "login_attempts",
+		"CollectionInvitations":      "invitations",
+		"CollectionReservations":     "reservations",
+		"CollectionRoomStatusLog":    "room_status_log",
+		"CollectionHousekeeping":     "housekeeping_tasks",
+		"CollectionMaintenance":      "maintenance_tickets",
+		"CollectionRatePlans":        "rate_plans",
+		"CollectionLeases":           "leases",
+		"CollectionCharges":          "charges",
+		"CollectionPayments":         "payments",
+		"CollectionInvoices":         "invoices",
+		"CollectionInvoiceFolios":    "invoice_folios",
+		"CollectionShifts":           "shifts",
+		"CollectionReportSchedules":  "report_schedules",
+		"CollectionReportRuns":       "report_runs",
 		"JWTSecretKey":               "my_secret_key",
+		"RequireAdminTOTP":           "false",
 		"ServerAddress":              "0.0.0.0",
 		"ServerPort":                 "8000",
 		"CloudinaryCloudName":        "your-cloudinary-cloud-name",
@@ -88,6 +166,28 @@ func loadConfig() {
 		"GoogleDriveCredentialsPath": "credentials.json",
 		"LocalFileSystemFolder":      "/uploads",
 		"StorageSelector":            "local",
+		"MailSender":                 "log",
+		"SMTPHost":                   "localhost",
+		"SMTPPort":                   "587",
+		"SMTPUsername":               "",
+		"SMTPPassword":               "",
+		"SMTPFrom":                   "no-reply@hotelman.local",
+		"MailOutboxFolder":           "/outbox",
+		"RentGraceDays":              "5",
+		"LateFeeFixed":               "0",
+		"LateFeePercent":             "10",
+		"OverdueScanMinutes":         "60",
+		"HotelName":                  "Hotelman",
+		"HotelAddress":               "",
+		"HotelPhone":                 "",
+		"HotelRFC":                   "",
+		"PACProvider":                "fake",
+		"CFDISerie":                  "H",
+		"CFDIIssuerName":             "",
+		"CFDIRegimenFiscal":          "601",
+		"CFDIPostalCode":             "",
+		"CFDITimeZone":               "America/Mexico_City",
+		"ReportScanMinutes":          "1",
 	}
 
 	// Intentar cargar desde variables de entorno
@@ -110,9 +210,13 @@ func allEnvVariablesSet(config map[string]string) bool {
 		"RoleAdmin", "RoleReceptionist", "StatusCreated", "StatusBadRequest",
 		"StatusUnauthorized", "StatusForbidden", "StatusInternalServerError",
 		"MongoDBURI", "MongoDBDatabase", "FrontendURL", "CollectionUsers", "CollectionValidCURPs", "CollectionClients", "CollectionRooms",
-		"JWTSecretKey", "ServerAddress", "ServerPort",
+		"CollectionSessions", "CollectionRefreshTokens", "CollectionRoles", "CollectionPasswordResets", "CollectionLoginThrottles", "CollectionLoginAttempts", "CollectionInvitations", "CollectionReservations", "CollectionRoomStatusLog", "CollectionHousekeeping", "CollectionMaintenance", "CollectionRatePlans", "CollectionLeases", "CollectionCharges", "CollectionPayments", "CollectionInvoices", "CollectionInvoiceFolios", "CollectionShifts", "CollectionReportSchedules", "CollectionReportRuns",
+		"JWTSecretKey", "RequireAdminTOTP", "ServerAddress", "ServerPort",
 		"CloudinaryCloudName", "CloudinaryAPIKey", "CloudinaryAPISecret",
 		"GoogleDriveFolderID", "GoogleDriveCredentialsPath", "LocalFileSystemFolder", "StorageSelector",
+		"MailSender", "SMTPHost", "SMTPPort", "SMTPFrom", "MailOutboxFolder",
+		"RentGraceDays", "LateFeeFixed", "LateFeePercent", "OverdueScanMinutes",
+		"HotelName", "PACProvider", "CFDISerie", "CFDITimeZone", "ReportScanMinutes",
 	}
 
 	for _, key := range requiredKeys {
@@ -156,7 +260,28 @@ func loadFromToml(config map[string]string) {
 	config["CollectionValidCURPs"] = Config.Constants.CollectionValidCURPs
 	config["CollectionClients"] = Config.Constants.CollectionClients
 	config["CollectionRooms"] = Config.Constants.CollectionRooms // Nueva colección agregada
+	config["CollectionSessions"] = Config.Constants.CollectionSessions
+	config["CollectionRefreshTokens"] = Config.Constants.CollectionRefreshTokens
+	config["CollectionRoles"] = Config.Constants.CollectionRoles
+	config["CollectionPasswordResets"] = Config.Constants.CollectionPasswordResets
+	config["CollectionLoginThrottles"] = Config.Constants.CollectionLoginThrottles
+	config["CollectionLoginAttempts"] = Config.Constants.CollectionLoginAttempts
+	config["CollectionInvitations"] = Config.Constants.CollectionInvitations
+	config["CollectionReservations"] = Config.Constants.CollectionReservations
+	config["CollectionRoomStatusLog"] = Config.Constants.CollectionRoomStatusLog
+	config["CollectionHousekeeping"] = Config.Constants.CollectionHousekeeping
+	config["CollectionMaintenance"] = Config.Constants.CollectionMaintenance
+	config["CollectionRatePlans"] = Config.Constants.CollectionRatePlans
+	config["CollectionLeases"] = Config.Constants.CollectionLeases
+	config["CollectionCharges"] = Config.Constants.CollectionCharges
+	config["CollectionPayments"] = Config.Constants.CollectionPayments
+	config["CollectionInvoices"] = Config.Constants.CollectionInvoices
+	config["CollectionInvoiceFolios"] = Config.Constants.CollectionInvoiceFolios
+	config["CollectionShifts"] = Config.Constants.CollectionShifts
+	config["CollectionReportSchedules"] = Config.Constants.CollectionReportSchedules
+	config["CollectionReportRuns"] = Config.Constants.CollectionReportRuns
 	config["JWTSecretKey"] = Config.Constants.JWTSecretKey
+	config["RequireAdminTOTP"] = strconv.FormatBool(Config.Constants.RequireAdminTOTP)
 	config["ServerAddress"] = Config.Constants.ServerAddress
 	config["ServerPort"] = Config.Constants.ServerPort
 	config["CloudinaryCloudName"] = Config.Constants.CloudinaryCloudName
@@ -166,6 +291,28 @@ func loadFromToml(config map[string]string) {
 	config["GoogleDriveCredentialsPath"] = Config.Constants.GoogleDriveCredentialsPath
 	config["LocalFileSystemFolder"] = Config.Constants.LocalFileSystemFolder
 	config["StorageSelector"] = Config.Constants.StorageSelector
+	config["MailSender"] = Config.Constants.MailSender
+	config["SMTPHost"] = Config.Constants.SMTPHost
+	config["SMTPPort"] = Config.Constants.SMTPPort
+	config["SMTPUsername"] = Config.Constants.SMTPUsername
+	config["SMTPPassword"] = Config.Constants.SMTPPassword
+	config["SMTPFrom"] = Config.Constants.SMTPFrom
+	config["MailOutboxFolder"] = Config.Constants.MailOutboxFolder
+	config["RentGraceDays"] = strconv.Itoa(Config.Constants.RentGraceDays)
+	config["LateFeeFixed"] = strconv.FormatFloat(Config.Constants.LateFeeFixed, 'f', -1, 64)
+	config["LateFeePercent"] = strconv.FormatFloat(Config.Constants.LateFeePercent, 'f', -1, 64)
+	config["OverdueScanMinutes"] = strconv.Itoa(Config.Constants.OverdueScanMinutes)
+	config["HotelName"] = Config.Constants.HotelName
+	config["HotelAddress"] = Config.Constants.HotelAddress
+	config["HotelPhone"] = Config.Constants.HotelPhone
+	config["HotelRFC"] = Config.Constants.HotelRFC
+	config["PACProvider"] = Config.Constants.PACProvider
+	config["CFDISerie"] = Config.Constants.CFDISerie
+	config["CFDIIssuerName"] = Config.Constants.CFDIIssuerName
+	config["CFDIRegimenFiscal"] = Config.Constants.CFDIRegimenFiscal
+	config["CFDIPostalCode"] = Config.Constants.CFDIPostalCode
+	config["CFDITimeZone"] = Config.Constants.CFDITimeZone
+	config["ReportScanMinutes"] = strconv.Itoa(Config.Constants.ReportScanMinutes)
 }
 
 func assignConfigValues(config map[string]string) {
@@ -186,8 +333,29 @@ func assignConfigValues(config map[string]string) {
 	CollectionValidCURPs = config["CollectionValidCURPs"]
 	CollectionClients = config["CollectionClients"]
 	CollectionRooms = config["CollectionRooms"] // Nueva colección agregada
+	CollectionSessions = config["CollectionSessions"]
+	CollectionRefreshTokens = config["CollectionRefreshTokens"]
+	CollectionRoles = config["CollectionRoles"]
+	CollectionPasswordResets = config["CollectionPasswordResets"]
+	CollectionLoginThrottles = config["CollectionLoginThrottles"]
+	CollectionLoginAttempts = config["CollectionLoginAttempts"]
+	CollectionInvitations = config["CollectionInvitations"]
+	CollectionReservations = config["CollectionReservations"]
+	CollectionRoomStatusLog = config["CollectionRoomStatusLog"]
+	CollectionHousekeeping = config["CollectionHousekeeping"]
+	CollectionMaintenance = config["CollectionMaintenance"]
+	CollectionRatePlans = config["CollectionRatePlans"]
+	CollectionLeases = config["CollectionLeases"]
+	CollectionCharges = config["CollectionCharges"]
+	CollectionPayments = config["CollectionPayments"]
+	CollectionInvoices = config["CollectionInvoices"]
+	CollectionInvoiceFolios = config["CollectionInvoiceFolios"]
+	CollectionShifts = config["CollectionShifts"]
+	CollectionReportSchedules = config["CollectionReportSchedules"]
+	CollectionReportRuns = config["CollectionReportRuns"]
 
 	JWTSecretKey = config["JWTSecretKey"]
+	RequireAdminTOTP, _ = strconv.ParseBool(config["RequireAdminTOTP"])
 
 	ServerAddress = config["ServerAddress"]
 	ServerPort = config["ServerPort"]
@@ -205,12 +373,64 @@ func assignConfigValues(config map[string]string) {
 
 	StorageSelector = config["StorageSelector"]
 
+	// Correo
+	MailSender = config["MailSender"]
+	SMTPHost = config["SMTPHost"]
+	SMTPPort = config["SMTPPort"]
+	SMTPUsername = config["SMTPUsername"]
+	SMTPPassword = config["SMTPPassword"]
+	SMTPFrom = config["SMTPFrom"]
+	MailOutboxFolder = config["MailOutboxFolder"]
+
+	// Rentas vencidas
+	RentGraceDays, _ = strconv.Atoi(config["RentGraceDays"])
+	LateFeeFixed, _ = strconv.ParseFloat(config["LateFeeFixed"], 64)
+	LateFeePercent, _ = strconv.ParseFloat(config["LateFeePercent"], 64)
+	OverdueScanMinutes, _ = strconv.Atoi(config["OverdueScanMinutes"])
+
+	// Encabezado del hotel
+	HotelName = config["HotelName"]
+	HotelAddress = config["HotelAddress"]
+	HotelPhone = config["HotelPhone"]
+	HotelRFC = config["HotelRFC"]
+
+	// Facturación
+	PACProvider = config["PACProvider"]
+	CFDISerie = config["CFDISerie"]
+	CFDIIssuerName = config["CFDIIssuerName"]
+	CFDIRegimenFiscal = config["CFDIRegimenFiscal"]
+	CFDIPostalCode = config["CFDIPostalCode"]
+	CFDITimeZone = config["CFDITimeZone"]
+
+	// Reportes programados
+	ReportScanMinutes, _ = strconv.Atoi(config["ReportScanMinutes"])
+
 	// Inicializar AllCollections con las colecciones definidas individualmente
 	AllCollections = []string{
 		CollectionUsers,
 		CollectionValidCURPs,
 		CollectionClients,
 		CollectionRooms, // Nueva colección agregada
+		CollectionSessions,
+		CollectionRefreshTokens,
+		CollectionRoles,
+		CollectionPasswordResets,
+		CollectionLoginThrottles,
+		CollectionLoginAttempts,
+		CollectionInvitations,
+		CollectionReservations,
+		CollectionRoomStatusLog,
+		CollectionHousekeeping,
+		CollectionMaintenance,
+		CollectionRatePlans,
+		CollectionLeases,
+		CollectionCharges,
+		CollectionPayments,
+		CollectionInvoices,
+		CollectionInvoiceFolios,
+		CollectionShifts,
+		CollectionReportSchedules,
+		CollectionReportRuns,
 	}
 }
 
@@ -236,8 +456,29 @@ func createDefaultConfig(filename string) {
 	CollectionValidCURPs = "valid_curps"
 	CollectionClients = "clients"
 	CollectionRooms = "rooms"  // Nueva colección agregada
+	CollectionSessions = "sessions"
+	CollectionRefreshTokens = "refresh_tokens"
+	CollectionRoles = "roles"
+	CollectionPasswordResets = "password_resets"
+	CollectionLoginThrottles = "login_throttles"
+	CollectionLoginAttempts = "login_attempts"
+	CollectionInvitations = "invitations"
+	CollectionReservations = "reservations"
+	CollectionRoomStatusLog = "room_status_log"
+	CollectionHousekeeping = "housekeeping_tasks"
+	CollectionMaintenance = "maintenance_tickets"
+	CollectionRatePlans = "rate_plans"
+	CollectionLeases = "leases"
+	CollectionCharges = "charges"
+	CollectionPayments = "payments"
+	CollectionInvoices = "invoices"
+	CollectionInvoiceFolios = "invoice_folios"
+	CollectionShifts = "shifts"
+	CollectionReportSchedules = "report_schedules"
+	CollectionReportRuns = "report_runs"
 
 	JWTSecretKey = "my_secret_key"
+	RequireAdminTOTP = false
 
 	ServerAddress = "0.0.0.0"
 	ServerPort = "8000"
@@ -250,6 +491,33 @@ func createDefaultConfig(filename string) {
 	GoogleDriveCredentialsPath = "credentials.json"
 	LocalFileSystemFolder = "/uploads"
 	StorageSelector = "local"
+
+	MailSender = "log"
+	SMTPHost = "localhost"
+	SMTPPort = "587"
+	SMTPUsername = ""
+	SMTPPassword = ""
+	SMTPFrom = "no-reply@hotelman.local"
+	MailOutboxFolder = "/outbox"
+
+	RentGraceDays = 5
+	LateFeeFixed = 0.0
+	LateFeePercent = 10.0
+	OverdueScanMinutes = 60
+
+	HotelName = "Hotelman"
+	HotelAddress = ""
+	HotelPhone = ""
+	HotelRFC = ""
+
+	PACProvider = "fake"
+	CFDISerie = "H"
+	CFDIIssuerName = ""
+	CFDIRegimenFiscal = "601"
+	CFDIPostalCode = ""
+	CFDITimeZone = "America/Mexico_City"
+
+	ReportScanMinutes = 1
 	`
 
 	// Crear el archivo config.toml con los valores predeterminados
@@ -288,12 +556,33 @@ type Constants struct {
 	MongoDBDatabase string `toml:"MongoDBDatabase"`
 	FrontendURL     string `toml:"FrontendURL"`
 
-	CollectionUsers      string `toml:"CollectionUsers"`
-	CollectionValidCURPs string `toml:"CollectionValidCURPs"`
-	CollectionClients    string `toml:"CollectionClients"`
-	CollectionRooms      string `toml:"CollectionRooms"` // Nueva colección agregada
-
-	JWTSecretKey string `toml:"JWTSecretKey"`
+	CollectionUsers           string `toml:"CollectionUsers"`
+	CollectionValidCURPs      string `toml:"CollectionValidCURPs"`
+	CollectionClients         string `toml:"CollectionClients"`
+	CollectionRooms           string `toml:"CollectionRooms"` // Nueva colección agregada
+	CollectionSessions        string `toml:"CollectionSessions"`
+	CollectionRefreshTokens   string `toml:"CollectionRefreshTokens"`
+	CollectionRoles           string `toml:"CollectionRoles"`
+	CollectionPasswordResets  string `toml:"CollectionPasswordResets"`
+	CollectionLoginThrottles  string `toml:"CollectionLoginThrottles"`
+	CollectionLoginAttempts   string `toml:"CollectionLoginAttempts"`
+	CollectionInvitations     string `toml:"CollectionInvitations"`
+	CollectionReservations    string `toml:"CollectionReservations"`
+	CollectionRoomStatusLog   string `toml:"CollectionRoomStatusLog"`
+	CollectionHousekeeping    string `toml:"CollectionHousekeeping"`
+	CollectionMaintenance     string `toml:"CollectionMaintenance"`
+	CollectionRatePlans       string `toml:"CollectionRatePlans"`
+	CollectionLeases          string `toml:"CollectionLeases"`
+	CollectionCharges         string `toml:"CollectionCharges"`
+	CollectionPayments        string `toml:"CollectionPayments"`
+	CollectionInvoices        string `toml:"CollectionInvoices"`
+	CollectionInvoiceFolios   string `toml:"CollectionInvoiceFolios"`
+	CollectionShifts          string `toml:"CollectionShifts"`
+	CollectionReportSchedules string `toml:"CollectionReportSchedules"`
+	CollectionReportRuns      string `toml:"CollectionReportRuns"`
+
+	JWTSecretKey     string `toml:"JWTSecretKey"`
+	RequireAdminTOTP bool   `toml:"RequireAdminTOTP"`
 
 	ServerAddress string `toml:"ServerAddress"`
 	ServerPort    string `toml:"ServerPort"`
@@ -307,6 +596,33 @@ type Constants struct {
 
 	LocalFileSystemFolder string `toml:"/uploads"`
 	StorageSelector       string `toml:"local"`
+
+	MailSender       string `toml:"MailSender"`
+	SMTPHost         string `toml:"SMTPHost"`
+	SMTPPort         string `toml:"SMTPPort"`
+	SMTPUsername     string `toml:"SMTPUsername"`
+	SMTPPassword     string `toml:"SMTPPassword"`
+	SMTPFrom         string `toml:"SMTPFrom"`
+	MailOutboxFolder string `toml:"MailOutboxFolder"`
+
+	RentGraceDays      int     `toml:"RentGraceDays"`
+	LateFeeFixed       float64 `toml:"LateFeeFixed"`
+	LateFeePercent     float64 `toml:"LateFeePercent"`
+	OverdueScanMinutes int     `toml:"OverdueScanMinutes"`
+
+	HotelName    string `toml:"HotelName"`
+	HotelAddress string `toml:"HotelAddress"`
+	HotelPhone   string `toml:"HotelPhone"`
+	HotelRFC     string `toml:"HotelRFC"`
+
+	PACProvider       string `toml:"PACProvider"`
+	CFDISerie         string `toml:"CFDISerie"`
+	CFDIIssuerName    string `toml:"CFDIIssuerName"`
+	CFDIRegimenFiscal string `toml:"CFDIRegimenFiscal"`
+	CFDIPostalCode    string `toml:"CFDIPostalCode"`
+	CFDITimeZone      string `toml:"CFDITimeZone"`
+
+	ReportScanMinutes int `toml:"ReportScanMinutes"`
 }
 
 // Config es una instancia global de ConfigFile que contiene la configuración cargada
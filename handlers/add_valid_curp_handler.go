@@ -9,8 +9,10 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// AddValidCURPHandler autoriza un CURP para que su titular pueda registrarse como administrador en /signup
 type AddValidCURPHandler struct {
 	Client *mongo.Client
 }
@@ -20,13 +22,18 @@ func (h *AddValidCURPHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		CURP string `json:"curp"`
 	}
 	err := json.NewDecoder(r.Body).Decode(&curpData)
-	if err != nil {
+	if err != nil || !isValidCURP(curpData.CURP) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	// Upsert para no duplicar el CURP si ya estaba autorizado
 	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionValidCURPs)
-	_, err = collection.InsertOne(context.TODO(), bson.M{"curp": curpData.CURP})
+	_, err = collection.UpdateOne(context.TODO(),
+		bson.M{"curp": curpData.CURP},
+		bson.M{"$setOnInsert": bson.M{"curp": curpData.CURP}},
+		options.Update().SetUpsert(true),
+	)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -26,7 +26,7 @@ func (h *GetAllUsersHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
 
 	// Definir un slice para almacenar los usuarios recuperados
-	var users []models.User
+	users := []models.PublicUser{}
 
 	// Obtener todos los usuarios de la colección
 	cur, err := collection.Find(context.TODO(), bson.M{})
@@ -44,7 +44,7 @@ func (h *GetAllUsersHandler) Handle(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		users = append(users, user)
+		users = append(users, user.Public())
 	}
 
 	// Verificar si hubo errores durante el cursor
@@ -53,7 +53,7 @@ func (h *GetAllUsersHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Codificar el slice de usuarios como JSON y enviar como respuesta
+	// Codificar el slice de usuarios (sin contraseñas) como JSON y enviar como respuesta
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
 }
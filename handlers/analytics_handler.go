@@ -1,213 +1,109 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"time"
 
-	"hotelman-backend/constants"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
 )
 
+// maxAnalyticsDays limita el rango de fechas que se puede pedir en una sola consulta
+const maxAnalyticsDays = 731
+
 // AnalyticsHandler maneja las solicitudes de análisis
 type AnalyticsHandler struct {
-	Client *mongo.Client
-}
-
-// AnalyticsResponse define la estructura de la respuesta de análisis
-type AnalyticsResponse struct {
-	TotalPriceGuest float64 `json:"totalPriceGuest"`
-	Guest           struct {
-		Total int `json:"total"`
-	} `json:"guest"`
-	Rental struct {
-		Total int `json:"total"`
-	} `json:"rental"`
-	TotalClients int `json:"totalClients"`
+	Analytics *services.AnalyticsService
 }
 
-// GetAnalyticsHandler maneja la obtención de datos de análisis
+// GetAnalyticsHandler devuelve la ocupación, el ADR, el RevPAR y los ingresos entre startDate y endDate (ambos
+// incluidos, YYYY-MM-DD o RFC3339; por defecto el mes actual), agrupados por bucket (day, week o month) y
+// calculados sobre las habitaciones de roomType (guest, rental o all; por defecto guest)
 func (h *AnalyticsHandler) GetAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
-	// Leer parámetros de consulta (query parameters)
-	startDateStr := r.URL.Query().Get("startDate")
-	endDateStr := r.URL.Query().Get("endDate")
-
-	// Configuración de fechas por defecto (mensuales)
-	now := time.Now().UTC()
-	var startDate, endDate time.Time
-
-	if startDateStr != "" && endDateStr != "" {
-		// Convertir las fechas proporcionadas
-		var err error
-		startDate, err = time.Parse(time.RFC3339, startDateStr)
-		if err != nil {
-			http.Error(w, "Invalid startDate format", http.StatusBadRequest)
-			return
-		}
-		endDate, err = time.Parse(time.RFC3339, endDateStr)
-		if err != nil {
-			http.Error(w, "Invalid endDate format", http.StatusBadRequest)
-			return
-		}
-	} else {
-		// Establecer el rango mensual por defecto
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-		endDate = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
-	}
-
-	// Conectar a la colección de clientes para calcular el total de precios
-	clientCollection := h.Client.Database(constants.MongoDBDatabase).Collection("clients")
-
-	// Obtener el total de precios de los clientes según el período solicitado
-	priceSumPipeline := mongo.Pipeline{
-		{{"$match", bson.D{
-			{"createdAt", bson.D{{"$gte", startDate}, {"$lte", endDate}}},
-			{"price", bson.D{{"$exists", true}, {"$type", "double"}}}, // Filtra solo documentos que contienen el campo "price"
-		}}},
-		{{"$group", bson.D{{"_id", nil}, {"totalPrice", bson.D{{"$sum", "$price"}}}}}},
-	}
-
-	cursor, err := clientCollection.Aggregate(context.Background(), priceSumPipeline)
-	if err != nil {
-		http.Error(w, "Failed to calculate total guest price", http.StatusInternalServerError)
+	params, ok := parseAnalyticsParams(w, r.URL.Query())
+	if !ok {
 		return
 	}
 
-	var priceSumResult struct {
-		TotalPrice float64 `bson:"totalPrice"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&priceSumResult); err != nil {
-			http.Error(w, "Failed to decode total guest price", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Obtener el total de habitaciones de huéspedes
-	guestTotalPipeline := mongo.Pipeline{
-		{{"$match", bson.D{{"roomType", "guest"}}}},
-		{{"$count", "total"}},
-	}
-
-	cursor, err = h.Client.Database(constants.MongoDBDatabase).Collection("rooms").Aggregate(context.Background(), guestTotalPipeline)
+	report, err := h.Analytics.Report(r.Context(), params.StartDate, params.EndDate, params.Bucket, params.RoomType)
 	if err != nil {
-		http.Error(w, "Failed to calculate total guest rooms", http.StatusInternalServerError)
+		http.Error(w, "Failed to calculate analytics", http.StatusInternalServerError)
 		return
 	}
 
-	var guestTotalCountResult struct {
-		Total int `bson:"total"`
-	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&guestTotalCountResult); err != nil {
-			http.Error(w, "Failed to decode total guest rooms", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Obtener el total de habitaciones de renta
-	rentalTotalPipeline := mongo.Pipeline{
-		{{"$match", bson.D{{"roomType", "rental"}}}},
-		{{"$count", "total"}},
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
 
-	cursor, err = h.Client.Database(constants.MongoDBDatabase).Collection("rooms").Aggregate(context.Background(), rentalTotalPipeline)
-	if err != nil {
-		http.Error(w, "Failed to calculate total rental rooms", http.StatusInternalServerError)
-		return
+// parseAnalyticsParams lee startDate, endDate, bucket y roomType con sus valores por defecto; el fin del rango
+// queda exclusivo. Si algún parámetro es inválido responde 400.
+func parseAnalyticsParams(w http.ResponseWriter, query url.Values) (services.ReportParams, bool) {
+	// Rango mensual por defecto
+	now := time.Now().UTC()
+	params := services.ReportParams{
+		StartDate: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Bucket:    query.Get("bucket"),
+		RoomType:  query.Get("roomType"),
 	}
+	params.EndDate = params.StartDate.AddDate(0, 1, 0)
 
-	var rentalTotalCountResult struct {
-		Total int `bson:"total"`
+	if value := query.Get("startDate"); value != "" {
+		date, err := parseAnalyticsDate(value)
+		if err != nil {
+			http.Error(w, "Invalid startDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return params, false
+		}
+		params.StartDate = date
 	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&rentalTotalCountResult); err != nil {
-			http.Error(w, "Failed to decode total rental rooms", http.StatusInternalServerError)
-			return
+	if value := query.Get("endDate"); value != "" {
+		date, err := parseAnalyticsDate(value)
+		if err != nil {
+			http.Error(w, "Invalid endDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return params, false
 		}
+		params.EndDate = date.AddDate(0, 0, 1)
 	}
-
-	// Obtener el total de clientes
-	clientTotalCount, err := clientCollection.CountDocuments(context.Background(), bson.M{})
-	if err != nil {
-		http.Error(w, "Failed to count total clients", http.StatusInternalServerError)
-		return
+	if !params.EndDate.After(params.StartDate) {
+		http.Error(w, "endDate must not be before startDate", http.StatusBadRequest)
+		return params, false
 	}
-
-	// Obtener el total de huéspedes con customID
-	guestWithIDCountPipeline := mongo.Pipeline{
-		{{"$match", bson.D{
-			{"customID", bson.D{{"$exists", true}, {"$ne", ""}}},          // Filtrar clientes con customID no vacío
-			{"createdAt", bson.D{{"$gte", startDate}, {"$lte", endDate}}}, // Filtrar por rango de fechas
-		}}},
-		{{"$count", "total"}},
+	if params.EndDate.Sub(params.StartDate) > maxAnalyticsDays*24*time.Hour {
+		http.Error(w, "Date range is too long", http.StatusBadRequest)
+		return params, false
 	}
 
-	cursor, err = clientCollection.Aggregate(context.Background(), guestWithIDCountPipeline)
-	if err != nil {
-		http.Error(w, "Failed to calculate total guests with customID", http.StatusInternalServerError)
-		return
+	if params.Bucket == "" {
+		params.Bucket = models.BucketDay
 	}
-
-	var guestWithIDCountResult struct {
-		Total int `bson:"total"`
+	if !models.IsValidBucket(params.Bucket) {
+		http.Error(w, "Invalid bucket, expected day, week or month", http.StatusBadRequest)
+		return params, false
 	}
-
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&guestWithIDCountResult); err != nil {
-			http.Error(w, "Failed to decode total guests with customID", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Obtener el total de huéspedes sin customID
-	guestWithoutIDCountPipeline := mongo.Pipeline{
-		{{"$match", bson.D{
-			{"customID", bson.D{{"$exists", false}}},                      // Filtrar clientes sin customID
-			{"createdAt", bson.D{{"$gte", startDate}, {"$lte", endDate}}}, // Filtrar por rango de fechas
-		}}},
-		{{"$count", "total"}},
+	if params.RoomType == "" {
+		params.RoomType = models.RoomTypeGuest
 	}
-
-	cursor, err = clientCollection.Aggregate(context.Background(), guestWithoutIDCountPipeline)
-	if err != nil {
-		http.Error(w, "Failed to calculate total guests without customID", http.StatusInternalServerError)
-		return
+	if !isValidAnalyticsRoomType(params.RoomType) {
+		http.Error(w, "Invalid roomType, expected guest, rental or all", http.StatusBadRequest)
+		return params, false
 	}
+	return params, true
+}
 
-	var guestWithoutIDCountResult struct {
-		Total int `bson:"total"`
-	}
+func isValidAnalyticsRoomType(roomType string) bool {
+	return roomType == models.RoomTypeGuest || roomType == models.RoomTypeRental || roomType == services.AnalyticsRoomTypeAll
+}
 
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&guestWithoutIDCountResult); err != nil {
-			http.Error(w, "Failed to decode total guests without customID", http.StatusInternalServerError)
-			return
-		}
+// parseAnalyticsDate acepta YYYY-MM-DD o RFC3339 y devuelve el inicio de ese día en UTC
+func parseAnalyticsDate(value string) (time.Time, error) {
+	if date, err := parseReservationDate(value); err == nil {
+		return date, nil
 	}
-
-	// Construir la respuesta
-	response := AnalyticsResponse{
-		TotalPriceGuest: priceSumResult.TotalPrice,
-		Guest: struct {
-			Total int `json:"total"`
-		}{
-			Total: guestWithIDCountResult.Total,
-		},
-		Rental: struct {
-			Total int `json:"total"`
-		}{
-			Total: guestWithoutIDCountResult.Total,
-		},
-		TotalClients: int(clientTotalCount),
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
 }
@@ -26,6 +26,8 @@ type CreateClientHandler struct {
 	CloudinaryService      *services.CloudinaryService
 	GoogleDriveService     *services.GoogleDriveService
 	LocalFileSystemService *services.LocalFileSystemService
+	Pricing                *services.PricingService
+	Payments               *services.PaymentService
 }
 
 // Handle procesa la solicitud de creación de un nuevo cliente
@@ -56,8 +58,11 @@ func (h *CreateClientHandler) createRental(w http.ResponseWriter, r *http.Reques
 		NumeroCelular: r.FormValue("numeroCelular"),
 		CURP:          r.FormValue("curp"),
 		RoomNumber:    r.FormValue("RoomNumber"),
+		History:       []models.HistoryRecord{},
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
+		Estado:        r.FormValue("estado"),
+		RentalPrice:   parseFloat(r.FormValue("rentalPrice")),
 	}
 
 	// Subir archivos según el StorageSelector
@@ -142,13 +147,44 @@ func (h *CreateClientHandler) createGuest(w http.ResponseWriter, r *http.Request
 		RoomNumber:       r.FormValue("roomNumber"),
 		Price:            parseFloat(r.FormValue("price")),
 		Duration:         parseInt(r.FormValue("duration")),
+		DurationUnit:     r.FormValue("durationUnit"),
+		History:          []models.HistoryRecord{},
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}
+	if guest.DurationUnit == "" {
+		guest.DurationUnit = models.DurationNights
+	}
+	if guest.DurationUnit != models.DurationHours && guest.DurationUnit != models.DurationNights {
+		http.Error(w, "Invalid duration unit. Must be either 'hours' or 'nights'", http.StatusBadRequest)
+		return
+	}
 
-	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionClients)
-	_, err := collection.InsertOne(context.Background(), guest)
-	if err != nil {
+	// El precio sale de la cotización; si se captura otro precio se registra como cambio manual
+	if guest.RoomNumber != "" && guest.Duration > 0 {
+		quote, err := h.Pricing.Quote(r.Context(), guest.RoomNumber, guest.DurationUnit, guest.Duration, time.Now().UTC())
+		switch err {
+		case nil:
+			guest.QuotedPrice = &quote.Total
+			guest.RatePlans = quote.RatePlans
+			if r.FormValue("price") == "" || guest.Price == quote.Total {
+				guest.Price = quote.Total
+			} else {
+				guest.PriceOverriddenBy = actingUser(r)
+				guest.PriceOverrideReason = r.FormValue("priceOverrideReason")
+			}
+		case services.ErrRoomNotFound, services.ErrNoRatePlan:
+			// Sin tarifa aplicable se conserva el precio capturado
+		default:
+			log.Printf("Error cotizando la estancia del huésped: %v", err)
+			http.Error(w, "Failed to quote stay", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// El precio de la estancia queda como cargo del huésped para calcular su saldo
+	if err := h.Payments.CreateGuestWithStayCharge(r.Context(), &guest); err != nil {
+		log.Printf("Error creando el huésped %s: %v", guest.ID.Hex(), err)
 		http.Error(w, "Failed to create guest", http.StatusInternalServerError)
 		return
 	}
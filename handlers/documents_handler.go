@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DocumentsHandler genera y descarga los recibos de pago y los estados de cuenta en PDF
+type DocumentsHandler struct {
+	Documents              *services.DocumentService
+	GoogleDriveService     *services.GoogleDriveService
+	LocalFileSystemService *services.LocalFileSystemService
+}
+
+// GetReceiptHandler genera y descarga el recibo del pago indicado en ?id= sin guardarlo; si ya se guardó una copia
+// su URL va en el encabezado Content-Location
+func (h *DocumentsHandler) GetReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, content, err := h.Documents.Receipt(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to generate receipt")
+		return
+	}
+
+	writePDF(w, receiptFilename(payment.ID), payment.ReceiptURL, content)
+}
+
+// StoreReceiptHandler guarda en el almacenamiento configurado el recibo del pago indicado en ?id= y devuelve su URL.
+// Si el pago ya tiene un recibo guardado se devuelve el mismo; al anular el pago se descarta para guardar el anulado.
+func (h *DocumentsHandler) StoreReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, content, err := h.Documents.Receipt(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to generate receipt")
+		return
+	}
+
+	url := payment.ReceiptURL
+	if url == "" {
+		filename := receiptFilename(payment.ID)
+		url, err = services.StoreDocument(filename, content, h.LocalFileSystemService, h.GoogleDriveService)
+		if err != nil {
+			log.Printf("Error guardando el recibo %s: %v", filename, err)
+			http.Error(w, "Failed to store receipt", http.StatusInternalServerError)
+			return
+		}
+		if err := h.Documents.SaveReceiptURL(r.Context(), payment.ID, url); err != nil {
+			log.Printf("Error guardando la URL del recibo %s: %v", filename, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"receiptUrl": url})
+}
+
+// GetStatementHandler genera y descarga el estado de cuenta del huésped o inquilino indicado en ?id= sin guardarlo
+func (h *DocumentsHandler) GetStatementHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	content, err := h.Documents.Statement(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to generate statement")
+		return
+	}
+
+	writePDF(w, fmt.Sprintf("estado-de-cuenta-%s.pdf", id.Hex()), "", content)
+}
+
+// StoreStatementHandler guarda en el almacenamiento configurado una copia con fecha del estado de cuenta del
+// huésped o inquilino indicado en ?id= y devuelve su URL
+func (h *DocumentsHandler) StoreStatementHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	content, err := h.Documents.Statement(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to generate statement")
+		return
+	}
+
+	filename := fmt.Sprintf("estado-de-cuenta-%s-%s.pdf", id.Hex(), time.Now().Format("20060102150405"))
+	url, err := services.StoreDocument(filename, content, h.LocalFileSystemService, h.GoogleDriveService)
+	if err != nil {
+		log.Printf("Error guardando el estado de cuenta %s: %v", filename, err)
+		http.Error(w, "Failed to store statement", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"statementUrl": url})
+}
+
+func receiptFilename(id primitive.ObjectID) string {
+	return fmt.Sprintf("recibo-%s.pdf", id.Hex())
+}
+
+// writePDF responde con el PDF como descarga; url es la copia guardada, si existe
+func writePDF(w http.ResponseWriter, filename, url string, content []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if url != "" {
+		w.Header().Set("Content-Location", url)
+	}
+	w.Write(content)
+}
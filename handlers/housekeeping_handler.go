@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HousekeepingHandler maneja el tablero de tareas de limpieza
+type HousekeepingHandler struct {
+	Housekeeping *services.HousekeepingService
+}
+
+// GetTasksHandler lista las tareas filtrando por status, assignedTo y roomNumber.
+// Sin status se devuelven solo las tareas abiertas; con ?mine=true solo las asignadas al usuario autenticado.
+func (h *HousekeepingHandler) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := bson.M{}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	} else {
+		filter["status"] = bson.M{"$in": []string{models.HousekeepingPending, models.HousekeepingInProgress, models.HousekeepingFinished}}
+	}
+	if assignedTo := query.Get("assignedTo"); assignedTo != "" {
+		filter["assignedTo"] = assignedTo
+	}
+	if query.Get("mine") == "true" {
+		filter["assignedTo"] = actingUser(r)
+	}
+	if roomNumber := query.Get("roomNumber"); roomNumber != "" {
+		filter["roomNumber"] = roomNumber
+	}
+
+	tasks, err := h.Housekeeping.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get housekeeping tasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// CreateTaskHandler abre manualmente una tarea de limpieza para la habitación indicada por roomId o roomNumber
+func (h *HousekeepingHandler) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RoomID     string `json:"roomId"`
+		RoomNumber string `json:"roomNumber"`
+		Notes      string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	ref, ok := roomRef(w, payload.RoomID, payload.RoomNumber)
+	if !ok {
+		return
+	}
+
+	task, err := h.Housekeeping.Create(r.Context(), ref, payload.Notes)
+	if err != nil {
+		writeHousekeepingError(w, err, "Failed to create housekeeping task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(task)
+}
+
+// AssignTaskHandler asigna la tarea indicada en ?id= al usuario del cuerpo
+func (h *HousekeepingHandler) AssignTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		AssignedTo string `json:"assignedTo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.AssignedTo == "" {
+		http.Error(w, "assignedTo is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.Housekeeping.Assign(r.Context(), id, payload.AssignedTo, actingUser(r))
+	if err != nil {
+		writeHousekeepingError(w, err, "Failed to assign housekeeping task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// StartTaskHandler empieza la limpieza de la tarea indicada en ?id=
+func (h *HousekeepingHandler) StartTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.Housekeeping.Start(r.Context(), id, actingUser(r))
+	if err != nil {
+		writeHousekeepingError(w, err, "Failed to start housekeeping task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// FinishTaskHandler termina la limpieza de la tarea indicada en ?id= y la deja lista para inspección
+func (h *HousekeepingHandler) FinishTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Notes string `json:"notes"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}
+
+	task, err := h.Housekeeping.Finish(r.Context(), id, actingUser(r), payload.Notes)
+	if err != nil {
+		writeHousekeepingError(w, err, "Failed to finish housekeeping task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// InspectTaskHandler aprueba o rechaza la limpieza de la tarea indicada en ?id=
+func (h *HousekeepingHandler) InspectTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Passed *bool  `json:"passed"`
+		Notes  string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Passed == nil {
+		http.Error(w, "passed is required", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.Housekeeping.Inspect(r.Context(), id, *payload.Passed, actingUser(r), payload.Notes)
+	if err != nil {
+		writeHousekeepingError(w, err, "Failed to inspect housekeeping task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// taskID obtiene el ID de la tarea de ?id=; si es inválido responde 400
+func taskID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return id, false
+	}
+	return id, true
+}
+
+// writeHousekeepingError traduce los errores del HousekeepingService a respuestas HTTP
+func writeHousekeepingError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrTaskNotFound:
+		http.Error(w, "Housekeeping task not found", http.StatusNotFound)
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrAssigneeInvalid:
+		http.Error(w, "Assignee does not exist or is disabled", http.StatusBadRequest)
+	case services.ErrTaskNotAssignee:
+		http.Error(w, "Task is assigned to another user", http.StatusForbidden)
+	case services.ErrTaskExists:
+		http.Error(w, "Room already has an open housekeeping task", http.StatusConflict)
+	case services.ErrTaskState:
+		http.Error(w, "Task is not in the required state", http.StatusConflict)
+	case services.ErrRoomStatusTransition, services.ErrRoomOccupancyStatus:
+		http.Error(w, "Room status does not allow this step", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
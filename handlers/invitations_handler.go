@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvitationsHandler permite a los administradores emitir, listar y revocar invitaciones de registro
+type InvitationsHandler struct {
+	Invitations *services.InvitationStore
+	Roles       *services.RoleStore
+	Mailer      services.MailSender
+}
+
+// CreateInvitationHandler emite una invitación con rol preasignado y devuelve el token, que solo se muestra una vez.
+// Si se indica un correo, la invitación solo sirve para ese correo y se le envía el enlace de registro.
+func (h *InvitationsHandler) CreateInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Correo         string `json:"correo"`
+		Rol            string `json:"rol"`
+		ExpiresInHours int    `json:"expiresInHours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	payload.Correo = strings.TrimSpace(payload.Correo)
+	payload.Rol = strings.TrimSpace(payload.Rol)
+	if payload.Rol == "" {
+		http.Error(w, "Rol is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.Roles.Get(r.Context(), payload.Rol); err == services.ErrRoleNotFound {
+		http.Error(w, "Unknown role: "+payload.Rol, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to create invitation", http.StatusInternalServerError)
+		return
+	}
+
+	ttl := services.InvitationDefaultTTL
+	if payload.ExpiresInHours < 0 {
+		http.Error(w, "expiresInHours must be positive", http.StatusBadRequest)
+		return
+	}
+	if payload.ExpiresInHours > 0 {
+		ttl = time.Duration(payload.ExpiresInHours) * time.Hour
+	}
+	if ttl > services.InvitationMaxTTL {
+		http.Error(w, fmt.Sprintf("expiresInHours cannot exceed %d", int(services.InvitationMaxTTL.Hours())), http.StatusBadRequest)
+		return
+	}
+
+	var createdBy string
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		createdBy = claims.Username
+	}
+
+	invitation := models.Invitation{
+		Correo:    payload.Correo,
+		Rol:       payload.Rol,
+		CreatedBy: createdBy,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	token, err := h.Invitations.Create(r.Context(), &invitation)
+	if err != nil {
+		http.Error(w, "Failed to create invitation", http.StatusInternalServerError)
+		return
+	}
+
+	link := invitationLink(token)
+	if invitation.Correo != "" {
+		if err := h.sendInvitationMail(r, invitation, link); err != nil {
+			log.Printf("Error enviando la invitación a %s: %v", invitation.Correo, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invitation": invitation,
+		"token":      token,
+		"link":       link,
+	})
+}
+
+func (h *InvitationsHandler) sendInvitationMail(r *http.Request, invitation models.Invitation, link string) error {
+	body := fmt.Sprintf("Hola,\n\nFuiste invitado a unirte a Hotelman con el rol %s.\n"+
+		"Abre el siguiente enlace para crear tu cuenta; es válido hasta el %s y solo se puede usar una vez:\n\n%s\n",
+		invitation.Rol, invitation.ExpiresAt.Format("02/01/2006 15:04"), link)
+
+	return h.Mailer.Send(r.Context(), invitation.Correo, "Invitación a Hotelman", body)
+}
+
+// invitationLink construye el enlace de registro a partir del primer origen configurado del frontend
+func invitationLink(token string) string {
+	frontendURL := strings.TrimRight(strings.Split(constants.FrontendURL, ",")[0], "/")
+	return fmt.Sprintf("%s/signup?invitacion=%s", frontendURL, url.QueryEscape(token))
+}
+
+// GetInvitationsHandler lista las invitaciones pendientes de usar
+func (h *InvitationsHandler) GetInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.Invitations.ListPending(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// RevokeInvitationHandler revoca la invitación pendiente indicada en ?id=
+func (h *InvitationsHandler) RevokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.Invitations.Revoke(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to revoke invitation", http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		http.Error(w, "Pending invitation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Invitation revoked successfully"})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvoicesHandler maneja los datos fiscales de los clientes y la emisión de facturas CFDI
+type InvoicesHandler struct {
+	Invoices               *services.InvoiceService
+	GoogleDriveService     *services.GoogleDriveService
+	LocalFileSystemService *services.LocalFileSystemService
+}
+
+var (
+	rfcPattern           = regexp.MustCompile(`^[A-ZÑ&]{3,4}[0-9]{6}[A-Z0-9]{3}$`)
+	regimenFiscalPattern = regexp.MustCompile(`^[0-9]{3}$`)
+	codigoPostalPattern  = regexp.MustCompile(`^[0-9]{5}$`)
+	usoCFDIPattern       = regexp.MustCompile(`^[A-Z]{1,2}[0-9]{2}$`)
+)
+
+// UpdateFiscalDataHandler guarda los datos fiscales del huésped o inquilino indicado en ?id=
+func (h *InvoicesHandler) UpdateFiscalDataHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	var data models.FiscalData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	data.RFC = strings.ToUpper(strings.TrimSpace(data.RFC))
+	data.RazonSocial = strings.ToUpper(strings.TrimSpace(data.RazonSocial))
+	data.UsoCFDI = strings.ToUpper(strings.TrimSpace(data.UsoCFDI))
+	if !validateFiscalData(w, data) {
+		return
+	}
+
+	if err := h.Invoices.SetFiscalData(r.Context(), id, data); err != nil {
+		writeInvoiceError(w, err, "Failed to update fiscal data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// CreateInvoiceHandler factura los pagos indicados del cliente, guarda el XML timbrado y su PDF en el
+// almacenamiento configurado y devuelve la factura
+func (h *InvoicesHandler) CreateInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID   string   `json:"clientId"`
+		PaymentIDs []string `json:"paymentIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := primitive.ObjectIDFromHex(payload.ClientID)
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	if len(payload.PaymentIDs) == 0 {
+		http.Error(w, "At least one payment is required", http.StatusBadRequest)
+		return
+	}
+	paymentIDs := []primitive.ObjectID{}
+	for _, value := range payload.PaymentIDs {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+			return
+		}
+		paymentIDs = append(paymentIDs, id)
+	}
+
+	invoice, err := h.Invoices.Issue(r.Context(), clientID, paymentIDs, actingUser(r))
+	if err != nil {
+		writeInvoiceError(w, err, "Failed to issue invoice")
+		return
+	}
+
+	// La factura ya está timbrada; si los archivos no se pueden guardar se pueden volver a generar desde ella
+	pdf, err := h.Invoices.PDF(invoice)
+	if err != nil {
+		log.Printf("Error generando el PDF de la factura %s: %v", invoice.UUID, err)
+	} else {
+		name := fmt.Sprintf("factura-%s-%d", invoice.Serie, invoice.Folio)
+		xmlURL, xmlErr := services.StoreDocument(name+".xml", []byte(invoice.XML), h.LocalFileSystemService, h.GoogleDriveService)
+		pdfURL, pdfErr := services.StoreDocument(name+".pdf", pdf, h.LocalFileSystemService, h.GoogleDriveService)
+		if xmlErr != nil || pdfErr != nil {
+			log.Printf("Error guardando los archivos de la factura %s: %v %v", invoice.UUID, xmlErr, pdfErr)
+		}
+		invoice.XMLURL, invoice.PDFURL = xmlURL, pdfURL
+		if err := h.Invoices.AttachFiles(r.Context(), invoice.ID, xmlURL, pdfURL); err != nil {
+			log.Printf("Error guardando las URLs de la factura %s: %v", invoice.UUID, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoicesHandler lista las facturas filtrando por clientId y status
+func (h *InvoicesHandler) GetInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := bson.M{}
+	if clientID := query.Get("clientId"); clientID != "" {
+		id, err := primitive.ObjectIDFromHex(clientID)
+		if err != nil {
+			http.Error(w, "Invalid client ID", http.StatusBadRequest)
+			return
+		}
+		filter["clientId"] = id
+	}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	invoices, err := h.Invoices.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get invoices", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invoices)
+}
+
+// GetInvoiceXMLHandler descarga el XML timbrado de la factura indicada en ?id=
+func (h *InvoicesHandler) GetInvoiceXMLHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return
+	}
+
+	invoice, err := h.Invoices.Get(r.Context(), id)
+	if err != nil {
+		writeInvoiceError(w, err, "Failed to get invoice")
+		return
+	}
+	if invoice.Status != models.InvoiceStamped {
+		http.Error(w, "Invoice is not stamped yet", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.UUID+".xml"))
+	w.Write([]byte(invoice.XML))
+}
+
+// validateFiscalData valida el formato de los datos fiscales; si son inválidos responde 400
+func validateFiscalData(w http.ResponseWriter, data models.FiscalData) bool {
+	switch {
+	case !rfcPattern.MatchString(data.RFC):
+		http.Error(w, "Invalid RFC", http.StatusBadRequest)
+	case data.RazonSocial == "":
+		http.Error(w, "razonSocial is required", http.StatusBadRequest)
+	case !regimenFiscalPattern.MatchString(data.RegimenFiscal):
+		http.Error(w, "regimenFiscal must be a 3 digit SAT code", http.StatusBadRequest)
+	case !codigoPostalPattern.MatchString(data.CodigoPostal):
+		http.Error(w, "codigoPostal must have 5 digits", http.StatusBadRequest)
+	case !usoCFDIPattern.MatchString(data.UsoCFDI):
+		http.Error(w, "Invalid usoCfdi", http.StatusBadRequest)
+	default:
+		return true
+	}
+	return false
+}
+
+// writeInvoiceError traduce los errores del InvoiceService a respuestas HTTP
+func writeInvoiceError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrClientNotFound:
+		http.Error(w, "Client not found", http.StatusNotFound)
+	case services.ErrInvoiceNotFound:
+		http.Error(w, "Invoice not found", http.StatusNotFound)
+	case services.ErrFiscalDataMissing:
+		http.Error(w, "Client has no fiscal data", http.StatusUnprocessableEntity)
+	case services.ErrIssuerNotConfigured:
+		http.Error(w, "Invoice issuer is not configured", http.StatusServiceUnavailable)
+	case services.ErrPaymentNotInvoiceable:
+		http.Error(w, "Some payments do not exist, belong to another client, are voided or are already invoiced", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
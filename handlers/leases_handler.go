@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LeasesHandler maneja los contratos de arrendamiento de los inquilinos
+type LeasesHandler struct {
+	Leases  *services.LeaseService
+	Overdue *services.OverdueService
+}
+
+// CreateLeaseHandler crea el contrato de un inquilino y genera su calendario de cargos
+func (h *LeasesHandler) CreateLeaseHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RentalID      string  `json:"rentalId"`
+		RoomNumber    string  `json:"roomNumber"`
+		StartDate     string  `json:"startDate"`
+		EndDate       string  `json:"endDate"`
+		MonthlyAmount float64 `json:"monthlyAmount"`
+		DueDay        int     `json:"dueDay"`
+		Deposit       float64 `json:"deposit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	rentalID, err := primitive.ObjectIDFromHex(payload.RentalID)
+	if err != nil {
+		http.Error(w, "Invalid rental ID", http.StatusBadRequest)
+		return
+	}
+	startDate, err := parseReservationDate(payload.StartDate)
+	if err != nil {
+		http.Error(w, "Invalid startDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	endDate, err := parseReservationDate(payload.EndDate)
+	if err != nil {
+		http.Error(w, "Invalid endDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !validateLeaseTerms(w, &payload.MonthlyAmount, &payload.DueDay) {
+		return
+	}
+	if payload.Deposit < 0 {
+		http.Error(w, "Deposit cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	lease := models.Lease{
+		RentalID:      rentalID,
+		RoomNumber:    payload.RoomNumber,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		MonthlyAmount: payload.MonthlyAmount,
+		DueDay:        payload.DueDay,
+		Deposit:       payload.Deposit,
+		CreatedBy:     actingUser(r),
+	}
+	if err := h.Leases.Create(r.Context(), &lease); err != nil {
+		writeLeaseError(w, err, "Failed to create lease")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(lease)
+}
+
+// GetLeasesHandler lista los contratos filtrando por rentalId, roomNumber y status
+func (h *LeasesHandler) GetLeasesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := bson.M{}
+	if rentalID := query.Get("rentalId"); rentalID != "" {
+		id, err := primitive.ObjectIDFromHex(rentalID)
+		if err != nil {
+			http.Error(w, "Invalid rental ID", http.StatusBadRequest)
+			return
+		}
+		filter["rentalId"] = id
+	}
+	if roomNumber := query.Get("roomNumber"); roomNumber != "" {
+		filter["roomNumber"] = roomNumber
+	}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	leases, err := h.Leases.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get leases", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leases)
+}
+
+// GetLeaseHandler devuelve el contrato indicado en ?id= con su calendario de cargos
+func (h *LeasesHandler) GetLeaseHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := leaseID(w, r)
+	if !ok {
+		return
+	}
+
+	lease, charges, err := h.Leases.Get(r.Context(), id)
+	if err != nil {
+		writeLeaseError(w, err, "Failed to get lease")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"lease":   lease,
+		"charges": charges,
+	})
+}
+
+// RenewLeaseHandler renueva el contrato indicado en ?id= hasta endDate, opcionalmente con otra renta o día de pago
+func (h *LeasesHandler) RenewLeaseHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := leaseID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		EndDate       string   `json:"endDate"`
+		MonthlyAmount *float64 `json:"monthlyAmount"`
+		DueDay        *int     `json:"dueDay"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	endDate, err := parseReservationDate(payload.EndDate)
+	if err != nil {
+		http.Error(w, "Invalid endDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !validateLeaseTerms(w, payload.MonthlyAmount, payload.DueDay) {
+		return
+	}
+
+	lease, err := h.Leases.Renew(r.Context(), id, services.LeaseRenewal{
+		EndDate:       endDate,
+		MonthlyAmount: payload.MonthlyAmount,
+		DueDay:        payload.DueDay,
+	}, actingUser(r))
+	if err != nil {
+		writeLeaseError(w, err, "Failed to renew lease")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(lease)
+}
+
+// TerminateLeaseHandler termina el contrato indicado en ?id= en la fecha del cuerpo (por defecto hoy)
+func (h *LeasesHandler) TerminateLeaseHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := leaseID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Date   string `json:"date"`
+		Reason string `json:"reason"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}
+
+	now := time.Now().UTC()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if payload.Date != "" {
+		var err error
+		date, err = parseReservationDate(payload.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lease, err := h.Leases.Terminate(r.Context(), id, date, payload.Reason, actingUser(r))
+	if err != nil {
+		writeLeaseError(w, err, "Failed to terminate lease")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lease)
+}
+
+// GetOverdueTenantsHandler lista los inquilinos con rentas vencidas, del mayor atraso al menor y, a igual
+// atraso, del que más debe al que menos
+func (h *LeasesHandler) GetOverdueTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.Overdue.Overdue(r.Context(), time.Now())
+	if err != nil {
+		http.Error(w, "Failed to get overdue tenants", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenants)
+}
+
+// ScanOverdueHandler revisa en el momento las rentas vencidas sin esperar al proceso en segundo plano
+func (h *LeasesHandler) ScanOverdueHandler(w http.ResponseWriter, r *http.Request) {
+	marked, err := h.Overdue.Scan(r.Context(), time.Now())
+	if err != nil {
+		http.Error(w, "Failed to scan overdue charges", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"marked": marked})
+}
+
+// validateLeaseTerms valida la renta mensual y el día de pago que no sean nil; el día llega hasta 28 para que
+// exista en todos los meses
+func validateLeaseTerms(w http.ResponseWriter, monthlyAmount *float64, dueDay *int) bool {
+	if monthlyAmount != nil && *monthlyAmount <= 0 {
+		http.Error(w, "Monthly amount must be greater than zero", http.StatusBadRequest)
+		return false
+	}
+	if dueDay != nil && (*dueDay < 1 || *dueDay > 28) {
+		http.Error(w, "Due day must be between 1 and 28", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// leaseID obtiene el ID del contrato de ?id=; si es inválido responde 400
+func leaseID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid lease ID", http.StatusBadRequest)
+		return id, false
+	}
+	return id, true
+}
+
+// writeLeaseError traduce los errores del LeaseService a respuestas HTTP
+func writeLeaseError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrLeaseNotFound:
+		http.Error(w, "Lease not found", http.StatusNotFound)
+	case services.ErrRentalNotFound:
+		http.Error(w, "Rental not found", http.StatusNotFound)
+	case services.ErrLeaseActive:
+		http.Error(w, "Rental already has an active lease for those dates", http.StatusConflict)
+	case services.ErrLeaseClosed:
+		http.Error(w, "Lease is no longer active", http.StatusConflict)
+	case services.ErrLeaseDates:
+		http.Error(w, "Invalid lease dates", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -2,31 +2,42 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
-	"sync"
 	"time"
 
+	"hotelman-backend/auth"
 	"hotelman-backend/constants"
 	"hotelman-backend/models"
+	"hotelman-backend/services"
+	"hotelman-backend/utils"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// refreshTokenTTL es la vigencia de la sesión y de su familia de refresh tokens
+const refreshTokenTTL = 7 * 24 * time.Hour
+
 type LoginHandler struct {
-	Client   *mongo.Client
-	jwtKey   []byte
-	TokenMap *sync.Map // Mapa sincronizado para almacenar tokens activos
+	Client        *mongo.Client
+	Tokens        *auth.TokenManager
+	Sessions      *services.SessionStore      // Sesiones persistentes asociadas a cada login
+	RefreshTokens *services.RefreshTokenStore // Refresh tokens rotativos de cada sesión
+	Throttle      *services.LoginThrottle     // Protección contra fuerza bruta y registro de intentos
 }
 
-func NewLoginHandler(client *mongo.Client, jwtKey []byte) *LoginHandler {
+func NewLoginHandler(client *mongo.Client, tokens *auth.TokenManager, sessions *services.SessionStore, refreshTokens *services.RefreshTokenStore, throttle *services.LoginThrottle) *LoginHandler {
 	return &LoginHandler{
-		Client:   client,
-		jwtKey:   jwtKey,
-		TokenMap: &sync.Map{},
+		Client:        client,
+		Tokens:        tokens,
+		Sessions:      sessions,
+		RefreshTokens: refreshTokens,
+		Throttle:      throttle,
 	}
 }
 
@@ -38,136 +49,202 @@ func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verificar si ya hay un token válido para el usuario
-	if tokenString, ok := h.getTokenFromMap(creds.Username); ok {
-		// Si hay un token válido, establecerlo en la cookie y devolverlo sin generar uno nuevo
-		http.SetCookie(w, &http.Cookie{
-			Name:     "Authorize",
-			Value:    tokenString,
-			Expires:  time.Now().Add(7 * 24 * time.Hour),
-			HttpOnly: false,
-		})
-		response := map[string]interface{}{
-			"token":  tokenString,
-			"claims": extractClaimsFromToken(tokenString),
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
 	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
 	var storedUser models.User
 
 	// Intentar buscar por correo electrónico
+	found := true
 	err = collection.FindOne(r.Context(), bson.M{"correo": creds.Username}).Decode(&storedUser)
 	if err != nil {
 		// Intentar buscar por CURP si no se encontró por correo
 		err = collection.FindOne(r.Context(), bson.M{"curp": creds.Username}).Decode(&storedUser)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+			found = false
 		}
 	}
 
+	// Los contadores de la cuenta usan el correo del usuario si existe, para que correo y CURP compartan límite
+	account := creds.Username
+	if found {
+		account = storedUser.Correo
+	}
+	ip := utils.ClientIP(r)
+	if !h.allowAttempt(w, r, account, ip) {
+		return
+	}
+
 	// Verificar la contraseña
-	if bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(creds.Password)) != nil {
+	if !found || bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(creds.Password)) != nil {
+		h.recordFailure(r, account, ip, models.LoginOutcomeFailure)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	// Obtener el token existente o generar uno nuevo si es necesario
-	tokenString, err := h.getOrCreateToken(storedUser)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if storedUser.Disabled {
+		h.rejectDisabled(w, r, storedUser)
 		return
 	}
 
-	// Establecer el token JWT en una cookie HttpOnly
-	http.SetCookie(w, &http.Cookie{
-		Name:     "Authorize",
-		Value:    tokenString,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-		HttpOnly: false,
-	})
-
-	// Responder con el token JWT y las claims en la respuesta JSON
-	response := map[string]interface{}{
-		"token":  tokenString,
-		"claims": extractClaimsFromToken(tokenString),
+	// Segundo factor: si el usuario tiene TOTP, o la política lo exige y aún no lo registró,
+	// se responde con un token intermedio en lugar de abrir la sesión
+	if storedUser.TOTPEnabled || requiresTOTP(storedUser) {
+		h.logAttempt(r, storedUser.Correo, ip, models.LoginOutcomeMFAPending)
+		if storedUser.TOTPEnabled {
+			h.writeMFAChallenge(w, storedUser, auth.MFAPurposeVerify)
+		} else {
+			h.writeMFAChallenge(w, storedUser, auth.MFAPurposeEnroll)
+		}
+		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+
+	h.completeLogin(w, r, storedUser, nil)
 }
 
-// Función auxiliar para obtener un token válido del mapa o crear uno nuevo
-func (h *LoginHandler) getOrCreateToken(user models.User) (string, error) {
-	// Verificar si ya hay un token válido para el usuario
-	if tokenString, ok := h.getTokenFromMap(user.Correo); ok {
-		// Verificar validez del token
-		claims := extractClaimsFromToken(tokenString)
-		if claims != nil && claims.ExpiresAt > time.Now().Unix() {
-			return tokenString, nil // Devolver el token válido existente
-		}
-		// Eliminar token expirado del mapa
-		h.TokenMap.Delete(user.Correo)
+// completeLogin abre una sesión para el usuario ya autenticado y responde con el par de tokens.
+// extra permite agregar campos a la respuesta JSON (p. ej. códigos de recuperación recién generados).
+func (h *LoginHandler) completeLogin(w http.ResponseWriter, r *http.Request, user models.User, extra map[string]interface{}) {
+	// Una cuenta desactivada no puede abrir sesión aunque haya superado el segundo factor
+	if user.Disabled {
+		h.rejectDisabled(w, r, user)
+		return
 	}
 
-	// Generar un nuevo TokenID aleatorio usando UUID
+	// Registrar una nueva sesión; su TokenID identifica también a la familia de refresh tokens
 	tokenID := uuid.New().String()
+	refreshExpiration := time.Now().Add(refreshTokenTTL)
+	session := &models.Session{
+		TokenID:   tokenID,
+		Username:  user.Correo,
+		Role:      user.Rol,
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		ExpiresAt: refreshExpiration,
+	}
+	if err := h.Sessions.Create(r.Context(), session); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// El contador de fallos de la cuenta solo se reinicia cuando se completa el login, incluido el segundo factor
+	if err := h.Throttle.RecordSuccess(r.Context(), user.Correo); err != nil {
+		log.Printf("Error reiniciando los fallos de login de %s: %v", user.Correo, err)
+	}
 
-	// Generar un nuevo token JWT
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
-	claims := &models.Claims{
-		TokenID:  tokenID, // Asignar TokenID aleatorio
-		Username: user.Correo,
-		Role:     user.Rol,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+	accessToken, claims, err := h.Tokens.Issue(user.Correo, user.Rol, tokenID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	refreshToken, err := h.RefreshTokens.Issue(r.Context(), tokenID, user.Correo, user.Rol, refreshExpiration)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(h.jwtKey)
+	h.logAttempt(r, user.Correo, utils.ClientIP(r), models.LoginOutcomeSuccess)
+	writeTokenPair(w, accessToken, claims, refreshToken, refreshExpiration, extra)
+}
+
+// rejectDisabled registra el intento y responde 403 a una cuenta desactivada
+func (h *LoginHandler) rejectDisabled(w http.ResponseWriter, r *http.Request, user models.User) {
+	h.logAttempt(r, user.Correo, utils.ClientIP(r), models.LoginOutcomeDisabled)
+	http.Error(w, "Account is disabled", http.StatusForbidden)
+}
+
+// allowAttempt rechaza el intento con 429 (retardo progresivo) o 423 (bloqueo) si la cuenta o la IP lo exigen
+func (h *LoginHandler) allowAttempt(w http.ResponseWriter, r *http.Request, account, ip string) bool {
+	wait, locked, err := h.Throttle.Check(r.Context(), account, ip)
 	if err != nil {
-		return "", err
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if wait <= 0 {
+		return true
 	}
 
-	// Almacenar el nuevo token en el mapa de tokens activos
-	h.TokenMap.Store(user.Correo, tokenString)
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	if locked {
+		h.logAttempt(r, account, ip, models.LoginOutcomeLocked)
+		http.Error(w, "Account temporarily locked due to too many failed attempts", http.StatusLocked)
+	} else {
+		h.logAttempt(r, account, ip, models.LoginOutcomeThrottled)
+		http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+	}
+	return false
+}
 
-	return tokenString, nil
+// recordFailure suma el fallo a los contadores y lo registra en el historial de intentos
+func (h *LoginHandler) recordFailure(r *http.Request, account, ip, outcome string) {
+	if err := h.Throttle.RecordFailure(r.Context(), account, ip); err != nil {
+		log.Printf("Error registrando el fallo de login de %s: %v", account, err)
+	}
+	h.logAttempt(r, account, ip, outcome)
 }
 
-// Función auxiliar para obtener un token válido del mapa
-func (h *LoginHandler) getTokenFromMap(username string) (string, bool) {
-	var tokenString string
-	var found bool
+// logAttempt guarda el intento de login; un error aquí no debe impedir la respuesta
+func (h *LoginHandler) logAttempt(r *http.Request, account, ip, outcome string) {
+	if err := h.Throttle.LogAttempt(r.Context(), account, ip, r.UserAgent(), outcome); err != nil {
+		log.Printf("Error registrando el intento de login de %s: %v", account, err)
+	}
+}
 
-	h.TokenMap.Range(func(key, value interface{}) bool {
-		t := value.(string)
-		claims := extractClaimsFromToken(t)
+// writeMFAChallenge responde con el token intermedio que exige el segundo paso del login
+func (h *LoginHandler) writeMFAChallenge(w http.ResponseWriter, user models.User, purpose string) {
+	mfaToken, err := h.Tokens.IssueMFA(user.Correo, user.Rol, purpose)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-		if claims != nil && claims.Username == username {
-			tokenString = t
-			found = true
-			return false // Detener el rango
-		}
-		return true // Continuar el rango
-	})
+	response := map[string]interface{}{
+		"mfaToken":  mfaToken,
+		"expiresIn": int(auth.MFATokenTTL.Seconds()),
+	}
+	if purpose == auth.MFAPurposeEnroll {
+		response["mfaEnrollmentRequired"] = true
+	} else {
+		response["mfaRequired"] = true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	return tokenString, found
+// requiresTOTP indica si la política obliga al usuario a usar TOTP
+func requiresTOTP(user models.User) bool {
+	return constants.RequireAdminTOTP && user.Rol == auth.RoleAdministracion
 }
 
-// Función auxiliar para extraer los claims de un token JWT
-func extractClaimsFromToken(tokenString string) *models.Claims {
-	token, _ := jwt.ParseWithClaims(tokenString, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(constants.JWTSecretKey), nil
+// writeTokenPair establece las cookies de acceso y refresh y responde con ambos tokens en JSON
+func writeTokenPair(w http.ResponseWriter, accessToken string, claims *auth.Claims, refreshToken string, refreshExpiration time.Time, extra map[string]interface{}) {
+	// Establecer el token JWT en la cookie Authorize
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Authorize",
+		Value:    accessToken,
+		Expires:  time.Unix(claims.ExpiresAt, 0),
+		HttpOnly: false,
+	})
+
+	// El refresh token solo viaja en una cookie HttpOnly
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Refresh",
+		Value:    refreshToken,
+		Expires:  refreshExpiration,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
 
-	if claims, ok := token.Claims.(*models.Claims); ok && token.Valid {
-		return claims
+	// Responder con los tokens y las claims en la respuesta JSON
+	response := map[string]interface{}{
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(auth.AccessTokenTTL.Seconds()),
+		"claims":       claims,
 	}
-
-	return nil
+	for key, value := range extra {
+		response[key] = value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
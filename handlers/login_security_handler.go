@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LoginSecurityHandler permite a los administradores auditar intentos de login y desbloquear cuentas o IPs
+type LoginSecurityHandler struct {
+	Throttle *services.LoginThrottle
+}
+
+// GetLoginAttemptsHandler lista los intentos de login filtrando por username, ip, outcome, startDate y endDate
+func (h *LoginSecurityHandler) GetLoginAttemptsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 50 // Default page size
+	}
+
+	filter := bson.M{}
+	if username := query.Get("username"); username != "" {
+		filter["username"] = username
+	}
+	if ip := query.Get("ip"); ip != "" {
+		filter["ip"] = ip
+	}
+	if outcome := query.Get("outcome"); outcome != "" {
+		filter["outcome"] = outcome
+	}
+
+	createdAt := bson.M{}
+	if startDateStr := query.Get("startDate"); startDateStr != "" {
+		startDate, err := time.Parse(time.RFC3339, startDateStr)
+		if err != nil {
+			http.Error(w, "Invalid startDate format", http.StatusBadRequest)
+			return
+		}
+		createdAt["$gte"] = startDate
+	}
+	if endDateStr := query.Get("endDate"); endDateStr != "" {
+		endDate, err := time.Parse(time.RFC3339, endDateStr)
+		if err != nil {
+			http.Error(w, "Invalid endDate format", http.StatusBadRequest)
+			return
+		}
+		createdAt["$lte"] = endDate
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	attempts, total, err := h.Throttle.ListAttempts(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve login attempts", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attempts":   attempts,
+		"totalPages": totalPages,
+	})
+}
+
+// GetLockoutsHandler lista las cuentas e IPs bloqueadas actualmente
+func (h *LoginSecurityHandler) GetLockoutsHandler(w http.ResponseWriter, r *http.Request) {
+	locked, err := h.Throttle.ListLocked(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve lockouts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locked)
+}
+
+// UnlockHandler desbloquea la cuenta indicada en ?username= o la IP indicada en ?ip=
+func (h *LoginSecurityHandler) UnlockHandler(w http.ResponseWriter, r *http.Request) {
+	var key string
+	if username := r.URL.Query().Get("username"); username != "" {
+		key = services.AccountKey(username)
+	} else if ip := r.URL.Query().Get("ip"); ip != "" {
+		key = services.IPKey(ip)
+	} else {
+		http.Error(w, "username or ip is required", http.StatusBadRequest)
+		return
+	}
+
+	unlocked, err := h.Throttle.Unlock(r.Context(), key)
+	if err != nil {
+		http.Error(w, "Failed to unlock", http.StatusInternalServerError)
+		return
+	}
+	if !unlocked {
+		http.Error(w, "No lockout found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Unlocked successfully"})
+}
@@ -1,12 +1,44 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/services"
 )
 
-type LogoutHandler struct{}
+type LogoutHandler struct {
+	Tokens        *auth.TokenManager
+	Sessions      *services.SessionStore
+	RefreshTokens *services.RefreshTokenStore
+}
 
 func (h *LogoutHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	// Identificar la sesión por el token de acceso o, si ya expiró, por el refresh token
+	var tokenID string
+	if claims, err := h.Tokens.Parse(auth.TokenFromRequest(r)); err == nil {
+		tokenID = claims.TokenID
+	} else if cookie, err := r.Cookie("Refresh"); err == nil {
+		if record, err := h.RefreshTokens.Find(r.Context(), cookie.Value); err == nil {
+			tokenID = record.FamilyID
+		}
+	}
+
+	// Revocar la sesión y su familia de refresh tokens, si existe
+	if tokenID != "" {
+		if _, err := h.Sessions.Revoke(r.Context(), tokenID); err != nil {
+			log.Printf("Error revocando la sesión %s: %v", tokenID, err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+		if err := h.RefreshTokens.RevokeFamily(r.Context(), tokenID); err != nil {
+			log.Printf("Error revocando los refresh tokens de la sesión %s: %v", tokenID, err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Establecer la cookie con el nombre "Authorize" con un valor vacío y una edad máxima de -1 para eliminarla
 	http.SetCookie(w, &http.Cookie{
 		Name:     "Authorize",          // Nombre correcto de la cookie
@@ -18,6 +50,15 @@ func (h *LogoutHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode, // Ajusta la política SameSite según sea necesario
 	})
 
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Refresh",
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
 	// Configurar el encabezado de tipo de contenido
 	w.Header().Set("Content-Type", "text/plain")
 	// Configurar el código de estado
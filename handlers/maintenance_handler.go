@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaintenanceHandler maneja los tickets de mantenimiento de las habitaciones
+type MaintenanceHandler struct {
+	Maintenance            *services.MaintenanceService
+	CloudinaryService      *services.CloudinaryService
+	LocalFileSystemService *services.LocalFileSystemService
+}
+
+// CreateTicketHandler abre un ticket a partir de un formulario multipart; las fotos se envían en el campo "photos"
+func (h *MaintenanceHandler) CreateTicketHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(20 << 20) // Limit to 20 MB
+	if err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	ref, ok := roomRef(w, r.FormValue("roomId"), r.FormValue("roomNumber"))
+	if !ok {
+		return
+	}
+
+	ticket := models.MaintenanceTicket{
+		Title:       strings.TrimSpace(r.FormValue("title")),
+		Description: r.FormValue("description"),
+		Priority:    r.FormValue("priority"),
+		Blocking:    r.FormValue("blocking") == "true",
+		AssignedTo:  r.FormValue("assignedTo"),
+		ReportedBy:  actingUser(r),
+	}
+	if ticket.Title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = models.MaintenancePriorityMedium
+	}
+	if !models.IsValidMaintenancePriority(ticket.Priority) {
+		http.Error(w, "Invalid priority", http.StatusBadRequest)
+		return
+	}
+
+	ticket.Photos, err = uploadPhotos(r.MultipartForm.File["photos"], h.LocalFileSystemService, h.CloudinaryService.UploadMaintenancePhoto)
+	if err != nil {
+		log.Printf("Error subiendo las fotos del ticket: %v", err)
+		http.Error(w, "Error al subir las fotos", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Maintenance.Create(r.Context(), ref, &ticket); err != nil {
+		writeMaintenanceError(w, err, "Failed to create maintenance ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// UpdateTicketHandler modifica el ticket abierto indicado en ?id=
+func (h *MaintenanceHandler) UpdateTicketHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		Priority    *string `json:"priority"`
+		Blocking    *bool   `json:"blocking"`
+		Status      *string `json:"status"`
+		AssignedTo  *string `json:"assignedTo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.Priority != nil && !models.IsValidMaintenancePriority(*payload.Priority) {
+		http.Error(w, "Invalid priority", http.StatusBadRequest)
+		return
+	}
+	// El cierre tiene su propio endpoint para registrar la resolución
+	if payload.Status != nil && *payload.Status != models.MaintenanceOpen && *payload.Status != models.MaintenanceInProgress {
+		http.Error(w, "Invalid status, use /maintenance/tickets/close to close a ticket", http.StatusBadRequest)
+		return
+	}
+
+	ticket, err := h.Maintenance.Update(r.Context(), id, services.TicketChanges{
+		Title:       payload.Title,
+		Description: payload.Description,
+		Priority:    payload.Priority,
+		Blocking:    payload.Blocking,
+		Status:      payload.Status,
+		AssignedTo:  payload.AssignedTo,
+	}, actingUser(r))
+	if err != nil {
+		writeMaintenanceError(w, err, "Failed to update maintenance ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// CloseTicketHandler cierra el ticket indicado en ?id= con la resolución del cuerpo
+func (h *MaintenanceHandler) CloseTicketHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Resolution string `json:"resolution"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}
+
+	ticket, err := h.Maintenance.Close(r.Context(), id, payload.Resolution, actingUser(r))
+	if err != nil {
+		writeMaintenanceError(w, err, "Failed to close maintenance ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// AddPhotosHandler sube las fotos del campo "photos" y las agrega al ticket abierto indicado en ?id=
+func (h *MaintenanceHandler) AddPhotosHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := ticketID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseMultipartForm(20 << 20); err != nil { // Limit to 20 MB
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		http.Error(w, "At least one photo is required", http.StatusBadRequest)
+		return
+	}
+
+	urls, err := uploadPhotos(files, h.LocalFileSystemService, h.CloudinaryService.UploadMaintenancePhoto)
+	if err != nil {
+		log.Printf("Error subiendo las fotos del ticket %s: %v", id.Hex(), err)
+		http.Error(w, "Error al subir las fotos", http.StatusInternalServerError)
+		return
+	}
+
+	ticket, err := h.Maintenance.AddPhotos(r.Context(), id, urls)
+	if err != nil {
+		writeMaintenanceError(w, err, "Failed to add photos")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// GetTicketsHandler lista tickets filtrando por status, roomNumber, priority, assignedTo y blocking
+func (h *MaintenanceHandler) GetTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	filter := bson.M{}
+	for _, field := range []string{"status", "roomNumber", "priority", "assignedTo"} {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if blocking := query.Get("blocking"); blocking != "" {
+		filter["blocking"] = blocking == "true"
+	}
+
+	tickets, total, err := h.Maintenance.List(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve maintenance tickets", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tickets":    tickets,
+		"totalPages": totalPages,
+	})
+}
+
+// uploadPhotos sube las fotos al sistema de archivos local o, según constants.StorageSelector, con la función
+// de Cloudinary indicada, y devuelve sus URLs
+func uploadPhotos(files []*multipart.FileHeader, local *services.LocalFileSystemService, cloud func(multipart.File, *multipart.FileHeader) (string, error)) ([]string, error) {
+	urls := []string{}
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		var url string
+		if constants.StorageSelector == "local" {
+			url, err = local.UploadFileImage(file, header)
+		} else {
+			url, err = cloud(file, header)
+		}
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// ticketID obtiene el ID del ticket de ?id=; si es inválido responde 400
+func ticketID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid ticket ID", http.StatusBadRequest)
+		return id, false
+	}
+	return id, true
+}
+
+// writeMaintenanceError traduce los errores del MaintenanceService a respuestas HTTP
+func writeMaintenanceError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrTicketNotFound:
+		http.Error(w, "Maintenance ticket not found", http.StatusNotFound)
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrTicketClosed:
+		http.Error(w, "Maintenance ticket is closed", http.StatusConflict)
+	case services.ErrRoomStatusTransition:
+		http.Error(w, "Room status does not allow this change", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recoveryCodeCount es la cantidad de códigos de recuperación que se entregan al activar TOTP
+const recoveryCodeCount = 10
+
+var (
+	errInvalidMFACode   = errors.New("invalid verification code")
+	errTOTPAlreadyOn    = errors.New("TOTP is already enabled")
+	errTOTPNotPending   = errors.New("TOTP enrollment has not been started")
+	errTOTPNotEnabled   = errors.New("TOTP is not enabled")
+	errTOTPPolicyLocked = errors.New("TOTP is required for this role")
+)
+
+// MFAHandler maneja el registro de TOTP y el segundo paso del login
+type MFAHandler struct {
+	Client *mongo.Client
+	Tokens *auth.TokenManager
+	Login  *LoginHandler
+}
+
+type mfaPayload struct {
+	MFAToken     string `json:"mfaToken"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// VerifyLoginHandler completa el login con un código TOTP o de recuperación
+func (h *MFAHandler) VerifyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	payload, user, ok := h.pendingLogin(w, r, auth.MFAPurposeVerify)
+	if !ok {
+		return
+	}
+
+	// Los códigos incorrectos cuentan igual que una contraseña incorrecta
+	ip := utils.ClientIP(r)
+	if !h.Login.allowAttempt(w, r, user.Correo, ip) {
+		return
+	}
+	if err := h.verifySecondFactor(r.Context(), user, payload.Code, payload.RecoveryCode); err != nil {
+		if err == errInvalidMFACode {
+			h.Login.recordFailure(r, user.Correo, ip, models.LoginOutcomeMFAFailure)
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	h.Login.completeLogin(w, r, *user, nil)
+}
+
+// EnrollLoginHandler inicia el registro de TOTP para un usuario al que la política se lo exige al iniciar sesión
+func (h *MFAHandler) EnrollLoginHandler(w http.ResponseWriter, r *http.Request) {
+	_, user, ok := h.pendingLogin(w, r, auth.MFAPurposeEnroll)
+	if !ok {
+		return
+	}
+	h.writeEnrollment(w, r, user)
+}
+
+// ActivateLoginHandler confirma el registro de TOTP durante el login, entrega los códigos de recuperación y abre la sesión
+func (h *MFAHandler) ActivateLoginHandler(w http.ResponseWriter, r *http.Request) {
+	payload, user, ok := h.pendingLogin(w, r, auth.MFAPurposeEnroll)
+	if !ok {
+		return
+	}
+
+	ip := utils.ClientIP(r)
+	if !h.Login.allowAttempt(w, r, user.Correo, ip) {
+		return
+	}
+	codes, err := h.activateEnrollment(r.Context(), user, payload.Code)
+	if err != nil {
+		if err == errInvalidMFACode {
+			h.Login.recordFailure(r, user.Correo, ip, models.LoginOutcomeMFAFailure)
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	h.Login.completeLogin(w, r, *user, map[string]interface{}{"recoveryCodes": codes})
+}
+
+// EnrollHandler inicia el registro de TOTP para el usuario autenticado
+func (h *MFAHandler) EnrollHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	h.writeEnrollment(w, r, user)
+}
+
+// ActivateHandler confirma el registro de TOTP del usuario autenticado y devuelve sus códigos de recuperación
+func (h *MFAHandler) ActivateHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var payload mfaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.activateEnrollment(r.Context(), user, payload.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"recoveryCodes": codes})
+}
+
+// DisableHandler desactiva TOTP del usuario autenticado, salvo que la política lo exija para su rol
+func (h *MFAHandler) DisableHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var payload mfaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	if requiresTOTP(*user) {
+		writeMFAError(w, errTOTPPolicyLocked)
+		return
+	}
+	if err := h.verifySecondFactor(r.Context(), user, payload.Code, payload.RecoveryCode); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	_, err := h.users().UpdateOne(r.Context(),
+		bson.M{"correo": user.Correo},
+		bson.M{
+			"$set":   bson.M{"totpEnabled": false},
+			"$unset": bson.M{"totpSecret": "", "totpPendingSecret": "", "totpLastStep": "", "recoveryCodes": ""},
+		},
+	)
+	if err != nil {
+		http.Error(w, "Failed to disable TOTP", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "TOTP disabled successfully"})
+}
+
+// RegenerateRecoveryCodesHandler reemplaza los códigos de recuperación del usuario autenticado
+func (h *MFAHandler) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var payload mfaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	// Solo se aceptan códigos TOTP para generar nuevos códigos de recuperación
+	if err := h.verifySecondFactor(r.Context(), user, payload.Code, ""); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	_, err = h.users().UpdateOne(r.Context(), bson.M{"correo": user.Correo}, bson.M{"$set": bson.M{"recoveryCodes": hashes}})
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"recoveryCodes": codes})
+}
+
+func (h *MFAHandler) users() *mongo.Collection {
+	return h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
+}
+
+// pendingLogin decodifica el cuerpo, valida el token intermedio y carga al usuario
+func (h *MFAHandler) pendingLogin(w http.ResponseWriter, r *http.Request, purpose string) (*mfaPayload, *models.User, bool) {
+	var payload mfaPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	claims, err := h.Tokens.ParseMFA(payload.MFAToken, purpose)
+	if err != nil {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	var user models.User
+	if err := h.users().FindOne(r.Context(), bson.M{"correo": claims.Username}).Decode(&user); err != nil {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+	return &payload, &user, true
+}
+
+// currentUser carga al usuario autenticado por el middleware RequireAuth
+func (h *MFAHandler) currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.users().FindOne(r.Context(), bson.M{"correo": claims.Username}).Decode(&user); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &user, true
+}
+
+// writeEnrollment genera un secreto pendiente de confirmación y responde con la URI para el código QR
+func (h *MFAHandler) writeEnrollment(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if user.TOTPEnabled {
+		writeMFAError(w, errTOTPAlreadyOn)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		http.Error(w, "Failed to start TOTP enrollment", http.StatusInternalServerError)
+		return
+	}
+	_, err = h.users().UpdateOne(r.Context(), bson.M{"correo": user.Correo}, bson.M{"$set": bson.M{"totpPendingSecret": secret}})
+	if err != nil {
+		http.Error(w, "Failed to start TOTP enrollment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"secret":          secret,
+		"provisioningUri": auth.TOTPProvisioningURI(user.Correo, secret),
+	})
+}
+
+// activateEnrollment confirma el secreto pendiente con un código válido y genera los códigos de recuperación
+func (h *MFAHandler) activateEnrollment(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, errTOTPAlreadyOn
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, errTOTPNotPending
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPPendingSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, errInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.users().UpdateOne(ctx,
+		bson.M{"correo": user.Correo, "totpPendingSecret": user.TOTPPendingSecret},
+		bson.M{
+			"$set": bson.M{
+				"totpEnabled":   true,
+				"totpSecret":    user.TOTPPendingSecret,
+				"totpLastStep":  step,
+				"recoveryCodes": hashes,
+			},
+			"$unset": bson.M{"totpPendingSecret": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, errTOTPNotPending
+	}
+	return codes, nil
+}
+
+// verifySecondFactor valida un código TOTP (sin permitir reutilizarlo) o consume un código de recuperación
+func (h *MFAHandler) verifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if !user.TOTPEnabled {
+		return errTOTPNotEnabled
+	}
+
+	if code != "" {
+		step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return errInvalidMFACode
+		}
+		// Registrar el paso usado de forma atómica para que el mismo código no sirva dos veces
+		result, err := h.users().UpdateOne(ctx,
+			bson.M{"correo": user.Correo, "$or": bson.A{
+				bson.M{"totpLastStep": bson.M{"$lt": step}},
+				bson.M{"totpLastStep": bson.M{"$exists": false}},
+			}},
+			bson.M{"$set": bson.M{"totpLastStep": step}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return errInvalidMFACode
+		}
+		return nil
+	}
+
+	if recoveryCode != "" {
+		hash := auth.HashRecoveryCode(recoveryCode)
+		result, err := h.users().UpdateOne(ctx,
+			bson.M{"correo": user.Correo, "recoveryCodes": hash},
+			bson.M{"$pull": bson.M{"recoveryCodes": hash}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return errInvalidMFACode
+		}
+		return nil
+	}
+
+	return errInvalidMFACode
+}
+
+// newRecoveryCodes genera los códigos de recuperación y sus hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = auth.HashRecoveryCode(c)
+	}
+	return codes, hashes, nil
+}
+
+// writeMFAError traduce los errores del segundo factor a respuestas HTTP
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch err {
+	case errInvalidMFACode:
+		http.Error(w, "Invalid verification code", http.StatusUnauthorized)
+	case errTOTPAlreadyOn:
+		http.Error(w, "TOTP is already enabled", http.StatusConflict)
+	case errTOTPNotPending:
+		http.Error(w, "TOTP enrollment has not been started", http.StatusBadRequest)
+	case errTOTPNotEnabled:
+		http.Error(w, "TOTP is not enabled", http.StatusBadRequest)
+	case errTOTPPolicyLocked:
+		http.Error(w, "TOTP is required for this role", http.StatusForbidden)
+	default:
+		http.Error(w, "Failed to process verification", http.StatusInternalServerError)
+	}
+}
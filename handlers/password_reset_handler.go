@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+	"hotelman-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordResetHandler maneja el flujo de "olvidé mi contraseña"
+type PasswordResetHandler struct {
+	Client        *mongo.Client
+	Resets        *services.PasswordResetStore
+	Mailer        services.MailSender
+	Sessions      *services.SessionStore
+	RefreshTokens *services.RefreshTokenStore
+	Throttle      *services.LoginThrottle // Limita las solicitudes de restablecimiento por IP
+}
+
+// ForgotPasswordHandler genera un token de restablecimiento y lo envía por correo.
+// Siempre responde lo mismo para no revelar qué correos están registrados, aunque falle el envío,
+// y limita las solicitudes por IP con el mismo throttling que el login.
+func (h *PasswordResetHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Correo string `json:"correo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Correo == "" {
+		http.Error(w, "Correo is required", http.StatusBadRequest)
+		return
+	}
+
+	ip := utils.ClientIP(r)
+	wait, _, err := h.Throttle.CheckPasswordReset(r.Context(), ip)
+	if err != nil {
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	if err := h.Throttle.RecordPasswordReset(r.Context(), ip); err != nil {
+		log.Printf("Error registrando la solicitud de restablecimiento de %s: %v", ip, err)
+	}
+
+	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
+	var user models.User
+	err = collection.FindOne(r.Context(), bson.M{"correo": payload.Correo}).Decode(&user)
+	if err == nil {
+		if err := h.sendResetMail(r, user); err != nil {
+			log.Printf("Error enviando el correo de restablecimiento a %s: %v", user.Correo, err)
+		}
+	} else if err != mongo.ErrNoDocuments {
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Si el correo está registrado, recibirás instrucciones para restablecer tu contraseña"})
+}
+
+func (h *PasswordResetHandler) sendResetMail(r *http.Request, user models.User) error {
+	token, err := h.Resets.Create(r.Context(), user.Correo, utils.ClientIP(r))
+	if err != nil {
+		return err
+	}
+
+	// El enlace apunta al primer origen configurado del frontend
+	frontendURL := strings.TrimRight(strings.Split(constants.FrontendURL, ",")[0], "/")
+	link := fmt.Sprintf("%s/reset-password?token=%s", frontendURL, url.QueryEscape(token))
+
+	body := fmt.Sprintf("Hola %s,\n\nRecibimos una solicitud para restablecer tu contraseña de Hotelman.\n"+
+		"Abre el siguiente enlace para elegir una nueva contraseña; es válido por %d minutos y solo se puede usar una vez:\n\n%s\n\n"+
+		"Si no solicitaste este cambio puedes ignorar este correo.\n",
+		user.Nombres, int(services.PasswordResetTTL.Minutes()), link)
+
+	return h.Mailer.Send(r.Context(), user.Correo, "Restablecer contraseña de Hotelman", body)
+}
+
+// ResetPasswordHandler consume el token, actualiza la contraseña y cierra todas las sesiones del usuario
+func (h *PasswordResetHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token               string `json:"token"`
+		Contrasena          string `json:"contrasena"`
+		ConfirmarContrasena string `json:"confirmarContrasena"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.Contrasena == "" {
+		http.Error(w, "La contraseña es obligatoria", http.StatusBadRequest)
+		return
+	}
+	if payload.Contrasena != payload.ConfirmarContrasena {
+		http.Error(w, "Las contraseñas no coinciden", http.StatusBadRequest)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Contrasena), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
+		return
+	}
+
+	reset, err := h.Resets.Consume(r.Context(), payload.Token)
+	if err == services.ErrPasswordResetInvalid {
+		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
+	result, err := collection.UpdateOne(r.Context(),
+		bson.M{"correo": reset.Correo},
+		bson.M{"$set": bson.M{"password": string(hashedPassword)}},
+	)
+	if err != nil {
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	if result.MatchedCount == 0 {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Cerrar las sesiones abiertas con la contraseña anterior
+	if _, err := h.Sessions.RevokeAllForUser(r.Context(), reset.Correo); err != nil {
+		log.Printf("Error revocando las sesiones de %s: %v", reset.Correo, err)
+	}
+	if err := h.RefreshTokens.RevokeAllForUser(r.Context(), reset.Correo); err != nil {
+		log.Printf("Error revocando los refresh tokens de %s: %v", reset.Correo, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Contraseña actualizada con éxito"})
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentsHandler maneja el registro, la anulación y la consulta de pagos y saldos de clientes
+type PaymentsHandler struct {
+	Payments *services.PaymentService
+}
+
+// RegisterPaymentHandler registra un pago de un cliente, opcionalmente aplicado a uno de sus cargos
+func (h *PaymentsHandler) RegisterPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID  string  `json:"clientId"`
+		ChargeID  string  `json:"chargeId"`
+		Amount    float64 `json:"amount"`
+		Method    string  `json:"method"`
+		Reference string  `json:"reference"`
+		Notes     string  `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := primitive.ObjectIDFromHex(payload.ClientID)
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	if payload.Amount <= 0 {
+		http.Error(w, "Amount must be greater than zero", http.StatusBadRequest)
+		return
+	}
+	if !models.IsValidPaymentMethod(payload.Method) {
+		http.Error(w, "Invalid method. Must be one of cash, card or transfer", http.StatusBadRequest)
+		return
+	}
+
+	payment := models.Payment{
+		ClientID:   clientID,
+		Amount:     payload.Amount,
+		Method:     payload.Method,
+		Reference:  strings.TrimSpace(payload.Reference),
+		Notes:      payload.Notes,
+		ReceivedBy: actingUser(r),
+	}
+	if payload.ChargeID != "" {
+		chargeID, err := primitive.ObjectIDFromHex(payload.ChargeID)
+		if err != nil {
+			http.Error(w, "Invalid charge ID", http.StatusBadRequest)
+			return
+		}
+		payment.ChargeID = &chargeID
+	}
+
+	if err := h.Payments.Register(r.Context(), &payment); err != nil {
+		writePaymentError(w, err, "Failed to register payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// VoidPaymentHandler anula el pago indicado en ?id= con el motivo del cuerpo
+func (h *PaymentsHandler) VoidPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || strings.TrimSpace(payload.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.Payments.Void(r.Context(), id, payload.Reason, actingUser(r))
+	if err != nil {
+		writePaymentError(w, err, "Failed to void payment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// GetPaymentsHandler lista pagos filtrando por clientId, chargeId, method, status, receivedBy y el rango
+// de fechas from/to (YYYY-MM-DD, ambos incluidos)
+func (h *PaymentsHandler) GetPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	filter := bson.M{}
+	for _, field := range []string{"clientId", "chargeId"} {
+		if value := query.Get(field); value != "" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				http.Error(w, "Invalid "+field, http.StatusBadRequest)
+				return
+			}
+			filter[field] = id
+		}
+	}
+	for _, field := range []string{"method", "status", "receivedBy"} {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	receivedAt := bson.M{}
+	if from := query.Get("from"); from != "" {
+		date, err := parseReservationDate(from)
+		if err != nil {
+			http.Error(w, "Invalid from format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		receivedAt["$gte"] = date
+	}
+	if to := query.Get("to"); to != "" {
+		date, err := parseReservationDate(to)
+		if err != nil {
+			http.Error(w, "Invalid to format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		receivedAt["$lt"] = date.AddDate(0, 0, 1)
+	}
+	if len(receivedAt) > 0 {
+		filter["receivedAt"] = receivedAt
+	}
+
+	payments, total, err := h.Payments.List(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve payments", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"payments":   payments,
+		"totalPages": totalPages,
+	})
+}
+
+// GetBalanceHandler devuelve el saldo y los cargos pendientes del cliente indicado en ?id=
+func (h *PaymentsHandler) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.Payments.Balance(r.Context(), id)
+	if err != nil {
+		writePaymentError(w, err, "Failed to get balance")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
+
+// writePaymentError traduce los errores del PaymentService a respuestas HTTP
+func writePaymentError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrClientNotFound:
+		http.Error(w, "Client not found", http.StatusNotFound)
+	case services.ErrPaymentNotFound:
+		http.Error(w, "Payment not found", http.StatusNotFound)
+	case services.ErrChargeNotFound:
+		http.Error(w, "Charge not found for this client", http.StatusNotFound)
+	case services.ErrPaymentVoided:
+		http.Error(w, "Payment is already voided", http.StatusConflict)
+	case services.ErrNoOpenShift:
+		http.Error(w, "Open a cash shift before receiving cash payments", http.StatusConflict)
+	case services.ErrPaymentInvoiced:
+		http.Error(w, "Payment is included in an invoice and cannot be voided", http.StatusConflict)
+	case services.ErrChargeClosed:
+		http.Error(w, "Charge is already paid or cancelled", http.StatusConflict)
+	case services.ErrPaymentExceedsCharge:
+		http.Error(w, "Payment exceeds the outstanding amount of the charge", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
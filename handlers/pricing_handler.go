@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PricingHandler maneja los planes de tarifa y las cotizaciones de estancias
+type PricingHandler struct {
+	Pricing *services.PricingService
+}
+
+// ratePlanPayload es el cuerpo para crear o reemplazar un plan; las fechas de temporada van en YYYY-MM-DD
+type ratePlanPayload struct {
+	Name            string  `json:"name"`
+	Kind            string  `json:"kind"`
+	Category        string  `json:"category"`
+	Rate            float64 `json:"rate"`
+	Active          *bool   `json:"active"`
+	StartDate       string  `json:"startDate"`
+	EndDate         string  `json:"endDate"`
+	MinNights       int     `json:"minNights"`
+	DiscountPercent float64 `json:"discountPercent"`
+}
+
+// GetRatePlansHandler lista los planes filtrando por kind, category y active
+func (h *PricingHandler) GetRatePlansHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := bson.M{}
+	if kind := query.Get("kind"); kind != "" {
+		filter["kind"] = kind
+	}
+	if category := query.Get("category"); category != "" {
+		filter["category"] = category
+	}
+	if active := query.Get("active"); active != "" {
+		filter["active"] = active == "true"
+	}
+
+	plans, err := h.Pricing.ListPlans(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to get rate plans", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans)
+}
+
+// CreateRatePlanHandler crea un plan de tarifa
+func (h *PricingHandler) CreateRatePlanHandler(w http.ResponseWriter, r *http.Request) {
+	plan, ok := decodeRatePlan(w, r)
+	if !ok {
+		return
+	}
+	plan.CreatedBy = actingUser(r)
+
+	if err := h.Pricing.CreatePlan(r.Context(), plan); err != nil {
+		http.Error(w, "Failed to create rate plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// UpdateRatePlanHandler reemplaza la definición del plan indicado en ?id=
+func (h *PricingHandler) UpdateRatePlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid rate plan ID", http.StatusBadRequest)
+		return
+	}
+
+	plan, ok := decodeRatePlan(w, r)
+	if !ok {
+		return
+	}
+
+	err = h.Pricing.UpdatePlan(r.Context(), id, plan)
+	if err == services.ErrRatePlanNotFound {
+		http.Error(w, "Rate plan not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update rate plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// DeleteRatePlanHandler elimina el plan indicado en ?id=
+func (h *PricingHandler) DeleteRatePlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid rate plan ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Pricing.DeletePlan(r.Context(), id)
+	if err == services.ErrRatePlanNotFound {
+		http.Error(w, "Rate plan not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete rate plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Rate plan deleted successfully"})
+}
+
+// QuoteHandler calcula el precio de una estancia con ?roomNumber=, ?duration=, ?unit= (hours o nights, por defecto nights)
+// y ?start= (YYYY-MM-DD, por defecto hoy)
+func (h *PricingHandler) QuoteHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	roomNumber := query.Get("roomNumber")
+	if roomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
+		return
+	}
+	duration, err := strconv.Atoi(query.Get("duration"))
+	if err != nil || duration <= 0 {
+		http.Error(w, "Duration must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	unit := query.Get("unit")
+	if unit == "" {
+		unit = models.DurationNights
+	}
+	start := time.Now().UTC()
+	if value := query.Get("start"); value != "" {
+		start, err = parseReservationDate(value)
+		if err != nil {
+			http.Error(w, "Invalid start format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	quote, err := h.Pricing.Quote(r.Context(), roomNumber, unit, duration, start)
+	if err != nil {
+		writeQuoteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// decodeRatePlan lee y valida el plan del cuerpo; si es inválido responde 400
+func decodeRatePlan(w http.ResponseWriter, r *http.Request) (*models.RatePlan, bool) {
+	var payload ratePlanPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	plan := &models.RatePlan{
+		Name:     strings.TrimSpace(payload.Name),
+		Kind:     payload.Kind,
+		Category: payload.Category,
+		Active:   payload.Active == nil || *payload.Active,
+	}
+	if plan.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if !models.IsValidRatePlanKind(plan.Kind) {
+		http.Error(w, "Invalid kind. Must be one of hourly, nightly, weekend, seasonal or longStay", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if plan.Kind == models.RatePlanLongStay {
+		if payload.MinNights <= 0 || payload.DiscountPercent <= 0 || payload.DiscountPercent > 100 {
+			http.Error(w, "Long stay plans need minNights and a discountPercent between 0 and 100", http.StatusBadRequest)
+			return nil, false
+		}
+		plan.MinNights = payload.MinNights
+		plan.DiscountPercent = payload.DiscountPercent
+		return plan, true
+	}
+
+	if payload.Rate <= 0 {
+		http.Error(w, "Rate must be greater than zero", http.StatusBadRequest)
+		return nil, false
+	}
+	plan.Rate = payload.Rate
+
+	if plan.Kind == models.RatePlanSeasonal {
+		startDate, err := parseReservationDate(payload.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid startDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return nil, false
+		}
+		endDate, err := parseReservationDate(payload.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid endDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return nil, false
+		}
+		if endDate.Before(startDate) {
+			http.Error(w, "endDate cannot be before startDate", http.StatusBadRequest)
+			return nil, false
+		}
+		plan.StartDate = &startDate
+		plan.EndDate = &endDate
+	}
+	return plan, true
+}
+
+// writeQuoteError traduce los errores de PricingService.Quote a respuestas HTTP
+func writeQuoteError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrQuoteDuration:
+		http.Error(w, "Invalid duration or unit. Unit must be hours or nights", http.StatusBadRequest)
+	case services.ErrNoRatePlan:
+		http.Error(w, "No rate plan applies to this stay", http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Failed to quote stay", http.StatusInternalServerError)
+	}
+}
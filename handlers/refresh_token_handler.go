@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/services"
+)
+
+// RefreshTokenHandler rota el refresh token y emite un nuevo token de acceso
+type RefreshTokenHandler struct {
+	Tokens        *auth.TokenManager
+	Sessions      *services.SessionStore
+	RefreshTokens *services.RefreshTokenStore
+}
+
+func NewRefreshTokenHandler(tokens *auth.TokenManager, sessions *services.SessionStore, refreshTokens *services.RefreshTokenStore) *RefreshTokenHandler {
+	return &RefreshTokenHandler{
+		Tokens:        tokens,
+		Sessions:      sessions,
+		RefreshTokens: refreshTokens,
+	}
+}
+
+func (h *RefreshTokenHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	// El refresh token puede llegar en el cuerpo JSON o en la cookie Refresh
+	var payload struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}
+	if payload.RefreshToken == "" {
+		if cookie, err := r.Cookie("Refresh"); err == nil {
+			payload.RefreshToken = cookie.Value
+		}
+	}
+	if payload.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	// Comprobar antes de rotar que la sesión de la familia siga activa
+	current, err := h.RefreshTokens.Find(r.Context(), payload.RefreshToken)
+	if err == services.ErrRefreshTokenInvalid {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+	active, err := h.Sessions.IsActive(r.Context(), current.FamilyID)
+	if err != nil {
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+	if !active {
+		http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+		return
+	}
+
+	current, next, err := h.RefreshTokens.Rotate(r.Context(), payload.RefreshToken)
+	switch err {
+	case nil:
+	case services.ErrRefreshTokenReused:
+		// Un token ya rotado se volvió a presentar: se mata la sesión completa
+		log.Printf("Reutilización de refresh token detectada para %s (familia %s)", current.Username, current.FamilyID)
+		if _, err := h.Sessions.Revoke(r.Context(), current.FamilyID); err != nil {
+			log.Printf("Error revocando la sesión %s: %v", current.FamilyID, err)
+		}
+		http.Error(w, "Refresh token reuse detected", http.StatusUnauthorized)
+		return
+	case services.ErrRefreshTokenInvalid:
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	default:
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, claims, err := h.Tokens.Issue(current.Username, current.Role, current.FamilyID)
+	if err != nil {
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	writeTokenPair(w, accessToken, claims, next, current.ExpiresAt, nil)
+}
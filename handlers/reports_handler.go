@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportsHandler exporta reportes en CSV o XLSX y administra su generación programada
+type ReportsHandler struct {
+	Reports *services.ReportService
+}
+
+// reportContentTypes es el Content-Type de cada formato de exportación
+var reportContentTypes = map[string]string{
+	models.ReportCSV:  "text/csv; charset=utf-8",
+	models.ReportXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportReportHandler genera el reporte kind en el formato format (csv por defecto) con los mismos parámetros
+// de rango que /analytics, lo guarda en el almacenamiento configurado y lo descarga
+func (h *ReportsHandler) ExportReportHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, ok := parseAnalyticsParams(w, query)
+	if !ok {
+		return
+	}
+	params.Kind = query.Get("kind")
+	params.Format = query.Get("format")
+	if params.Format == "" {
+		params.Format = models.ReportCSV
+	}
+	if !validateReportDefinition(w, params.Kind, params.Format) {
+		return
+	}
+
+	run, content, err := h.Reports.Generate(r.Context(), params, actingUser(r), nil)
+	if err != nil {
+		http.Error(w, "Failed to generate report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", reportContentTypes[run.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", run.Filename))
+	w.Header().Set("Content-Location", run.FileURL)
+	w.Write(content)
+}
+
+// GetReportRunsHandler lista las ejecuciones de reportes filtrando por kind, status y scheduleId
+func (h *ReportsHandler) GetReportRunsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	filter := bson.M{}
+	for _, field := range []string{"kind", "status"} {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if scheduleID := query.Get("scheduleId"); scheduleID != "" {
+		id, err := primitive.ObjectIDFromHex(scheduleID)
+		if err != nil {
+			http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+			return
+		}
+		filter["scheduleId"] = id
+	}
+
+	runs, total, err := h.Reports.ListRuns(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve report runs", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"runs":       runs,
+		"totalPages": totalPages,
+	})
+}
+
+// DownloadReportRunHandler redirige al archivo guardado de la ejecución indicada en ?id=
+func (h *ReportsHandler) DownloadReportRunHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid report run ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.Reports.GetRun(r.Context(), id)
+	if err != nil {
+		writeReportError(w, err, "Failed to get report run")
+		return
+	}
+	if run.Status != models.ReportRunSucceeded || run.FileURL == "" {
+		http.Error(w, "Report run has no file", http.StatusConflict)
+		return
+	}
+
+	http.Redirect(w, r, run.FileURL, http.StatusFound)
+}
+
+// GetReportSchedulesHandler lista las programaciones de reportes
+func (h *ReportsHandler) GetReportSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.Reports.ListSchedules(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve report schedules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateReportScheduleHandler crea una programación de reporte
+func (h *ReportsHandler) CreateReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := decodeReportSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Reports.CreateSchedule(r.Context(), schedule, actingUser(r)); err != nil {
+		writeReportError(w, err, "Failed to create report schedule")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateReportScheduleHandler reemplaza la programación indicada en ?id=
+func (h *ReportsHandler) UpdateReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+	schedule, ok := decodeReportSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Reports.UpdateSchedule(r.Context(), id, schedule)
+	if err != nil {
+		writeReportError(w, err, "Failed to update report schedule")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteReportScheduleHandler elimina la programación indicada en ?id=
+func (h *ReportsHandler) DeleteReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Reports.DeleteSchedule(r.Context(), id); err != nil {
+		writeReportError(w, err, "Failed to delete report schedule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunReportScheduleHandler genera ahora el reporte de la programación indicada en ?id= y devuelve la ejecución
+func (h *ReportsHandler) RunReportScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.Reports.RunSchedule(r.Context(), id, actingUser(r))
+	if err != nil && run == nil {
+		writeReportError(w, err, "Failed to run report schedule")
+		return
+	}
+
+	// Una ejecución fallida también se devuelve, con su error, porque queda en el historial
+	w.Header().Set("Content-Type", "application/json")
+	if run.Status == models.ReportRunFailed {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(run)
+}
+
+// decodeReportSchedule lee y valida la programación del cuerpo; si es inválida responde 400
+func decodeReportSchedule(w http.ResponseWriter, r *http.Request) (*models.ReportSchedule, bool) {
+	var payload struct {
+		Name     string `json:"name"`
+		Kind     string `json:"kind"`
+		Format   string `json:"format"`
+		Cron     string `json:"cron"`
+		Period   string `json:"period"`
+		Bucket   string `json:"bucket"`
+		RoomType string `json:"roomType"`
+		Enabled  *bool  `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	schedule := &models.ReportSchedule{
+		Name:     strings.TrimSpace(payload.Name),
+		Kind:     payload.Kind,
+		Format:   payload.Format,
+		Cron:     strings.TrimSpace(payload.Cron),
+		Period:   payload.Period,
+		Bucket:   payload.Bucket,
+		RoomType: payload.RoomType,
+		Enabled:  payload.Enabled == nil || *payload.Enabled,
+	}
+	if schedule.Format == "" {
+		schedule.Format = models.ReportCSV
+	}
+	if schedule.Period == "" {
+		schedule.Period = models.BucketMonth
+	}
+	if schedule.Bucket == "" {
+		schedule.Bucket = models.BucketDay
+	}
+	if schedule.RoomType == "" {
+		schedule.RoomType = models.RoomTypeGuest
+	}
+
+	switch {
+	case schedule.Name == "":
+		http.Error(w, "name is required", http.StatusBadRequest)
+	case schedule.Cron == "":
+		http.Error(w, "cron is required", http.StatusBadRequest)
+	case !models.IsValidBucket(schedule.Period):
+		http.Error(w, "Invalid period, expected day, week or month", http.StatusBadRequest)
+	case !models.IsValidBucket(schedule.Bucket):
+		http.Error(w, "Invalid bucket, expected day, week or month", http.StatusBadRequest)
+	case !isValidAnalyticsRoomType(schedule.RoomType):
+		http.Error(w, "Invalid roomType, expected guest, rental or all", http.StatusBadRequest)
+	default:
+		return schedule, validateReportDefinition(w, schedule.Kind, schedule.Format)
+	}
+	return nil, false
+}
+
+// validateReportDefinition valida el tipo y el formato del reporte; si son inválidos responde 400
+func validateReportDefinition(w http.ResponseWriter, kind, format string) bool {
+	switch {
+	case !models.IsValidReportKind(kind):
+		http.Error(w, "Invalid kind, expected occupancy, revenue, overdue or guests", http.StatusBadRequest)
+	case !models.IsValidReportFormat(format):
+		http.Error(w, "Invalid format, expected csv or xlsx", http.StatusBadRequest)
+	default:
+		return true
+	}
+	return false
+}
+
+// writeReportError traduce los errores del ReportService a respuestas HTTP
+func writeReportError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrReportScheduleNotFound:
+		http.Error(w, "Report schedule not found", http.StatusNotFound)
+	case services.ErrReportRunNotFound:
+		http.Error(w, "Report run not found", http.StatusNotFound)
+	case services.ErrInvalidCron:
+		http.Error(w, "Invalid cron expression, expected 5 fields (minute hour day month weekday)", http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
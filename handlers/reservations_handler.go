@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reservationDateLayout es el formato de las fechas de llegada y salida
+const reservationDateLayout = "2006-01-02"
+
+// ReservationsHandler maneja la creación, modificación, cancelación y consulta de reservaciones
+type ReservationsHandler struct {
+	Reservations *services.ReservationStore
+}
+
+// CreateReservationHandler crea una reservación para una habitación (roomNumber) o un tipo de habitación (roomType)
+func (h *ReservationsHandler) CreateReservationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RoomNumber    string `json:"roomNumber"`
+		RoomType      string `json:"roomType"`
+		ClientID      string `json:"clientId"`
+		ArrivalDate   string `json:"arrivalDate"`
+		DepartureDate string `json:"departureDate"`
+		Notes         string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := primitive.ObjectIDFromHex(payload.ClientID)
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	if payload.RoomNumber == "" && payload.RoomType == "" {
+		http.Error(w, "roomNumber or roomType is required", http.StatusBadRequest)
+		return
+	}
+	arrival, err := parseReservationDate(payload.ArrivalDate)
+	if err != nil {
+		http.Error(w, "Invalid arrivalDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	departure, err := parseReservationDate(payload.DepartureDate)
+	if err != nil {
+		http.Error(w, "Invalid departureDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	reservation := models.Reservation{
+		RoomNumber:    strings.TrimSpace(payload.RoomNumber),
+		RoomType:      payload.RoomType,
+		ClientID:      clientID,
+		ArrivalDate:   arrival,
+		DepartureDate: departure,
+		Notes:         payload.Notes,
+		CreatedBy:     actingUser(r),
+	}
+	if err := h.Reservations.Create(r.Context(), &reservation); err != nil {
+		writeReservationError(w, err, "Failed to create reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// UpdateReservationHandler modifica habitación, fechas o notas de la reservación indicada en ?id=; un roomNumber
+// vacío le quita la habitación y la deja pendiente
+func (h *ReservationsHandler) UpdateReservationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		RoomNumber    *string `json:"roomNumber"`
+		ArrivalDate   *string `json:"arrivalDate"`
+		DepartureDate *string `json:"departureDate"`
+		Notes         *string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	changes := services.ReservationChanges{RoomNumber: payload.RoomNumber, Notes: payload.Notes}
+	if payload.ArrivalDate != nil {
+		arrival, err := parseReservationDate(*payload.ArrivalDate)
+		if err != nil {
+			http.Error(w, "Invalid arrivalDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		changes.ArrivalDate = &arrival
+	}
+	if payload.DepartureDate != nil {
+		departure, err := parseReservationDate(*payload.DepartureDate)
+		if err != nil {
+			http.Error(w, "Invalid departureDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		changes.DepartureDate = &departure
+	}
+
+	reservation, err := h.Reservations.Update(r.Context(), id, changes)
+	if err != nil {
+		writeReservationError(w, err, "Failed to update reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// CancelReservationHandler cancela la reservación indicada en ?id=
+func (h *ReservationsHandler) CancelReservationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.Reservations.Cancel(r.Context(), id)
+	if err != nil {
+		writeReservationError(w, err, "Failed to cancel reservation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// GetReservationsHandler lista reservaciones filtrando por status, roomNumber, clientId y rango de fechas (from, to)
+func (h *ReservationsHandler) GetReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	filter := bson.M{}
+	if status := query.Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if roomNumber := query.Get("roomNumber"); roomNumber != "" {
+		filter["roomNumber"] = roomNumber
+	}
+	if clientIDStr := query.Get("clientId"); clientIDStr != "" {
+		clientID, err := primitive.ObjectIDFromHex(clientIDStr)
+		if err != nil {
+			http.Error(w, "Invalid client ID", http.StatusBadRequest)
+			return
+		}
+		filter["clientId"] = clientID
+	}
+	// Con from/to se devuelven las reservaciones cuya estancia se traslapa con el rango
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseReservationDate(fromStr)
+		if err != nil {
+			http.Error(w, "Invalid from format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		filter["departureDate"] = bson.M{"$gt": from}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseReservationDate(toStr)
+		if err != nil {
+			http.Error(w, "Invalid to format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		filter["arrivalDate"] = bson.M{"$lt": to}
+	}
+
+	reservations, total, err := h.Reservations.List(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve reservations", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reservations": reservations,
+		"totalPages":   totalPages,
+	})
+}
+
+// GetAvailabilityHandler devuelve las habitaciones libres entre arrivalDate y departureDate, opcionalmente por roomType
+func (h *ReservationsHandler) GetAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	arrival, err := parseReservationDate(query.Get("arrivalDate"))
+	if err != nil {
+		http.Error(w, "Invalid arrivalDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	departure, err := parseReservationDate(query.Get("departureDate"))
+	if err != nil {
+		http.Error(w, "Invalid departureDate format, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !departure.After(arrival) {
+		http.Error(w, "departureDate must be after arrivalDate", http.StatusBadRequest)
+		return
+	}
+
+	rooms, err := h.Reservations.AvailableRooms(r.Context(), arrival, departure, query.Get("roomType"))
+	if err != nil {
+		http.Error(w, "Failed to search availability", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rooms)
+}
+
+// parseReservationDate interpreta una fecha YYYY-MM-DD como medianoche UTC
+func parseReservationDate(value string) (time.Time, error) {
+	return time.Parse(reservationDateLayout, value)
+}
+
+// writeReservationError traduce los errores del ReservationStore a respuestas HTTP
+func writeReservationError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrReservationNotFound:
+		http.Error(w, "Reservation not found", http.StatusNotFound)
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrClientNotFound:
+		http.Error(w, "Client not found", http.StatusNotFound)
+	case services.ErrReservationDates:
+		http.Error(w, "departureDate must be after arrivalDate", http.StatusBadRequest)
+	case services.ErrReservationOverlap:
+		http.Error(w, "Room is already reserved for those dates", http.StatusConflict)
+	case services.ErrReservationClosed:
+		http.Error(w, "Reservation can no longer be modified", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
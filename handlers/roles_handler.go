@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RolesHandler maneja la administración de roles y sus permisos
+type RolesHandler struct {
+	Client *mongo.Client
+	Roles  *services.RoleStore
+}
+
+type rolePayload struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetPermissionsHandler devuelve todos los permisos que se pueden asignar a un rol
+func (h *RolesHandler) GetPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth.AllPermissions)
+}
+
+// GetRolesHandler devuelve todos los roles con sus permisos
+func (h *RolesHandler) GetRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.Roles.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get roles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roles)
+}
+
+// CreateRoleHandler crea un rol personalizado
+func (h *RolesHandler) CreateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload rolePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		http.Error(w, "Role name is required", http.StatusBadRequest)
+		return
+	}
+	if invalid, ok := validatePermissions(payload.Permissions); !ok {
+		http.Error(w, "Unknown permission: "+invalid, http.StatusBadRequest)
+		return
+	}
+
+	role := models.Role{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	}
+	err := h.Roles.Create(r.Context(), &role)
+	if err == services.ErrRoleExists {
+		http.Error(w, "A role with that name already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create role", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(role)
+}
+
+// UpdateRoleHandler reemplaza la descripción y los permisos del rol indicado en ?name=
+func (h *RolesHandler) UpdateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing role name", http.StatusBadRequest)
+		return
+	}
+
+	var payload rolePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	// La actualización reemplaza la lista completa; omitirla dejaría el rol sin permisos por accidente
+	if payload.Permissions == nil {
+		http.Error(w, "permissions is required", http.StatusBadRequest)
+		return
+	}
+	if invalid, ok := validatePermissions(payload.Permissions); !ok {
+		http.Error(w, "Unknown permission: "+invalid, http.StatusBadRequest)
+		return
+	}
+
+	// Evitar que los administradores pierdan la capacidad de administrar roles
+	if name == auth.RoleAdministracion && !containsString(payload.Permissions, string(auth.PermRolesManage)) {
+		http.Error(w, "The Administracion role must keep the roles:manage permission", http.StatusBadRequest)
+		return
+	}
+
+	err := h.Roles.Update(r.Context(), name, payload.Description, payload.Permissions)
+	if err == services.ErrRoleNotFound {
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update role", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role updated successfully"})
+}
+
+// DeleteRoleHandler elimina el rol indicado en ?name= si no es del sistema y ningún usuario lo tiene asignado
+func (h *RolesHandler) DeleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing role name", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.Roles.Get(r.Context(), name)
+	if err == services.ErrRoleNotFound {
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete role", http.StatusInternalServerError)
+		return
+	}
+	if role.System {
+		http.Error(w, "System roles cannot be deleted", http.StatusForbidden)
+		return
+	}
+
+	users := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
+	assigned, err := users.CountDocuments(r.Context(), bson.M{"rol": name})
+	if err != nil {
+		http.Error(w, "Failed to delete role", http.StatusInternalServerError)
+		return
+	}
+	if assigned > 0 {
+		http.Error(w, "Role is assigned to one or more users", http.StatusConflict)
+		return
+	}
+
+	if err := h.Roles.Delete(r.Context(), name); err != nil {
+		http.Error(w, "Failed to delete role", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role deleted successfully"})
+}
+
+// validatePermissions verifica que todos los permisos existan; devuelve el primero inválido
+func validatePermissions(permissions []string) (string, bool) {
+	for _, p := range permissions {
+		if !auth.IsValidPermission(p) {
+			return p, false
+		}
+	}
+	return "", true
+}
+
+// containsString indica si el slice contiene el valor dado
+func containsString(slice []string, item string) bool {
+	for _, v := range slice {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
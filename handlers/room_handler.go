@@ -3,11 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"hotelman-backend/constants"
 	"hotelman-backend/models"
+	"hotelman-backend/services"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,7 +19,11 @@ import (
 
 // RoomHandler maneja las solicitudes relacionadas con las habitaciones
 type RoomHandler struct {
-	Client *mongo.Client
+	Client                 *mongo.Client
+	Rooms                  *services.RoomStore
+	Statuses               *services.RoomStatusService
+	CloudinaryService      *services.CloudinaryService
+	LocalFileSystemService *services.LocalFileSystemService
 }
 
 // CreateRoomHandler maneja la creación de nuevas habitaciones
@@ -28,18 +35,29 @@ func (h *RoomHandler) CreateRoomHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validar el tipo de habitación
-	if room.RoomType != "rental" && room.RoomType != "guest" {
-		http.Error(w, "Invalid room type. Must be either 'rental' or 'guest'", http.StatusBadRequest)
+	room.RoomNumber = strings.TrimSpace(room.RoomNumber)
+	if room.RoomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
+		return
+	}
+	if !validateRoomDefinition(w, room) {
 		return
 	}
 
-	room.ID = primitive.NewObjectID()
-	room.CreatedAt = time.Now()
-	room.UpdatedAt = time.Now()
+	// Las habitaciones nuevas quedan disponibles salvo que se indique otro estado válido
+	if room.Status == "" {
+		room.Status = models.RoomStatusAvailable
+	}
+	if !models.IsValidRoomStatus(room.Status) || room.Status == models.RoomStatusOccupied {
+		http.Error(w, "Invalid room status", http.StatusBadRequest)
+		return
+	}
 
-	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
-	_, err = collection.InsertOne(context.Background(), room)
+	err = h.Rooms.Create(r.Context(), &room)
+	if err == services.ErrRoomNumberTaken {
+		http.Error(w, "Room number already exists", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to create room", http.StatusInternalServerError)
 		return
@@ -49,76 +67,209 @@ func (h *RoomHandler) CreateRoomHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(room)
 }
 
-// UpdateRoomStatusHandler maneja la actualización del estado de una habitación
-func (h *RoomHandler) UpdateRoomStatusHandler(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		OccupantID string `json:"occupantId"`
-		Status     string `json:"status"`
+// GetRoomHandler devuelve la habitación indicada en ?roomId= o ?roomNumber=
+func (h *RoomHandler) GetRoomHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ref, ok := roomRef(w, query.Get("roomId"), query.Get("roomNumber"))
+	if !ok {
+		return
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&payload)
+	room, err := h.Rooms.Get(r.Context(), ref)
 	if err != nil {
+		writeRoomError(w, err, "Failed to get room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// UpdateRoomHandler modifica la definición de la habitación indicada en ?id=; el estado se cambia en /rooms/status
+func (h *RoomHandler) UpdateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid room ID", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		RoomType        *string             `json:"roomType"`
+		RoomNumber      *string             `json:"roomNumber"`
+		Name            *string             `json:"name"`
+		Description     *string             `json:"description"`
+		Category        *string             `json:"category"`
+		Floor           *int                `json:"floor"`
+		Capacity        *int                `json:"capacity"`
+		Beds            *[]models.BedConfig `json:"beds"`
+		Amenities       *[]string           `json:"amenities"`
+		BaseNightlyRate *float64            `json:"baseNightlyRate"`
+		BaseMonthlyRate *float64            `json:"baseMonthlyRate"`
+		Photos          *[]string           `json:"photos"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Error parsing request body", http.StatusBadRequest)
 		return
 	}
 
-	occupantID, err := primitive.ObjectIDFromHex(payload.OccupantID)
+	// Validar los campos enviados con las mismas reglas que al crear la habitación
+	check := models.Room{RoomType: models.RoomTypeGuest}
+	if payload.RoomType != nil {
+		check.RoomType = *payload.RoomType
+	}
+	if payload.RoomNumber != nil {
+		trimmed := strings.TrimSpace(*payload.RoomNumber)
+		if trimmed == "" {
+			http.Error(w, "Room number is required", http.StatusBadRequest)
+			return
+		}
+		payload.RoomNumber = &trimmed
+	}
+	if payload.Capacity != nil {
+		check.Capacity = *payload.Capacity
+	}
+	if payload.Beds != nil {
+		check.Beds = *payload.Beds
+	}
+	if payload.BaseNightlyRate != nil {
+		check.BaseNightlyRate = *payload.BaseNightlyRate
+	}
+	if payload.BaseMonthlyRate != nil {
+		check.BaseMonthlyRate = *payload.BaseMonthlyRate
+	}
+	if !validateRoomDefinition(w, check) {
+		return
+	}
+
+	room, err := h.Rooms.Update(r.Context(), id, services.RoomChanges{
+		RoomType:        payload.RoomType,
+		RoomNumber:      payload.RoomNumber,
+		Name:            payload.Name,
+		Description:     payload.Description,
+		Category:        payload.Category,
+		Floor:           payload.Floor,
+		Capacity:        payload.Capacity,
+		Beds:            payload.Beds,
+		Amenities:       payload.Amenities,
+		BaseNightlyRate: payload.BaseNightlyRate,
+		BaseMonthlyRate: payload.BaseMonthlyRate,
+		Photos:          payload.Photos,
+	})
 	if err != nil {
-		http.Error(w, "Invalid occupant ID", http.StatusBadRequest)
+		writeRoomError(w, err, "Failed to update room")
 		return
 	}
 
-	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
-	filter := bson.M{"occupantId": occupantID}
-	update := bson.M{"$set": bson.M{"status": payload.Status, "updatedAt": time.Now()}}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
 
-	result, err := collection.UpdateOne(context.Background(), filter, update)
+// DeleteRoomHandler elimina la habitación indicada en ?id=; si tiene historial solo se marca como eliminada
+func (h *RoomHandler) DeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
 	if err != nil {
-		http.Error(w, "Failed to update room status", http.StatusInternalServerError)
+		http.Error(w, "Invalid room ID", http.StatusBadRequest)
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		http.Error(w, "No room found with the given occupant ID", http.StatusNotFound)
+	soft, err := h.Rooms.Delete(r.Context(), id, actingUser(r))
+	if err != nil {
+		writeRoomError(w, err, "Failed to delete room")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(bson.M{"message": "Room status updated successfully"})
+	message := "Room deleted successfully"
+	if soft {
+		message = "Room has history; it was marked as deleted"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": message, "softDeleted": soft})
 }
 
-// GetRoomOccupantHandler maneja la obtención del inquilino de una habitación
-func (h *RoomHandler) GetRoomOccupantHandler(w http.ResponseWriter, r *http.Request) {
-	roomNumber := r.URL.Query().Get("roomNumber")
-	if roomNumber == "" {
-		http.Error(w, "Room number is required", http.StatusBadRequest)
+// AddRoomPhotosHandler sube las fotos del campo "photos" y las agrega a la habitación indicada en ?id=
+func (h *RoomHandler) AddRoomPhotosHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid room ID", http.StatusBadRequest)
 		return
 	}
 
-	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
-	filter := bson.M{"roomNumber": roomNumber}
+	if err := r.ParseMultipartForm(20 << 20); err != nil { // Limit to 20 MB
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		http.Error(w, "At least one photo is required", http.StatusBadRequest)
+		return
+	}
 
-	var room models.Room
-	err := collection.FindOne(context.Background(), filter).Decode(&room)
+	urls, err := uploadPhotos(files, h.LocalFileSystemService, h.CloudinaryService.UploadRoomPhoto)
 	if err != nil {
-		http.Error(w, "Failed to get room", http.StatusInternalServerError)
+		log.Printf("Error subiendo las fotos de la habitación %s: %v", id.Hex(), err)
+		http.Error(w, "Error al subir las fotos", http.StatusInternalServerError)
 		return
 	}
 
-	if room.ID.IsZero() {
-		http.Error(w, "Room not found", http.StatusNotFound)
+	room, err := h.Rooms.AddPhotos(r.Context(), id, urls)
+	if err != nil {
+		writeRoomError(w, err, "Failed to add photos")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(room)
 }
 
-// AssignOccupantHandler maneja la asignación de un ocupante a una habitación
-func (h *RoomHandler) AssignOccupantHandler(w http.ResponseWriter, r *http.Request) {
+// validateRoomDefinition valida el uso, la capacidad, las camas y las tarifas base; si algo es inválido responde 400
+func validateRoomDefinition(w http.ResponseWriter, room models.Room) bool {
+	if room.RoomType != models.RoomTypeRental && room.RoomType != models.RoomTypeGuest {
+		http.Error(w, "Invalid room type. Must be either 'rental' or 'guest'", http.StatusBadRequest)
+		return false
+	}
+	if room.Capacity < 0 {
+		http.Error(w, "Capacity cannot be negative", http.StatusBadRequest)
+		return false
+	}
+	for _, bed := range room.Beds {
+		if strings.TrimSpace(bed.Type) == "" || bed.Count <= 0 {
+			http.Error(w, "Each bed needs a type and a positive count", http.StatusBadRequest)
+			return false
+		}
+	}
+	if room.BaseNightlyRate < 0 || room.BaseMonthlyRate < 0 {
+		http.Error(w, "Rates cannot be negative", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeRoomError traduce los errores del RoomStore a respuestas HTTP
+func writeRoomError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrRoomNumberTaken:
+		http.Error(w, "Room number already exists", http.StatusConflict)
+	case services.ErrRoomNumberLocked:
+		http.Error(w, "Room number cannot change once the room has history", http.StatusConflict)
+	case services.ErrRoomOccupied:
+		http.Error(w, "Room is occupied", http.StatusConflict)
+	case services.ErrRoomInUse:
+		http.Error(w, "Room has active reservations", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// UpdateRoomStatusHandler cambia el estado de la habitación indicada por roomId o roomNumber
+// aplicando las transiciones permitidas en models.RoomStatusTransitions
+func (h *RoomHandler) UpdateRoomStatusHandler(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
+		RoomID     string `json:"roomId"`
 		RoomNumber string `json:"roomNumber"`
-		OccupantID string `json:"occupantId"`
+		Status     string `json:"status"`
+		Note       string `json:"note"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&payload)
@@ -127,45 +278,141 @@ func (h *RoomHandler) AssignOccupantHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	roomNumber := payload.RoomNumber
-	occupantID, err := primitive.ObjectIDFromHex(payload.OccupantID)
+	ref, ok := roomRef(w, payload.RoomID, payload.RoomNumber)
+	if !ok {
+		return
+	}
+
+	room, err := h.Statuses.Change(r.Context(), ref, payload.Status, actingUser(r), payload.Note)
+	switch err {
+	case nil:
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	case services.ErrInvalidRoomStatus:
+		http.Error(w, "Invalid room status", http.StatusBadRequest)
+		return
+	case services.ErrRoomOccupancyStatus:
+		http.Error(w, "Occupied status can only change through check-in and check-out", http.StatusConflict)
+		return
+	case services.ErrRoomStatusTransition:
+		http.Error(w, "Room status transition not allowed", http.StatusConflict)
+		return
+	case services.ErrRoomBlocked:
+		http.Error(w, "Room has open blocking maintenance tickets", http.StatusConflict)
+		return
+	default:
+		http.Error(w, "Failed to update room status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// GetRoomStatusHistoryHandler devuelve la línea de tiempo de estados de la habitación indicada en ?roomId= o ?roomNumber=
+func (h *RoomHandler) GetRoomStatusHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ref, ok := roomRef(w, query.Get("roomId"), query.Get("roomNumber"))
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 50 // Default page size
+	}
+
+	changes, total, err := h.Statuses.History(r.Context(), ref, page, pageSize)
+	if err == services.ErrRoomNotFound {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Invalid occupant ID", http.StatusBadRequest)
+		http.Error(w, "Failed to get room status history", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"changes":    changes,
+		"totalPages": totalPages,
+	})
+}
+
+// roomRef construye la referencia a la habitación a partir de su ID o su número; si faltan o son inválidos responde 400
+func roomRef(w http.ResponseWriter, roomID, roomNumber string) (services.RoomRef, bool) {
+	if roomID != "" {
+		id, err := primitive.ObjectIDFromHex(roomID)
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return services.RoomRef{}, false
+		}
+		return services.RoomRef{ID: &id}, true
+	}
+	if roomNumber != "" {
+		return services.RoomRef{Number: roomNumber}, true
+	}
+	http.Error(w, "roomId or roomNumber is required", http.StatusBadRequest)
+	return services.RoomRef{}, false
+}
+
+// GetRoomOccupantHandler maneja la obtención del inquilino de una habitación
+func (h *RoomHandler) GetRoomOccupantHandler(w http.ResponseWriter, r *http.Request) {
+	roomNumber := r.URL.Query().Get("roomNumber")
+	if roomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
 		return
 	}
 
 	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
 	filter := bson.M{"roomNumber": roomNumber}
-	update := bson.M{"$set": bson.M{"occupantId": occupantID, "updatedAt": time.Now()}}
 
-	result, err := collection.UpdateOne(context.Background(), filter, update)
+	var room models.Room
+	err := collection.FindOne(context.Background(), filter).Decode(&room)
 	if err != nil {
-		http.Error(w, "Failed to assign occupant to room", http.StatusInternalServerError)
+		http.Error(w, "Failed to get room", http.StatusInternalServerError)
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		http.Error(w, "No room found with the given room number", http.StatusNotFound)
+	if room.ID.IsZero() {
+		http.Error(w, "Room not found", http.StatusNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(bson.M{"message": "Occupant assigned to room successfully"})
+	json.NewEncoder(w).Encode(room)
 }
 
-// GetAllRoomsHandler maneja la obtención de todas las habitaciones
+// GetAllRoomsHandler lista las habitaciones filtrando por roomType, category, status y floor.
+// Las habitaciones eliminadas solo se incluyen con ?includeDeleted=true.
 func (h *RoomHandler) GetAllRoomsHandler(w http.ResponseWriter, r *http.Request) {
-	collection := h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
-	cursor, err := collection.Find(context.Background(), bson.M{})
-	if err != nil {
-		http.Error(w, "Failed to get rooms", http.StatusInternalServerError)
-		return
+	query := r.URL.Query()
+
+	filter := bson.M{}
+	for _, field := range []string{"roomType", "category", "status"} {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if floor := query.Get("floor"); floor != "" {
+		n, err := strconv.Atoi(floor)
+		if err != nil {
+			http.Error(w, "Invalid floor", http.StatusBadRequest)
+			return
+		}
+		filter["floor"] = n
 	}
-	defer cursor.Close(context.Background())
 
-	var rooms []models.Room
-	if err = cursor.All(context.Background(), &rooms); err != nil {
-		http.Error(w, "Failed to decode rooms", http.StatusInternalServerError)
+	rooms, err := h.Rooms.List(r.Context(), filter, query.Get("includeDeleted") == "true")
+	if err != nil {
+		http.Error(w, "Failed to get rooms", http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hotelman-backend/services"
+)
+
+// SessionsHandler permite a los administradores consultar y revocar sesiones activas
+type SessionsHandler struct {
+	Sessions      *services.SessionStore
+	RefreshTokens *services.RefreshTokenStore
+}
+
+// ListSessionsHandler devuelve las sesiones activas, opcionalmente filtradas por usuario
+func (h *SessionsHandler) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+
+	sessions, err := h.Sessions.ListActive(r.Context(), username)
+	if err != nil {
+		http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSessionsHandler revoca una sesión por tokenId o todas las sesiones de un usuario
+func (h *SessionsHandler) RevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenID := r.URL.Query().Get("tokenId")
+	username := r.URL.Query().Get("username")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case tokenID != "":
+		revoked, err := h.Sessions.Revoke(r.Context(), tokenID)
+		if err != nil {
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+		if !revoked {
+			http.Error(w, "No active session found with the given token ID", http.StatusNotFound)
+			return
+		}
+		if err := h.RefreshTokens.RevokeFamily(r.Context(), tokenID); err != nil {
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Session revoked successfully", "revoked": 1})
+	case username != "":
+		count, err := h.Sessions.RevokeAllForUser(r.Context(), username)
+		if err != nil {
+			http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+			return
+		}
+		if err := h.RefreshTokens.RevokeAllForUser(r.Context(), username); err != nil {
+			http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Sessions revoked successfully", "revoked": count})
+	default:
+		http.Error(w, "tokenId or username is required", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShiftsHandler maneja la apertura, el cierre y los cortes de los turnos de caja
+type ShiftsHandler struct {
+	Shifts *services.ShiftService
+}
+
+// OpenShiftHandler abre un turno de caja del usuario con el fondo inicial del cuerpo
+func (h *ShiftsHandler) OpenShiftHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		OpeningFloat float64 `json:"openingFloat"`
+		Notes        string  `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.OpeningFloat < 0 {
+		http.Error(w, "Opening float cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	shift, err := h.Shifts.Open(r.Context(), actingUser(r), payload.OpeningFloat, payload.Notes)
+	if err != nil {
+		writeShiftError(w, err, "Failed to open shift")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+// GetCurrentShiftHandler devuelve el corte al momento del turno abierto del usuario
+func (h *ShiftsHandler) GetCurrentShiftHandler(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.Shifts.Current(r.Context(), actingUser(r))
+	if err != nil {
+		writeShiftError(w, err, "Failed to get current shift")
+		return
+	}
+	report, err := h.Shifts.Report(r.Context(), shift.ID)
+	if err != nil {
+		writeShiftError(w, err, "Failed to get current shift")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// CloseShiftHandler cierra el turno abierto del usuario con el efectivo contado y devuelve el corte
+func (h *ShiftsHandler) CloseShiftHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		CountedCash *float64 `json:"countedCash"`
+		Notes       string   `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.CountedCash == nil || *payload.CountedCash < 0 {
+		http.Error(w, "countedCash is required and cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Shifts.Close(r.Context(), actingUser(r), *payload.CountedCash, payload.Notes)
+	if err != nil {
+		writeShiftError(w, err, "Failed to close shift")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetShiftsHandler lista los turnos filtrando por openedBy, status y el rango de apertura from/to
+// (YYYY-MM-DD, ambos incluidos)
+func (h *ShiftsHandler) GetShiftsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1 // Default page
+	}
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 20 // Default page size
+	}
+
+	filter := bson.M{}
+	for _, field := range []string{"openedBy", "status"} {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	openedAt := bson.M{}
+	if from := query.Get("from"); from != "" {
+		date, err := parseReservationDate(from)
+		if err != nil {
+			http.Error(w, "Invalid from format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		openedAt["$gte"] = date
+	}
+	if to := query.Get("to"); to != "" {
+		date, err := parseReservationDate(to)
+		if err != nil {
+			http.Error(w, "Invalid to format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		openedAt["$lt"] = date.AddDate(0, 0, 1)
+	}
+	if len(openedAt) > 0 {
+		filter["openedAt"] = openedAt
+	}
+
+	shifts, total, err := h.Shifts.List(r.Context(), filter, page, pageSize)
+	if err != nil {
+		http.Error(w, "Failed to retrieve shifts", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize) // Calcular el número total de páginas
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shifts":     shifts,
+		"totalPages": totalPages,
+	})
+}
+
+// GetShiftReportHandler devuelve el corte del turno indicado en ?id=
+func (h *ShiftsHandler) GetShiftReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid shift ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Shifts.Report(r.Context(), id)
+	if err != nil {
+		writeShiftError(w, err, "Failed to get shift report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeShiftError traduce los errores del ShiftService a respuestas HTTP
+func writeShiftError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrShiftNotFound:
+		http.Error(w, "Shift not found", http.StatusNotFound)
+	case services.ErrNoOpenShift:
+		http.Error(w, "You have no open shift", http.StatusNotFound)
+	case services.ErrShiftAlreadyOpen:
+		http.Error(w, "You already have an open shift", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
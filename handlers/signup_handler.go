@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
+	"hotelman-backend/auth"
 	"hotelman-backend/constants"
 	"hotelman-backend/models"
 	"hotelman-backend/services"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// SignupHandler registra nuevos usuarios del personal. El registro exige una invitación válida,
+// cuyo rol se asigna al usuario, o un CURP autorizado previamente en valid_curps para dar de alta administradores.
 type SignupHandler struct {
 	Client                 *mongo.Client
+	Invitations            *services.InvitationStore
 	CloudinaryService      *services.CloudinaryService
 	LocalFileSystemService *services.LocalFileSystemService
 }
@@ -32,27 +38,76 @@ func (h *SignupHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	newUser.Nombres = r.FormValue("nombres")
 	newUser.Apellidos = r.FormValue("apellidos")
-	newUser.Correo = r.FormValue("correo")
+	newUser.Correo = strings.TrimSpace(r.FormValue("correo"))
 	newUser.Celular = r.FormValue("numeroCelular")
 	newUser.Password = r.FormValue("contrasena")
-	newUser.Rol = "Recepcionista"
 	confirmarContrasena := r.FormValue("confirmarContrasena")
 	newUser.CURP = r.FormValue("curp")
+	invitationToken := r.FormValue("invitacion")
 
-	if newUser.CURP != "" {
-		if !isValidCURP(newUser.CURP) {
-			http.Error(w, "CURP inválido", http.StatusBadRequest)
-			return
-		} else {
-			newUser.Rol = "Administracion"
-		}
+	if newUser.Correo == "" || newUser.Password == "" {
+		http.Error(w, "El correo y la contraseña son obligatorios", http.StatusBadRequest)
+		return
+	}
+	if newUser.CURP != "" && !isValidCURP(newUser.CURP) {
+		http.Error(w, "CURP inválido", http.StatusBadRequest)
+		return
 	}
-
 	if newUser.Password != confirmarContrasena {
 		http.Error(w, "Las contraseñas no coinciden", http.StatusBadRequest)
 		return
 	}
 
+	existing, err := collection.CountDocuments(r.Context(), bson.M{"correo": newUser.Correo})
+	if err != nil {
+		http.Error(w, "Error al registrar el usuario", http.StatusInternalServerError)
+		return
+	}
+	if existing > 0 {
+		http.Error(w, "El correo ya está registrado", http.StatusConflict)
+		return
+	}
+
+	// Determinar el rol: la invitación tiene prioridad; sin ella solo se admite un CURP autorizado
+	var invitation *models.Invitation
+	switch {
+	case invitationToken != "":
+		invitation, err = h.Invitations.Consume(r.Context(), invitationToken, newUser.Correo)
+		if err == services.ErrInvitationInvalid {
+			http.Error(w, "Invitación inválida o expirada", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Error al validar la invitación", http.StatusInternalServerError)
+			return
+		}
+		newUser.Rol = invitation.Rol
+	case newUser.CURP != "":
+		authorized, err := h.isAuthorizedCURP(r.Context(), newUser.CURP)
+		if err != nil {
+			http.Error(w, "Error al validar el CURP", http.StatusInternalServerError)
+			return
+		}
+		if !authorized {
+			http.Error(w, "El CURP no está autorizado para registrarse", http.StatusForbidden)
+			return
+		}
+		newUser.Rol = auth.RoleAdministracion
+	default:
+		http.Error(w, "Se requiere una invitación válida para registrarse", http.StatusForbidden)
+		return
+	}
+
+	// Si el registro falla después de consumir la invitación, devolverla a su estado pendiente
+	registered := false
+	defer func() {
+		if invitation != nil && !registered {
+			if err := h.Invitations.Release(context.Background(), invitation.ID); err != nil {
+				log.Printf("Error liberando la invitación %s: %v", invitation.ID.Hex(), err)
+			}
+		}
+	}()
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
 	if err != nil {
 		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
@@ -91,12 +146,27 @@ func (h *SignupHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		newUser.ProfilePicture = ""
 	}
 
-	_, err = collection.InsertOne(context.TODO(), newUser)
+	_, err = collection.InsertOne(r.Context(), newUser)
 	if err != nil {
 		http.Error(w, "Error al registrar el usuario", http.StatusInternalServerError)
 		return
 	}
+	registered = true
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Usuario registrado con éxito"})
 }
+
+// isAuthorizedCURP indica si el CURP fue registrado en valid_curps y todavía no lo usa ningún usuario
+func (h *SignupHandler) isAuthorizedCURP(ctx context.Context, curp string) (bool, error) {
+	db := h.Client.Database(constants.MongoDBDatabase)
+	authorized, err := db.Collection(constants.CollectionValidCURPs).CountDocuments(ctx, bson.M{"curp": curp})
+	if err != nil || authorized == 0 {
+		return false, err
+	}
+	used, err := db.Collection(constants.CollectionUsers).CountDocuments(ctx, bson.M{"curp": curp})
+	if err != nil {
+		return false, err
+	}
+	return used == 0, nil
+}
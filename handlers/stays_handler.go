@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StaysHandler maneja el registro de entradas (check-in) y salidas (check-out) de las habitaciones
+type StaysHandler struct {
+	Stays *services.StayService
+}
+
+// CheckInHandler registra la entrada de un huésped o inquilino a una habitación libre
+func (h *StaysHandler) CheckInHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RoomNumber    string `json:"roomNumber"`
+		ClientID      string `json:"clientId"`
+		ReservationID string `json:"reservationId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.RoomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
+		return
+	}
+
+	clientID, err := primitive.ObjectIDFromHex(payload.ClientID)
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	checkIn := services.CheckIn{RoomNumber: payload.RoomNumber, ClientID: clientID, User: actingUser(r)}
+	if payload.ReservationID != "" {
+		reservationID, err := primitive.ObjectIDFromHex(payload.ReservationID)
+		if err != nil {
+			http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+			return
+		}
+		checkIn.ReservationID = &reservationID
+	}
+
+	room, err := h.Stays.CheckIn(r.Context(), checkIn)
+	if err != nil {
+		writeStayError(w, err, "Failed to check in")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// AssignOccupantHandler atiende la ruta anterior PUT /rooms/assign ({roomNumber, occupantId}); es un check-in
+// sin reservación, con las mismas validaciones, cambio de estado e historial
+func (h *StaysHandler) AssignOccupantHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RoomNumber string `json:"roomNumber"`
+		OccupantID string `json:"occupantId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.RoomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
+		return
+	}
+	occupantID, err := primitive.ObjectIDFromHex(payload.OccupantID)
+	if err != nil {
+		http.Error(w, "Invalid occupant ID", http.StatusBadRequest)
+		return
+	}
+
+	room, err := h.Stays.CheckIn(r.Context(), services.CheckIn{RoomNumber: payload.RoomNumber, ClientID: occupantID, User: actingUser(r)})
+	if err != nil {
+		writeStayError(w, err, "Failed to assign occupant to room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// CheckOutHandler registra la salida del ocupante de la habitación y la deja libre
+func (h *StaysHandler) CheckOutHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RoomNumber string `json:"roomNumber"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.RoomNumber == "" {
+		http.Error(w, "Room number is required", http.StatusBadRequest)
+		return
+	}
+
+	room, err := h.Stays.CheckOut(r.Context(), payload.RoomNumber, actingUser(r))
+	if err != nil {
+		writeStayError(w, err, "Failed to check out")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room)
+}
+
+// actingUser devuelve el correo del usuario autenticado que realiza la operación
+func actingUser(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		return claims.Username
+	}
+	return ""
+}
+
+// writeStayError traduce los errores del StayService a respuestas HTTP
+func writeStayError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case services.ErrRoomNotFound:
+		http.Error(w, "Room not found", http.StatusNotFound)
+	case services.ErrClientNotFound:
+		http.Error(w, "Client not found", http.StatusNotFound)
+	case services.ErrRoomOccupied:
+		http.Error(w, "Room is already occupied", http.StatusConflict)
+	case services.ErrRoomOutOfService:
+		http.Error(w, "Room is out of service", http.StatusConflict)
+	case services.ErrRoomNotReady:
+		http.Error(w, "Room is not ready, it must be cleaned and inspected first", http.StatusConflict)
+	case services.ErrRoomNotOccupied:
+		http.Error(w, "Room is not occupied", http.StatusConflict)
+	case services.ErrReservationMismatch:
+		http.Error(w, "Reservation is not confirmed for this room and client", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+	"hotelman-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UsersHandler permite a los administradores editar, cambiar de rol y desactivar usuarios del personal,
+// y a cada usuario cambiar su propia contraseña
+type UsersHandler struct {
+	Client        *mongo.Client
+	Roles         *services.RoleStore
+	Sessions      *services.SessionStore
+	RefreshTokens *services.RefreshTokenStore
+}
+
+func (h *UsersHandler) users() *mongo.Collection {
+	return h.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionUsers)
+}
+
+// UpdateUserHandler actualiza los datos de perfil del usuario indicado en ?correo=
+func (h *UsersHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	correo := r.URL.Query().Get("correo")
+	if correo == "" {
+		http.Error(w, "Missing correo", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Nombres   *string `json:"nombres"`
+		Apellidos *string `json:"apellidos"`
+		Celular   *string `json:"celular"`
+		CURP      *string `json:"curp"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	// Solo se actualizan los campos presentes en el cuerpo
+	update := bson.M{}
+	if payload.Nombres != nil {
+		update["nombres"] = strings.TrimSpace(*payload.Nombres)
+	}
+	if payload.Apellidos != nil {
+		update["apellidos"] = strings.TrimSpace(*payload.Apellidos)
+	}
+	if payload.Celular != nil {
+		update["celular"] = strings.TrimSpace(*payload.Celular)
+	}
+	if payload.CURP != nil {
+		if *payload.CURP != "" && !isValidCURP(*payload.CURP) {
+			http.Error(w, "CURP inválido", http.StatusBadRequest)
+			return
+		}
+		update["curp"] = *payload.CURP
+	}
+	if len(update) == 0 {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	err := h.users().FindOneAndUpdate(r.Context(),
+		bson.M{"correo": correo},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.Public())
+}
+
+// ChangeRoleHandler asigna un nuevo rol al usuario indicado en ?correo= y cierra sus sesiones
+// para que el rol nuevo se aplique en el siguiente login
+func (h *UsersHandler) ChangeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	correo := r.URL.Query().Get("correo")
+	if correo == "" {
+		http.Error(w, "Missing correo", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Rol string `json:"rol"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || strings.TrimSpace(payload.Rol) == "" {
+		http.Error(w, "Rol is required", http.StatusBadRequest)
+		return
+	}
+	payload.Rol = strings.TrimSpace(payload.Rol)
+
+	if _, err := h.Roles.Get(r.Context(), payload.Rol); err == services.ErrRoleNotFound {
+		http.Error(w, "Unknown role: "+payload.Rol, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to change role", http.StatusInternalServerError)
+		return
+	}
+
+	user, ok := h.findUser(w, r, correo)
+	if !ok {
+		return
+	}
+	if user.Rol == payload.Rol {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user.Public())
+		return
+	}
+	if isSelf(r, correo) {
+		http.Error(w, "You cannot change your own role", http.StatusForbidden)
+		return
+	}
+	if !h.keepsAnAdmin(w, r, user) {
+		return
+	}
+
+	if _, err := h.users().UpdateOne(r.Context(), bson.M{"correo": correo}, bson.M{"$set": bson.M{"rol": payload.Rol}}); err != nil {
+		http.Error(w, "Failed to change role", http.StatusInternalServerError)
+		return
+	}
+	h.revokeAccess(r, correo)
+
+	user.Rol = payload.Rol
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.Public())
+}
+
+// SetUserStatusHandler desactiva o reactiva la cuenta indicada en ?correo=.
+// Desactivar una cuenta impide el login y revoca todas sus sesiones abiertas.
+func (h *UsersHandler) SetUserStatusHandler(w http.ResponseWriter, r *http.Request) {
+	correo := r.URL.Query().Get("correo")
+	if correo == "" {
+		http.Error(w, "Missing correo", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Disabled *bool `json:"disabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Disabled == nil {
+		http.Error(w, "disabled is required", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.findUser(w, r, correo)
+	if !ok {
+		return
+	}
+	if *payload.Disabled {
+		if isSelf(r, correo) {
+			http.Error(w, "You cannot disable your own account", http.StatusForbidden)
+			return
+		}
+		if !user.Disabled && !h.keepsAnAdmin(w, r, user) {
+			return
+		}
+	}
+
+	if _, err := h.users().UpdateOne(r.Context(), bson.M{"correo": correo}, bson.M{"$set": bson.M{"disabled": *payload.Disabled}}); err != nil {
+		http.Error(w, "Failed to update user status", http.StatusInternalServerError)
+		return
+	}
+	if *payload.Disabled {
+		h.revokeAccess(r, correo)
+	}
+
+	user.Disabled = *payload.Disabled
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.Public())
+}
+
+// ChangePasswordHandler cambia la contraseña del usuario autenticado tras verificar la actual
+// y cierra el resto de sus sesiones, conservando la sesión desde la que se hizo el cambio
+func (h *UsersHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		ContrasenaActual    string `json:"contrasenaActual"`
+		Contrasena          string `json:"contrasena"`
+		ConfirmarContrasena string `json:"confirmarContrasena"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	if payload.Contrasena == "" {
+		http.Error(w, "La contraseña es obligatoria", http.StatusBadRequest)
+		return
+	}
+	if payload.Contrasena != payload.ConfirmarContrasena {
+		http.Error(w, "Las contraseñas no coinciden", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := h.findUser(w, r, claims.Username)
+	if !ok {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.ContrasenaActual)) != nil {
+		http.Error(w, "La contraseña actual es incorrecta", http.StatusUnauthorized)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Contrasena), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
+		return
+	}
+	if _, err := h.users().UpdateOne(r.Context(), bson.M{"correo": user.Correo}, bson.M{"$set": bson.M{"password": string(hashedPassword)}}); err != nil {
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.Sessions.RevokeOthersForUser(r.Context(), user.Correo, claims.TokenID); err != nil {
+		log.Printf("Error revocando las sesiones de %s: %v", user.Correo, err)
+	}
+	if err := h.RefreshTokens.RevokeOthersForUser(r.Context(), user.Correo, claims.TokenID); err != nil {
+		log.Printf("Error revocando los refresh tokens de %s: %v", user.Correo, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Contraseña actualizada con éxito"})
+}
+
+// findUser busca el usuario por correo; si no existe o falla la consulta responde y devuelve false
+func (h *UsersHandler) findUser(w http.ResponseWriter, r *http.Request, correo string) (models.User, bool) {
+	var user models.User
+	err := h.users().FindOne(r.Context(), bson.M{"correo": correo}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return user, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return user, false
+	}
+	return user, true
+}
+
+// keepsAnAdmin evita dejar el sistema sin administradores activos al quitarle el rol o desactivar a uno
+func (h *UsersHandler) keepsAnAdmin(w http.ResponseWriter, r *http.Request, user models.User) bool {
+	if user.Rol != auth.RoleAdministracion || user.Disabled {
+		return true
+	}
+	admins, err := h.users().CountDocuments(r.Context(), bson.M{"rol": auth.RoleAdministracion, "disabled": bson.M{"$ne": true}})
+	if err != nil {
+		http.Error(w, "Failed to count administrators", http.StatusInternalServerError)
+		return false
+	}
+	if admins <= 1 {
+		http.Error(w, "At least one active administrator is required", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// revokeAccess cierra todas las sesiones y refresh tokens del usuario; los errores solo se registran
+func (h *UsersHandler) revokeAccess(r *http.Request, correo string) {
+	if _, err := h.Sessions.RevokeAllForUser(r.Context(), correo); err != nil {
+		log.Printf("Error revocando las sesiones de %s: %v", correo, err)
+	}
+	if err := h.RefreshTokens.RevokeAllForUser(r.Context(), correo); err != nil {
+		log.Printf("Error revocando los refresh tokens de %s: %v", correo, err)
+	}
+}
+
+// isSelf indica si el correo corresponde al usuario autenticado
+func isSelf(r *http.Request, correo string) bool {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	return ok && claims.Username == correo
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"hotelman-backend/auth"
 )
 
 // WelcomeHandler estructura para el manejador de la ruta /welcome
@@ -10,17 +12,8 @@ type WelcomeHandler struct{}
 
 // Handle maneja la solicitud para el endpoint /welcome
 func (h *WelcomeHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	// Extraer todas las cookies de la solicitud
-	cookies := r.Cookies()
-
-	// Aquí puedes ajustar cómo obtienes el token, por ejemplo, de las cookies o de la sesión
-	var token string
-	for _, cookie := range cookies {
-		if cookie.Name == "Authorize" { // Cambia "token" al nombre real de la cookie
-			token = cookie.Value
-			break
-		}
-	}
+	// Obtener el token con el que se autenticó la solicitud (cookie o encabezado Authorization)
+	token := auth.TokenFromRequest(r)
 
 	// Configurar el encabezado de tipo de contenido y el código de estado
 	w.Header().Set("Content-Type", "application/json")
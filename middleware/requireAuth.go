@@ -1,117 +1,79 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
-	"strings"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"hotelman-backend/auth"
+	"hotelman-backend/services"
 )
 
 type RequireAuth struct {
-	jwtKey []byte
-	roles  []string
+	tokens   *auth.TokenManager
+	sessions *services.SessionStore
+	roles    *services.RoleStore
 }
 
-func NewRequireAuth(jwtKey []byte, roles []string) *RequireAuth {
-	return &RequireAuth{jwtKey: jwtKey, roles: roles}
+func NewRequireAuth(tokens *auth.TokenManager, sessions *services.SessionStore, roles *services.RoleStore) *RequireAuth {
+	return &RequireAuth{tokens: tokens, sessions: sessions, roles: roles}
 }
 
-func (ra *RequireAuth) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var tokenString string
-
-		// Obtener el token de la cookie
-		if cookie, err := r.Cookie("Authorize"); err == nil {
-			tokenString = cookie.Value
-		} else {
-			// Obtener el token del encabezado Authorization
-			authHeader := r.Header.Get("Authorization")
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte("Unauthorized - Token missing or malformed"))
-				return
-			}
-		}
+// Require protege el handler exigiendo un token válido cuyo rol tenga el permiso indicado
+func (ra *RequireAuth) Require(permission auth.Permission, next http.HandlerFunc) http.Handler {
+	return ra.Middleware(permission, next)
+}
 
-		if tokenString == "" {
+func (ra *RequireAuth) Middleware(permission auth.Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Obtener el token de la cookie o del encabezado Authorization y verificarlo
+		claims, err := ra.tokens.Parse(auth.TokenFromRequest(r))
+		switch err {
+		case nil:
+		case auth.ErrTokenMissing:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized - Token missing or malformed"))
+			return
+		case auth.ErrTokenExpired:
 			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("Unauthorized - Token missing"))
+			w.Write([]byte("Unauthorized - Token has expired"))
+			return
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized - Token is not valid"))
 			return
 		}
 
-		// Parsear y verificar el token JWT
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return ra.jwtKey, nil
-		})
-
+		// Resolver los permisos a partir del documento del rol del usuario
+		role, err := ra.roles.Get(r.Context(), claims.Role)
+		if err == services.ErrRoleNotFound {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Forbidden - Unknown role"))
+			return
+		}
 		if err != nil {
-			if err == jwt.ErrSignatureInvalid {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte("Unauthorized - Invalid token signature"))
-				return
-			}
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("Bad request - Token parsing error"))
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Internal server error - Role lookup failed"))
 			return
 		}
-		if !token.Valid {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("Unauthorized - Token is not valid"))
+		if !role.HasPermission(string(permission)) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Forbidden - Access denied"))
 			return
 		}
 
-		// Extraer los claims estándar
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Verificar si el token ha expirado
-			if exp, ok := claims["exp"].(float64); ok {
-				if time.Unix(int64(exp), 0).Before(time.Now()) {
-					w.WriteHeader(http.StatusUnauthorized)
-					w.Write([]byte("Unauthorized - Token has expired"))
-					return
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte("Unauthorized - Token missing expiration"))
-				return
-			}
-
-			// Verificar el rol del usuario
-			if role, ok := claims["rol"].(string); ok {
-				if !contains(ra.roles, role) {
-					w.WriteHeader(http.StatusForbidden)
-					w.Write([]byte("Forbidden - Access denied"))
-					return
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte("Unauthorized - Token missing role"))
-				return
-			}
-
-			// Añadir los claims al contexto de la solicitud
-			ctx := context.WithValue(r.Context(), "claims", claims)
-			r = r.WithContext(ctx)
-
-			// Continuar con el siguiente handler
-			next.ServeHTTP(w, r)
-		} else {
+		// Verificar que la sesión del token siga activa (no revocada)
+		active, err := ra.sessions.IsActive(r.Context(), claims.TokenID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Internal server error - Session lookup failed"))
+			return
+		}
+		if !active {
 			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("Unauthorized - Token claims error"))
+			w.Write([]byte("Unauthorized - Session has been revoked"))
 			return
 		}
-	})
-}
 
-// Helper function to check if a slice contains a specific string
-func contains(slice []string, item string) bool {
-	for _, v := range slice {
-		if v == item {
-			return true
-		}
-	}
-	return false
+		// Añadir los claims al contexto de la solicitud y continuar con el siguiente handler
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
 }
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Periodos en los que se agrupan las series de analytics
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // De lunes a domingo
+	BucketMonth = "month"
+)
+
+// IsValidBucket indica si el periodo es uno de los soportados
+func IsValidBucket(bucket string) bool {
+	switch bucket {
+	case BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// Revenue son los pagos vigentes recibidos, separados por tipo de cliente y por método de pago
+type Revenue struct {
+	Total    float64            `json:"total"`
+	Guest    float64            `json:"guest"`
+	Rental   float64            `json:"rental"`
+	ByMethod map[string]float64 `json:"byMethod"`
+}
+
+// AnalyticsPeriod son las métricas de un periodo. Una habitación cuenta como vendida la noche de cada día
+// entre el check-in y el día anterior al check-out; una estancia que entra y sale el mismo día cuenta una noche.
+type AnalyticsPeriod struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`            // Exclusivo
+	RoomsAvailable int       `json:"roomsAvailable"` // Habitaciones-día disponibles
+	RoomsSold      int       `json:"roomsSold"`      // Habitaciones-día ocupadas
+	OccupancyRate  float64   `json:"occupancyRate"`  // RoomsSold / RoomsAvailable, de 0 a 1
+	ADR            float64   `json:"adr"`            // Ingreso por habitaciones / RoomsSold
+	RevPAR         float64   `json:"revpar"`         // Ingreso por habitaciones / RoomsAvailable
+	Revenue        Revenue   `json:"revenue"`
+}
+
+// AnalyticsReport son las métricas del rango pedido, en total y por periodo. RoomType indica qué habitaciones
+// y qué ingresos se usan para la ocupación, el ADR y el RevPAR.
+type AnalyticsReport struct {
+	StartDate time.Time         `json:"startDate"`
+	EndDate   time.Time         `json:"endDate"` // Exclusivo
+	Bucket    string            `json:"bucket"`
+	RoomType  string            `json:"roomType"`
+	Summary   AnalyticsPeriod   `json:"summary"`
+	Series    []AnalyticsPeriod `json:"series"`
+}
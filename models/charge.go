@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de cargo
+const (
+	ChargeRent    = "rent"
+	ChargeDeposit = "deposit"
+	ChargeStay    = "stay"    // Estancia de un huésped
+	ChargeLateFee = "lateFee" // Recargo por renta vencida
+)
+
+// Estados de un cargo
+const (
+	ChargePending   = "pending"   // Por cobrar
+	ChargeOverdue   = "overdue"   // Renta sin cubrir después del periodo de gracia
+	ChargePaid      = "paid"      // Cubierto
+	ChargeCancelled = "cancelled" // Anulado, p. ej. por terminación anticipada del contrato
+)
+
+// Charge es un importe que un cliente debe pagar, como la renta de un mes o un depósito
+type Charge struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ClientID    primitive.ObjectID  `bson:"clientId" json:"clientId"`
+	LeaseID     *primitive.ObjectID `bson:"leaseId,omitempty" json:"leaseId,omitempty"`
+	RelatedTo   *primitive.ObjectID `bson:"relatedTo,omitempty" json:"relatedTo,omitempty"` // Solo lateFee: renta vencida que lo originó
+	Kind        string              `bson:"kind" json:"kind"`
+	Description string              `bson:"description" json:"description"`
+	PeriodStart *time.Time          `bson:"periodStart,omitempty" json:"periodStart,omitempty"` // Solo renta: mes que cubre
+	PeriodEnd   *time.Time          `bson:"periodEnd,omitempty" json:"periodEnd,omitempty"`
+	DueDate     time.Time           `bson:"dueDate" json:"dueDate"`
+	Amount      float64             `bson:"amount" json:"amount"`
+	Paid        float64             `bson:"paid" json:"paid"` // Suma de los pagos vigentes aplicados al cargo
+	Status      string              `bson:"status" json:"status"`
+	CreatedAt   time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
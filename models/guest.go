@@ -15,7 +15,16 @@ type Guest struct {
 	RoomNumber       string             `bson:"roomNumber" json:"roomNumber"`
 	Price            float64            `bson:"price" json:"price"`
 	Duration         int                `bson:"duration" json:"duration"`
+	DurationUnit     string             `bson:"durationUnit,omitempty" json:"durationUnit,omitempty"` // "hours" o "nights"
 	History          []HistoryRecord    `bson:"history" json:"history"`
 	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt        time.Time          `bson:"updatedAt" json:"updatedAt"`
+
+	// Cotización con la que se fijó el precio; si el precio capturado difiere, se guarda quién lo cambió
+	QuotedPrice         *float64          `bson:"quotedPrice,omitempty" json:"quotedPrice,omitempty"`
+	RatePlans           []AppliedRatePlan `bson:"ratePlans,omitempty" json:"ratePlans,omitempty"`
+	PriceOverriddenBy   string            `bson:"priceOverriddenBy,omitempty" json:"priceOverriddenBy,omitempty"`
+	PriceOverrideReason string            `bson:"priceOverrideReason,omitempty" json:"priceOverrideReason,omitempty"`
+
+	FiscalData *FiscalData `bson:"fiscalData,omitempty" json:"fiscalData,omitempty"` // Para facturar
 }
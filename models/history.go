@@ -2,9 +2,20 @@ package models
 
 import (
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Acciones registradas en el historial de un cliente
+const (
+	HistoryCheckIn  = "checkIn"
+	HistoryCheckOut = "checkOut"
 )
 
 type HistoryRecord struct {
-	Action   string    `bson:"action" json:"action"`     // "checkIn" o "checkOut"
-	DateTime time.Time `bson:"dateTime" json:"dateTime"` // Fecha y hora del evento
+	Action        string              `bson:"action" json:"action"`                                   // "checkIn" o "checkOut"
+	DateTime      time.Time           `bson:"dateTime" json:"dateTime"`                               // Fecha y hora del evento
+	RoomNumber    string              `bson:"roomNumber,omitempty" json:"roomNumber,omitempty"`       // Habitación en la que ocurrió
+	User          string              `bson:"user,omitempty" json:"user,omitempty"`                   // Usuario del personal que lo registró
+	ReservationID *primitive.ObjectID `bson:"reservationId,omitempty" json:"reservationId,omitempty"` // Reservación asociada, si la hay
 }
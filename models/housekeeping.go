@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados de una tarea de limpieza
+const (
+	HousekeepingPending    = "pending"    // Creada, esperando a que alguien la empiece
+	HousekeepingInProgress = "inProgress" // Limpieza en curso
+	HousekeepingFinished   = "finished"   // Limpieza terminada, pendiente de inspección
+	HousekeepingInspected  = "inspected"  // Inspección aprobada; la habitación quedó disponible
+	HousekeepingRejected   = "rejected"   // Inspección rechazada; se generó una nueva tarea para repetir la limpieza
+)
+
+// HousekeepingTask es una tarea de limpieza de una habitación en el tablero de housekeeping
+type HousekeepingTask struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID      primitive.ObjectID `bson:"roomId" json:"roomId"`
+	RoomNumber  string             `bson:"roomNumber" json:"roomNumber"`
+	Status      string             `bson:"status" json:"status"`
+	AssignedTo  string             `bson:"assignedTo,omitempty" json:"assignedTo,omitempty"` // Correo del usuario que hará la limpieza
+	AssignedBy  string             `bson:"assignedBy,omitempty" json:"assignedBy,omitempty"`
+	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	InspectedBy string             `bson:"inspectedBy,omitempty" json:"inspectedBy,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	AssignedAt  *time.Time         `bson:"assignedAt,omitempty" json:"assignedAt,omitempty"`
+	StartedAt   *time.Time         `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	FinishedAt  *time.Time         `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	InspectedAt *time.Time         `bson:"inspectedAt,omitempty" json:"inspectedAt,omitempty"`
+}
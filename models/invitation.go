@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation representa una invitación para que un nuevo miembro del personal se registre con un rol preasignado.
+// Solo se guarda el hash del token; si Correo no está vacío, la invitación solo sirve para ese correo.
+type Invitation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	Correo    string             `bson:"correo,omitempty" json:"correo,omitempty"`
+	Rol       string             `bson:"rol" json:"rol"`
+	CreatedBy string             `bson:"createdBy" json:"createdBy"`
+	Revoked   bool               `bson:"revoked" json:"revoked"`
+	UsedBy    string             `bson:"usedBy,omitempty" json:"usedBy,omitempty"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FiscalData son los datos fiscales del receptor de una factura, tal como aparecen en su constancia de
+// situación fiscal
+type FiscalData struct {
+	RFC           string `bson:"rfc" json:"rfc"`
+	RazonSocial   string `bson:"razonSocial" json:"razonSocial"`
+	RegimenFiscal string `bson:"regimenFiscal" json:"regimenFiscal"` // Clave del catálogo c_RegimenFiscal, p. ej. "612"
+	CodigoPostal  string `bson:"codigoPostal" json:"codigoPostal"`   // Código postal del domicilio fiscal
+	UsoCFDI       string `bson:"usoCfdi" json:"usoCfdi"`             // Clave del catálogo c_UsoCFDI, p. ej. "G03"
+}
+
+// Estados de una factura
+const (
+	InvoicePending = "pending" // Folio apartado, esperando el timbrado del PAC
+	InvoiceStamped = "stamped" // Timbrada
+)
+
+// InvoiceConcept es una línea de la factura; Amount incluye el IVA trasladado
+type InvoiceConcept struct {
+	PaymentID     primitive.ObjectID `bson:"paymentId" json:"paymentId"`
+	ClaveProdServ string             `bson:"claveProdServ" json:"claveProdServ"`
+	Description   string             `bson:"description" json:"description"`
+	Base          float64            `bson:"base" json:"base"`
+	IVA           float64            `bson:"iva" json:"iva"`
+	Exempt        bool               `bson:"exempt" json:"exempt"` // Exento de IVA, como la renta de casa habitación
+	Amount        float64            `bson:"amount" json:"amount"`
+}
+
+// Invoice es un CFDI 4.0 de ingreso emitido por uno o varios pagos de un cliente
+type Invoice struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	ClientID   primitive.ObjectID   `bson:"clientId" json:"clientId"`
+	PaymentIDs []primitive.ObjectID `bson:"paymentIds" json:"paymentIds"`
+	Serie      string               `bson:"serie" json:"serie"`
+	Folio      int64                `bson:"folio" json:"folio"`
+	Receptor   FiscalData           `bson:"receptor" json:"receptor"`
+	FormaPago  string               `bson:"formaPago" json:"formaPago"` // Clave del catálogo c_FormaPago
+	Concepts   []InvoiceConcept     `bson:"concepts" json:"concepts"`
+	Subtotal   float64              `bson:"subtotal" json:"subtotal"`
+	IVA        float64              `bson:"iva" json:"iva"`
+	Total      float64              `bson:"total" json:"total"`
+	Status     string               `bson:"status" json:"status"`
+	UUID       string               `bson:"uuid,omitempty" json:"uuid,omitempty"` // Folio fiscal asignado por el PAC
+	StampedAt  *time.Time           `bson:"stampedAt,omitempty" json:"stampedAt,omitempty"`
+	XML        string               `bson:"xml,omitempty" json:"-"` // CFDI timbrado
+	XMLURL     string               `bson:"xmlUrl,omitempty" json:"xmlUrl,omitempty"`
+	PDFURL     string               `bson:"pdfUrl,omitempty" json:"pdfUrl,omitempty"`
+	IssuedBy   string               `bson:"issuedBy" json:"issuedBy"`
+	CreatedAt  time.Time            `bson:"createdAt" json:"createdAt"`
+}
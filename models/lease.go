@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados de un contrato de arrendamiento
+const (
+	LeaseActive     = "active"     // Vigente; genera cargos mensuales
+	LeaseRenewed    = "renewed"    // Sustituido por su renovación
+	LeaseTerminated = "terminated" // Terminado antes de su fecha de fin
+)
+
+// Valores de Rental.Estado que administran los contratos
+const (
+	RentalEstadoActivo     = "activo"
+	RentalEstadoAtrasado   = "atrasado" // Con rentas vencidas
+	RentalEstadoFinalizado = "finalizado"
+)
+
+// Lease es el contrato de arrendamiento de un inquilino. Al crearse genera un cargo de renta por cada mes
+// entre StartDate y EndDate, con vencimiento el día DueDay, y un cargo por el depósito si lo hay.
+type Lease struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	RentalID      primitive.ObjectID  `bson:"rentalId" json:"rentalId"`
+	RoomNumber    string              `bson:"roomNumber" json:"roomNumber"`
+	StartDate     time.Time           `bson:"startDate" json:"startDate"`
+	EndDate       time.Time           `bson:"endDate" json:"endDate"` // Día siguiente al último día de renta
+	MonthlyAmount float64             `bson:"monthlyAmount" json:"monthlyAmount"`
+	DueDay        int                 `bson:"dueDay" json:"dueDay"` // Día del mes en que vence la renta (1-28)
+	Deposit       float64             `bson:"deposit" json:"deposit"`
+	Status        string              `bson:"status" json:"status"`
+	RenewedFrom   *primitive.ObjectID `bson:"renewedFrom,omitempty" json:"renewedFrom,omitempty"`
+	RenewedBy     *primitive.ObjectID `bson:"renewedBy,omitempty" json:"renewedBy,omitempty"` // Contrato que lo sustituyó
+	CreatedBy     string              `bson:"createdBy" json:"createdBy"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+
+	TerminatedAt      *time.Time `bson:"terminatedAt,omitempty" json:"terminatedAt,omitempty"`
+	TerminatedBy      string     `bson:"terminatedBy,omitempty" json:"terminatedBy,omitempty"`
+	TerminationReason string     `bson:"terminationReason,omitempty" json:"terminationReason,omitempty"`
+}
+
+// OverdueTenant resume la deuda vencida de un inquilino
+type OverdueTenant struct {
+	RentalID       primitive.ObjectID `bson:"_id" json:"rentalId"`
+	Nombres        string             `bson:"nombres" json:"nombres"`
+	Apellidos      string             `bson:"apellidos" json:"apellidos"`
+	RoomNumber     string             `bson:"roomNumber" json:"roomNumber"`
+	OverdueCharges int                `bson:"overdueCharges" json:"overdueCharges"` // Rentas vencidas sin cubrir
+	AmountOwed     float64            `bson:"amountOwed" json:"amountOwed"`         // Saldo de las rentas vencidas y sus recargos
+	OldestDueDate  time.Time          `bson:"oldestDueDate" json:"oldestDueDate"`
+	DaysLate       int                `bson:"-" json:"daysLate"` // Días desde el vencimiento más antiguo
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resultados posibles de un intento de login
+const (
+	LoginOutcomeSuccess    = "success"     // Sesión abierta
+	LoginOutcomeMFAPending = "mfa_pending" // Contraseña correcta, falta el segundo factor
+	LoginOutcomeFailure    = "failure"     // Usuario o contraseña incorrectos
+	LoginOutcomeMFAFailure = "mfa_failure" // Código TOTP o de recuperación incorrecto
+	LoginOutcomeThrottled  = "throttled"   // Rechazado por el retardo progresivo
+	LoginOutcomeLocked     = "locked"      // Rechazado por bloqueo de la cuenta o la IP
+	LoginOutcomeDisabled   = "disabled"    // Credenciales correctas pero la cuenta está desactivada
+)
+
+// LoginAttempt registra cada intento de inicio de sesión para auditoría
+type LoginAttempt struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Username  string             `bson:"username" json:"username"`
+	IP        string             `bson:"ip" json:"ip"`
+	UserAgent string             `bson:"userAgent" json:"userAgent"`
+	Outcome   string             `bson:"outcome" json:"outcome"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// LoginThrottle lleva la cuenta de fallos consecutivos de una cuenta o de una IP
+type LoginThrottle struct {
+	Key           string     `bson:"key" json:"key"` // "account:<usuario>", "ip:<dirección>" o "reset:<dirección>"
+	Failures      int        `bson:"failures" json:"failures"`
+	LastFailureAt time.Time  `bson:"lastFailureAt" json:"lastFailureAt"`
+	LockedUntil   *time.Time `bson:"lockedUntil,omitempty" json:"lockedUntil,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prioridades de un ticket de mantenimiento
+const (
+	MaintenancePriorityLow    = "low"
+	MaintenancePriorityMedium = "medium"
+	MaintenancePriorityHigh   = "high"
+	MaintenancePriorityUrgent = "urgent"
+)
+
+// Estados de un ticket de mantenimiento
+const (
+	MaintenanceOpen       = "open"       // Reportado, sin atender
+	MaintenanceInProgress = "inProgress" // En reparación
+	MaintenanceClosed     = "closed"     // Resuelto o descartado
+)
+
+// IsValidMaintenancePriority indica si la prioridad es una de las definidas
+func IsValidMaintenancePriority(priority string) bool {
+	switch priority {
+	case MaintenancePriorityLow, MaintenancePriorityMedium, MaintenancePriorityHigh, MaintenancePriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// MaintenanceTicket registra una falla de una habitación (aire acondicionado, plomería, etc.).
+// Mientras una habitación tenga tickets bloqueantes abiertos permanece fuera de servicio.
+type MaintenanceTicket struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID      primitive.ObjectID `bson:"roomId" json:"roomId"`
+	RoomNumber  string             `bson:"roomNumber" json:"roomNumber"`
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description" json:"description"`
+	Priority    string             `bson:"priority" json:"priority"`
+	Blocking    bool               `bson:"blocking" json:"blocking"` // Impide usar la habitación hasta cerrarse
+	Status      string             `bson:"status" json:"status"`
+	Photos      []string           `bson:"photos" json:"photos"` // URLs de las fotos subidas con el servicio de almacenamiento
+	ReportedBy  string             `bson:"reportedBy" json:"reportedBy"`
+	AssignedTo  string             `bson:"assignedTo,omitempty" json:"assignedTo,omitempty"`
+	Resolution  string             `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	ClosedBy    string             `bson:"closedBy,omitempty" json:"closedBy,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ClosedAt    *time.Time         `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordReset representa un token de restablecimiento de contraseña de un solo uso; solo se guarda su hash
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	Correo    string             `bson:"correo" json:"correo"`
+	IP        string             `bson:"ip" json:"ip"`
+	Used      bool               `bson:"used" json:"used"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
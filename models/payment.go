@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Métodos de pago
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentTransfer = "transfer"
+)
+
+// Estados de un pago
+const (
+	PaymentValid  = "valid"
+	PaymentVoided = "voided" // Anulado; ya no cuenta en el saldo ni en el cargo
+)
+
+// IsValidPaymentMethod indica si el método de pago es uno de los definidos
+func IsValidPaymentMethod(method string) bool {
+	switch method {
+	case PaymentCash, PaymentCard, PaymentTransfer:
+		return true
+	}
+	return false
+}
+
+// Payment es dinero recibido de un huésped o inquilino, aplicado a un cargo o a cuenta de su saldo
+type Payment struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ClientID   primitive.ObjectID  `bson:"clientId" json:"clientId"`
+	ChargeID   *primitive.ObjectID `bson:"chargeId,omitempty" json:"chargeId,omitempty"`
+	Amount     float64             `bson:"amount" json:"amount"`
+	Method     string              `bson:"method" json:"method"`
+	Reference  string              `bson:"reference,omitempty" json:"reference,omitempty"` // Folio de la terminal o de la transferencia
+	Notes      string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	Status     string              `bson:"status" json:"status"`
+	ReceivedBy string              `bson:"receivedBy" json:"receivedBy"`
+	ReceivedAt time.Time           `bson:"receivedAt" json:"receivedAt"`
+	ReceiptURL string              `bson:"receiptUrl,omitempty" json:"receiptUrl,omitempty"` // Recibo PDF guardado; se descarta al anular el pago
+	InvoiceID  *primitive.ObjectID `bson:"invoiceId,omitempty" json:"invoiceId,omitempty"`   // Factura que lo incluye
+	ShiftID    *primitive.ObjectID `bson:"shiftId,omitempty" json:"shiftId,omitempty"`       // Turno de caja, solo en efectivo
+
+	VoidedBy   string     `bson:"voidedBy,omitempty" json:"voidedBy,omitempty"`
+	VoidedAt   *time.Time `bson:"voidedAt,omitempty" json:"voidedAt,omitempty"`
+	VoidReason string     `bson:"voidReason,omitempty" json:"voidReason,omitempty"`
+}
+
+// Balance es el estado de cuenta de un cliente
+type Balance struct {
+	ClientID       primitive.ObjectID `json:"clientId"`
+	Charged        float64            `json:"charged"` // Cargos no anulados
+	Paid           float64            `json:"paid"`    // Pagos vigentes
+	Balance        float64            `json:"balance"` // Charged - Paid; negativo si hay saldo a favor
+	PendingCharges []Charge           `json:"pendingCharges"`
+}
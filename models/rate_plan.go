@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de plan de tarifa
+const (
+	RatePlanHourly   = "hourly"   // Precio por hora
+	RatePlanNightly  = "nightly"  // Precio por noche
+	RatePlanWeekend  = "weekend"  // Precio por noche de viernes y sábado
+	RatePlanSeasonal = "seasonal" // Precio por noche dentro de una temporada
+	RatePlanLongStay = "longStay" // Descuento porcentual a partir de cierto número de noches
+)
+
+// Unidades de la duración de una estancia
+const (
+	DurationHours  = "hours"
+	DurationNights = "nights"
+)
+
+// IsValidRatePlanKind indica si el tipo de plan es uno de los definidos
+func IsValidRatePlanKind(kind string) bool {
+	switch kind {
+	case RatePlanHourly, RatePlanNightly, RatePlanWeekend, RatePlanSeasonal, RatePlanLongStay:
+		return true
+	}
+	return false
+}
+
+// RatePlan es una regla de precio para las estancias de huéspedes. Se aplica a las habitaciones de Category,
+// o a todas si Category está vacío; los planes de una categoría tienen prioridad sobre los generales.
+type RatePlan struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name     string             `bson:"name" json:"name"`
+	Kind     string             `bson:"kind" json:"kind"`
+	Category string             `bson:"category,omitempty" json:"category,omitempty"`
+	Rate     float64            `bson:"rate" json:"rate"` // Precio por hora o por noche; no aplica a longStay
+	Active   bool               `bson:"active" json:"active"`
+
+	// Solo seasonal: días naturales (UTC) de inicio y fin de la temporada, ambos incluidos
+	StartDate *time.Time `bson:"startDate,omitempty" json:"startDate,omitempty"`
+	EndDate   *time.Time `bson:"endDate,omitempty" json:"endDate,omitempty"`
+
+	// Solo longStay
+	MinNights       int     `bson:"minNights,omitempty" json:"minNights,omitempty"`
+	DiscountPercent float64 `bson:"discountPercent,omitempty" json:"discountPercent,omitempty"`
+
+	CreatedBy string    `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AppliedRatePlan identifica un plan de tarifa usado en una cotización
+type AppliedRatePlan struct {
+	ID   primitive.ObjectID `bson:"id" json:"id"`
+	Name string             `bson:"name" json:"name"`
+	Kind string             `bson:"kind" json:"kind"`
+}
+
+// QuoteLine es el precio de una noche, o de todas las horas de una estancia por horas
+type QuoteLine struct {
+	Date     time.Time        `json:"date"`
+	Quantity int              `json:"quantity"`
+	Rate     float64          `json:"rate"`
+	Amount   float64          `json:"amount"`
+	RatePlan *AppliedRatePlan `json:"ratePlan,omitempty"` // nil si se usó la tarifa base de la habitación
+}
+
+// Quote es el precio calculado para una estancia en una habitación
+type Quote struct {
+	RoomNumber   string            `json:"roomNumber"`
+	Category     string            `json:"category,omitempty"`
+	Unit         string            `json:"unit"`
+	Duration     int               `json:"duration"`
+	Start        time.Time         `json:"start"`
+	Lines        []QuoteLine       `json:"lines"`
+	Subtotal     float64           `json:"subtotal"`
+	Discount     float64           `json:"discount"`
+	Total        float64           `json:"total"`
+	RatePlans    []AppliedRatePlan `json:"ratePlans"` // Planes que intervinieron en el precio, sin repetir
+	BaseRateUsed bool              `json:"baseRateUsed"`
+}
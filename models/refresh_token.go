@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken representa un refresh token opaco; solo se guarda su hash SHA-256.
+// Todos los tokens obtenidos por rotación a partir de un mismo login comparten FamilyID,
+// que coincide con el TokenID de la sesión.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	FamilyID  string             `bson:"familyId" json:"familyId"`
+	Username  string             `bson:"username" json:"username"`
+	Role      string             `bson:"rol" json:"rol"`
+	Used      bool               `bson:"used" json:"used"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	Revoked   bool               `bson:"revoked" json:"revoked"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
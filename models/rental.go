@@ -22,4 +22,6 @@ type Rental struct {
 	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
 	Estado        string             `bson:"estado" json:"estado"`           // Nuevo campo Estado
 	RentalPrice   float64            `bson:"rentalPrice" json:"rentalPrice"` // Nuevo campo RentalPrice
+
+	FiscalData *FiscalData `bson:"fiscalData,omitempty" json:"fiscalData,omitempty"` // Para facturar
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reportes que se pueden exportar
+const (
+	ReportOccupancy = "occupancy" // Ocupación, ADR y RevPAR por periodo
+	ReportRevenue   = "revenue"   // Ingresos por tipo de cliente y método de pago por periodo
+	ReportOverdue   = "overdue"   // Inquilinos con rentas vencidas al momento de generarlo
+	ReportGuests    = "guests"    // Huéspedes que hicieron check-in en el rango
+)
+
+// Formatos de exportación
+const (
+	ReportCSV  = "csv"
+	ReportXLSX = "xlsx"
+)
+
+// Resultado de una ejecución de un reporte
+const (
+	ReportRunSucceeded = "succeeded"
+	ReportRunFailed    = "failed"
+)
+
+// ReportScheduler es el valor de GeneratedBy en las ejecuciones hechas por la programación
+const ReportScheduler = "scheduler"
+
+// IsValidReportKind indica si el reporte es uno de los definidos
+func IsValidReportKind(kind string) bool {
+	switch kind {
+	case ReportOccupancy, ReportRevenue, ReportOverdue, ReportGuests:
+		return true
+	}
+	return false
+}
+
+// IsValidReportFormat indica si el formato de exportación es uno de los soportados
+func IsValidReportFormat(format string) bool {
+	return format == ReportCSV || format == ReportXLSX
+}
+
+// ReportSchedule genera un reporte según una expresión cron de 5 campos (minuto, hora, día, mes, día de la
+// semana, en UTC). Cada ejecución cubre el Period completo anterior: el día, la semana (de lunes a domingo)
+// o el mes previo.
+type ReportSchedule struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name     string             `bson:"name" json:"name"`
+	Kind     string             `bson:"kind" json:"kind"`
+	Format   string             `bson:"format" json:"format"`
+	Cron     string             `bson:"cron" json:"cron"`
+	Period   string             `bson:"period" json:"period"`                         // day, week o month
+	Bucket   string             `bson:"bucket,omitempty" json:"bucket,omitempty"`     // Agrupación de ocupación e ingresos
+	RoomType string             `bson:"roomType,omitempty" json:"roomType,omitempty"` // guest, rental o all; solo ocupación e ingresos
+	Enabled  bool               `bson:"enabled" json:"enabled"`
+
+	NextRunAt *time.Time          `bson:"nextRunAt,omitempty" json:"nextRunAt,omitempty"` // Vacío si está desactivada
+	LastRunAt *time.Time          `bson:"lastRunAt,omitempty" json:"lastRunAt,omitempty"`
+	LastRunID *primitive.ObjectID `bson:"lastRunId,omitempty" json:"lastRunId,omitempty"`
+	CreatedBy string              `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ReportRun es una generación de un reporte, bajo demanda o programada, con el archivo guardado en el
+// almacenamiento configurado
+type ReportRun struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ScheduleID  *primitive.ObjectID `bson:"scheduleId,omitempty" json:"scheduleId,omitempty"`
+	Kind        string              `bson:"kind" json:"kind"`
+	Format      string              `bson:"format" json:"format"`
+	StartDate   time.Time           `bson:"startDate" json:"startDate"`
+	EndDate     time.Time           `bson:"endDate" json:"endDate"` // Exclusivo
+	Bucket      string              `bson:"bucket,omitempty" json:"bucket,omitempty"`
+	RoomType    string              `bson:"roomType,omitempty" json:"roomType,omitempty"`
+	Status      string              `bson:"status" json:"status"`
+	Rows        int                 `bson:"rows" json:"rows"`
+	Filename    string              `bson:"filename,omitempty" json:"filename,omitempty"`
+	FileURL     string              `bson:"fileUrl,omitempty" json:"fileUrl,omitempty"`
+	Error       string              `bson:"error,omitempty" json:"error,omitempty"`
+	GeneratedBy string              `bson:"generatedBy" json:"generatedBy"` // Usuario o ReportScheduler
+	GeneratedAt time.Time           `bson:"generatedAt" json:"generatedAt"`
+}
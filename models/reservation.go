@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados posibles de una reservación
+const (
+	ReservationPending   = "pending"   // Reservada por tipo de habitación, todavía sin habitación asignada
+	ReservationConfirmed = "confirmed" // Con habitación asignada; bloquea la habitación en sus fechas
+	ReservationCancelled = "cancelled" // Cancelada; ya no bloquea la habitación
+	ReservationCheckedIn = "checkedIn" // El huésped ya ocupa la habitación
+	ReservationCompleted = "completed" // El huésped ya salió de la habitación
+)
+
+// Reservation representa una reserva futura de una habitación (o de un tipo de habitación) para un cliente.
+// Las fechas son días naturales en UTC: la estancia ocupa las noches desde ArrivalDate hasta el día anterior a DepartureDate.
+type Reservation struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID        *primitive.ObjectID `bson:"roomId,omitempty" json:"roomId,omitempty"`
+	RoomNumber    string              `bson:"roomNumber,omitempty" json:"roomNumber,omitempty"`
+	RoomType      string              `bson:"roomType" json:"roomType"`
+	ClientID      primitive.ObjectID  `bson:"clientId" json:"clientId"`
+	ArrivalDate   time.Time           `bson:"arrivalDate" json:"arrivalDate"`
+	DepartureDate time.Time           `bson:"departureDate" json:"departureDate"`
+	Status        string              `bson:"status" json:"status"`
+	Notes         string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedBy     string              `bson:"createdBy" json:"createdBy"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+	CancelledAt   *time.Time          `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+}
@@ -6,6 +6,74 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Estados de una habitación
+const (
+	RoomStatusAvailable   = "available"    // Libre y lista para recibir huéspedes
+	RoomStatusOccupied    = "occupied"     // Con un ocupante registrado
+	RoomStatusDirty       = "dirty"        // Desocupada, pendiente de limpieza
+	RoomStatusCleaning    = "cleaning"     // Limpieza en curso
+	RoomStatusInspected   = "inspected"    // Limpia, pendiente de liberar tras la inspección
+	RoomStatusOutOfOrder  = "out-of-order" // Fuera de servicio
+	RoomStatusMaintenance = "maintenance"  // En mantenimiento
+)
+
+// RoomStatusTransitions define a qué estados puede pasar una habitación desde cada estado.
+// La entrada y salida de "occupied" solo ocurre con el check-in y el check-out.
+var RoomStatusTransitions = map[string][]string{
+	RoomStatusAvailable:   {RoomStatusOccupied, RoomStatusDirty, RoomStatusOutOfOrder, RoomStatusMaintenance},
+	RoomStatusOccupied:    {RoomStatusDirty},
+	RoomStatusDirty:       {RoomStatusCleaning, RoomStatusOutOfOrder, RoomStatusMaintenance},
+	RoomStatusCleaning:    {RoomStatusDirty, RoomStatusInspected, RoomStatusOutOfOrder, RoomStatusMaintenance},
+	RoomStatusInspected:   {RoomStatusAvailable, RoomStatusDirty, RoomStatusOutOfOrder, RoomStatusMaintenance},
+	RoomStatusOutOfOrder:  {RoomStatusMaintenance, RoomStatusDirty},
+	RoomStatusMaintenance: {RoomStatusOutOfOrder, RoomStatusDirty},
+}
+
+// IsValidRoomStatus indica si el estado existe en RoomStatusTransitions
+func IsValidRoomStatus(status string) bool {
+	_, ok := RoomStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionRoomStatus indica si una habitación puede pasar del estado from al estado to.
+// Las habitaciones con un estado antiguo no reconocido pueden pasar a cualquier estado válido.
+func CanTransitionRoomStatus(from, to string) bool {
+	allowed, ok := RoomStatusTransitions[from]
+	if !ok {
+		return IsValidRoomStatus(to)
+	}
+	for _, status := range allowed {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomStatusChange es un cambio de estado en la línea de tiempo de una habitación
+type RoomStatusChange struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID     primitive.ObjectID `bson:"roomId" json:"roomId"`
+	RoomNumber string             `bson:"roomNumber" json:"roomNumber"`
+	From       string             `bson:"from" json:"from"`
+	To         string             `bson:"to" json:"to"`
+	User       string             `bson:"user,omitempty" json:"user,omitempty"` // Usuario del personal que hizo el cambio
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Usos de una habitación
+const (
+	RoomTypeRental = "rental" // Renta mensual
+	RoomTypeGuest  = "guest"  // Hospedaje por noche
+)
+
+// BedConfig describe las camas de un tipo en una habitación, por ejemplo 2 "matrimonial"
+type BedConfig struct {
+	Type  string `bson:"type" json:"type"`
+	Count int    `bson:"count" json:"count"`
+}
+
 // Room representa una habitación en el sistema
 type Room struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -15,7 +83,21 @@ type Room struct {
 	Description string             `bson:"description" json:"description"`
 	Status      string             `bson:"status" json:"status"`
 
+	Category        string      `bson:"category,omitempty" json:"category,omitempty"` // Sencilla, doble, suite, etc.
+	Floor           int         `bson:"floor" json:"floor"`
+	Capacity        int         `bson:"capacity" json:"capacity"` // Número máximo de personas
+	Beds            []BedConfig `bson:"beds" json:"beds"`
+	Amenities       []string    `bson:"amenities" json:"amenities"`
+	BaseNightlyRate float64     `bson:"baseNightlyRate" json:"baseNightlyRate"`
+	BaseMonthlyRate float64     `bson:"baseMonthlyRate" json:"baseMonthlyRate"`
+	Photos          []string    `bson:"photos" json:"photos"` // URLs de las fotos subidas con el servicio de almacenamiento
+
 	OccupantID *primitive.ObjectID `bson:"occupantId,omitempty" json:"occupantId,omitempty"`
 	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
 	UpdatedAt  time.Time           `bson:"updatedAt" json:"updatedAt"`
+
+	// Las habitaciones con historial no se borran; se marcan como eliminadas y dejan de ofrecerse
+	Deleted   bool       `bson:"deleted,omitempty" json:"deleted,omitempty"`
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedBy string     `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
 }
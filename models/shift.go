@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados de un turno de caja
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// Shift es un turno de caja de un recepcionista. Los pagos en efectivo que recibe mientras está abierto se le
+// atribuyen, y al cerrarlo se compara el efectivo contado con el esperado.
+type Shift struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OpenedBy     string             `bson:"openedBy" json:"openedBy"`
+	OpeningFloat float64            `bson:"openingFloat" json:"openingFloat"` // Fondo de caja inicial
+	OpeningNotes string             `bson:"openingNotes,omitempty" json:"openingNotes,omitempty"`
+	Status       string             `bson:"status" json:"status"`
+	OpenedAt     time.Time          `bson:"openedAt" json:"openedAt"`
+
+	ClosedAt     *time.Time `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	CashReceived *float64   `bson:"cashReceived,omitempty" json:"cashReceived,omitempty"` // Pagos en efectivo vigentes; se acumula con cada pago y se recalcula al cerrar
+	ExpectedCash *float64   `bson:"expectedCash,omitempty" json:"expectedCash,omitempty"` // OpeningFloat + CashReceived
+	CountedCash  *float64   `bson:"countedCash,omitempty" json:"countedCash,omitempty"`
+	Discrepancy  *float64   `bson:"discrepancy,omitempty" json:"discrepancy,omitempty"` // CountedCash - ExpectedCash; negativo si falta dinero
+	ClosingNotes string     `bson:"closingNotes,omitempty" json:"closingNotes,omitempty"`
+}
+
+// ShiftReport es el corte de un turno: sus pagos en efectivo, anulados incluidos, y los totales. En un turno
+// abierto los totales son los de ese momento.
+type ShiftReport struct {
+	Shift        Shift     `json:"shift"`
+	CashPayments []Payment `json:"cashPayments"`
+	CashReceived float64   `json:"cashReceived"`
+	CashVoided   float64   `json:"cashVoided"`
+	ExpectedCash float64   `json:"expectedCash"`
+	Discrepancy  *float64  `json:"discrepancy,omitempty"`
+}
@@ -1,9 +1,5 @@
 package models
 
-import (
-	"github.com/dgrijalva/jwt-go"
-)
-
 type User struct {
 	Nombres        string `json:"nombres" bson:"nombres"`
 	Apellidos      string `json:"apellidos" bson:"apellidos"`
@@ -13,16 +9,45 @@ type User struct {
 	Rol            string `json:"rol" bson:"rol"` // "Administracion" o "Recepcionista"
 	CURP           string `json:"curp,omitempty" bson:"curp,omitempty"`
 	ProfilePicture string `json:"profilePicture,omitempty" bson:"profilePicture,omitempty"` // URL de la imagen de perfil
+	Disabled       bool   `json:"disabled" bson:"disabled,omitempty"`                       // Cuenta desactivada: no puede iniciar sesión
+
+	// Segundo factor TOTP; el secreto y los códigos de recuperación (hasheados) nunca se serializan a JSON
+	TOTPEnabled       bool     `json:"totpEnabled" bson:"totpEnabled"`
+	TOTPSecret        string   `json:"-" bson:"totpSecret,omitempty"`
+	TOTPPendingSecret string   `json:"-" bson:"totpPendingSecret,omitempty"` // Secreto generado pero aún no confirmado
+	TOTPLastStep      int64    `json:"-" bson:"totpLastStep,omitempty"`      // Último paso aceptado, evita reutilizar códigos
+	RecoveryCodes     []string `json:"-" bson:"recoveryCodes,omitempty"`
 }
 
-type Credentials struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+// PublicUser es la representación de un usuario que se expone en la API; nunca incluye la contraseña ni secretos
+type PublicUser struct {
+	Nombres        string `json:"nombres"`
+	Apellidos      string `json:"apellidos"`
+	Correo         string `json:"correo"`
+	Celular        string `json:"celular"`
+	Rol            string `json:"rol"`
+	CURP           string `json:"curp,omitempty"`
+	ProfilePicture string `json:"profilePicture,omitempty"`
+	TOTPEnabled    bool   `json:"totpEnabled"`
+	Disabled       bool   `json:"disabled"`
 }
 
-type Claims struct {
+// Public devuelve la representación pública del usuario
+func (u User) Public() PublicUser {
+	return PublicUser{
+		Nombres:        u.Nombres,
+		Apellidos:      u.Apellidos,
+		Correo:         u.Correo,
+		Celular:        u.Celular,
+		Rol:            u.Rol,
+		CURP:           u.CURP,
+		ProfilePicture: u.ProfilePicture,
+		TOTPEnabled:    u.TOTPEnabled,
+		Disabled:       u.Disabled,
+	}
+}
+
+type Credentials struct {
 	Username string `json:"username"`
-	Role     string `json:"rol"`
-	TokenID  string `json:"token_id"`
-	jwt.StandardClaims
+	Password string `json:"password"`
 }
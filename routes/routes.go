@@ -1,12 +1,14 @@
 package routes
 
 import (
+	"context"
+	"hotelman-backend/auth"
 	"hotelman-backend/constants"
 	"hotelman-backend/handlers"
 	"hotelman-backend/middleware"
 	"hotelman-backend/services"
-	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,19 +37,101 @@ func RegisterRoutes(router *mux.Router, client *mongo.Client, cloudinaryURL stri
 		}
 	}
 
+	// Emisor y verificador único de tokens de acceso
+	tokenManager := auth.NewTokenManager([]byte(constants.JWTSecretKey))
+
+	// Almacén de sesiones compartido por login, logout y el middleware de autenticación
+	sessionStore := services.NewSessionStore(client)
+	refreshTokenStore := services.NewRefreshTokenStore(client)
+	loginThrottle := services.NewLoginThrottle(client)
+	if err := loginThrottle.EnsureIndexes(context.Background()); err != nil {
+		panic("Failed to initialize login throttle indexes (check for duplicate keys): " + err.Error())
+	}
+
+	// Roles y permisos; se siembran los roles del sistema definidos en auth.DefaultRolePermissions
+	roleStore := services.NewRoleStore(client)
+	if err := roleStore.EnsureDefaults(context.Background()); err != nil {
+		panic("Failed to initialize roles: " + err.Error())
+	}
+
+	// Catálogo de habitaciones; el índice único impide números de habitación repetidos
+	roomStore := services.NewRoomStore(client)
+	if err := roomStore.EnsureIndexes(context.Background()); err != nil {
+		panic("Failed to initialize room indexes (check for duplicate room numbers): " + err.Error())
+	}
+
+	// Servicio de correo para notificaciones (SMTP o archivos locales según constants.MailSender)
+	mailSender, err := services.NewMailSender()
+	if err != nil {
+		panic("Failed to initialize mail sender: " + err.Error())
+	}
+
 	// Crear instancias de los nuevos handlers
 	setupAdminHandler := &handlers.SetupAdminHandler{Client: client}
+	invitationStore := services.NewInvitationStore(client)
 	signupHandler := &handlers.SignupHandler{
 		Client:                 client,
+		Invitations:            invitationStore,
 		CloudinaryService:      cloudinaryService,
 		LocalFileSystemService: localFileSystemService,
 	}
 	welcomeHandler := &handlers.WelcomeHandler{}
 	addValidCURPHandler := &handlers.AddValidCURPHandler{Client: client}
 
-	// Crear instancia de LoginHandler con jwtKey y Client
-	loginHandler := handlers.NewLoginHandler(client, []byte(constants.JWTSecretKey))
-	logoutHandler := handlers.LogoutHandler{}
+	// Crear instancia de LoginHandler con el TokenManager y Client
+	loginHandler := handlers.NewLoginHandler(client, tokenManager, sessionStore, refreshTokenStore, loginThrottle)
+	refreshTokenHandler := handlers.NewRefreshTokenHandler(tokenManager, sessionStore, refreshTokenStore)
+	logoutHandler := &handlers.LogoutHandler{Tokens: tokenManager, Sessions: sessionStore, RefreshTokens: refreshTokenStore}
+	passwordResetHandler := &handlers.PasswordResetHandler{
+		Client:        client,
+		Resets:        services.NewPasswordResetStore(client),
+		Mailer:        mailSender,
+		Sessions:      sessionStore,
+		RefreshTokens: refreshTokenStore,
+		Throttle:      loginThrottle,
+	}
+	mfaHandler := &handlers.MFAHandler{Client: client, Tokens: tokenManager, Login: loginHandler}
+	rolesHandler := &handlers.RolesHandler{Client: client, Roles: roleStore}
+	invitationsHandler := &handlers.InvitationsHandler{Invitations: invitationStore, Roles: roleStore, Mailer: mailSender}
+	loginSecurityHandler := &handlers.LoginSecurityHandler{Throttle: loginThrottle}
+	sessionsHandler := &handlers.SessionsHandler{Sessions: sessionStore, RefreshTokens: refreshTokenStore}
+
+	// Contratos de inquilinos y su calendario de cargos; las rentas vencidas se revisan en segundo plano
+	overdueService := services.NewOverdueService(client)
+	if constants.OverdueScanMinutes > 0 {
+		overdueService.Start(context.Background(), time.Duration(constants.OverdueScanMinutes)*time.Minute)
+	}
+	leasesHandler := &handlers.LeasesHandler{Leases: services.NewLeaseService(client), Overdue: overdueService}
+
+	// Planes de tarifa y cotizaciones; también fijan el precio al registrar huéspedes
+	pricingService := services.NewPricingService(client)
+	pricingHandler := &handlers.PricingHandler{Pricing: pricingService}
+
+	// Libro de pagos y cargos de clientes
+	paymentService := services.NewPaymentService(client)
+	paymentsHandler := &handlers.PaymentsHandler{Payments: paymentService}
+	// Turnos de caja; los pagos en efectivo se atribuyen al turno abierto de quien los recibe
+	shiftService := services.NewShiftService(client)
+	if err := shiftService.EnsureIndexes(context.Background()); err != nil {
+		panic("Failed to initialize shift indexes (check for users with several open shifts): " + err.Error())
+	}
+	shiftsHandler := &handlers.ShiftsHandler{Shifts: shiftService}
+	documentsHandler := &handlers.DocumentsHandler{
+		Documents:              services.NewDocumentService(client, paymentService),
+		GoogleDriveService:     googleDriveService,
+		LocalFileSystemService: localFileSystemService,
+	}
+
+	// Facturación CFDI; el PAC se elige con constants.PACProvider
+	pacClient, err := services.NewPACClient(constants.PACProvider)
+	if err != nil {
+		panic("Failed to initialize PAC client: " + err.Error())
+	}
+	invoicesHandler := &handlers.InvoicesHandler{
+		Invoices:               services.NewInvoiceService(client, pacClient),
+		GoogleDriveService:     googleDriveService,
+		LocalFileSystemService: localFileSystemService,
+	}
 
 	// Crear Instancia Cliente:
 	clientsHandler := &handlers.GetClientsHandler{Client: client}
@@ -56,16 +140,49 @@ func RegisterRoutes(router *mux.Router, client *mongo.Client, cloudinaryURL stri
 		CloudinaryService:      cloudinaryService,
 		GoogleDriveService:     googleDriveService,
 		LocalFileSystemService: localFileSystemService,
+		Pricing:                pricingService,
+		Payments:               paymentService,
 	}
 	// Instancia de GetAllUsersHandler
 	allUsersHandler := handlers.NewGetAllUsersHandler(client)
-	userDataHandler := handlers.NewUserHandler(client, []byte(constants.JWTSecretKey))
+	userDataHandler := handlers.NewUserHandler(client)
+	usersHandler := &handlers.UsersHandler{Client: client, Roles: roleStore, Sessions: sessionStore, RefreshTokens: refreshTokenStore}
 
 	// Instancia de Room Handler
-	roomHandler := &handlers.RoomHandler{Client: client}
+	roomHandler := &handlers.RoomHandler{
+		Client:                 client,
+		Rooms:                  roomStore,
+		Statuses:               services.NewRoomStatusService(client),
+		CloudinaryService:      cloudinaryService,
+		LocalFileSystemService: localFileSystemService,
+	}
+
+	// Instancia de check-in y check-out
+	staysHandler := &handlers.StaysHandler{Stays: services.NewStayService(client)}
+
+	// Instancia del tablero de housekeeping
+	housekeepingHandler := &handlers.HousekeepingHandler{Housekeeping: services.NewHousekeepingService(client)}
+
+	// Instancia de tickets de mantenimiento
+	maintenanceHandler := &handlers.MaintenanceHandler{
+		Maintenance:            services.NewMaintenanceService(client),
+		CloudinaryService:      cloudinaryService,
+		LocalFileSystemService: localFileSystemService,
+	}
+
+	// Instancia de Reservations handler
+	reservationsHandler := &handlers.ReservationsHandler{Reservations: services.NewReservationStore(client)}
 
 	// Instancia de Analytics handler
-	analyticsHandler := &handlers.AnalyticsHandler{Client: client}
+	analyticsService := services.NewAnalyticsService(client)
+	analyticsHandler := &handlers.AnalyticsHandler{Analytics: analyticsService}
+
+	// Reportes exportables; las programaciones pendientes se generan en segundo plano
+	reportService := services.NewReportService(client, analyticsService, overdueService, googleDriveService, localFileSystemService)
+	if constants.ReportScanMinutes > 0 {
+		reportService.Start(context.Background(), time.Duration(constants.ReportScanMinutes)*time.Minute)
+	}
+	reportsHandler := &handlers.ReportsHandler{Reports: reportService}
 
 	// Obtener la ruta raíz del proyecto
 	rootPath, err := os.Getwd()
@@ -75,38 +192,150 @@ func RegisterRoutes(router *mux.Router, client *mongo.Client, cloudinaryURL stri
 
 	serveHandler := &handlers.ServeFileHandler{UploadsDir: rootPath + constants.LocalFileSystemFolder}
 
-	// Crear instancia del middleware RequireAuth para roles específicos
-	requireAuthAdmin := middleware.NewRequireAuth([]byte(constants.JWTSecretKey), []string{"Administracion"})
-	requireAuthReceptionist := middleware.NewRequireAuth([]byte(constants.JWTSecretKey), []string{"Recepcionista", "Administracion"})
+	// Middleware RequireAuth; cada ruta protegida declara el permiso que exige y se resuelve contra el rol del usuario
+	requireAuth := middleware.NewRequireAuth(tokenManager, sessionStore, roleStore)
 
-	// Endpoints utilizando los nuevos handlers
+	// Endpoints públicos de autenticación
 	router.HandleFunc("/setup", setupAdminHandler.Handle).Methods("POST")
 	router.HandleFunc("/signup", signupHandler.Handle).Methods("POST")
 	router.HandleFunc("/login", loginHandler.Handle).Methods("POST")
+	router.HandleFunc("/login/mfa", mfaHandler.VerifyLoginHandler).Methods("POST")
+	router.HandleFunc("/login/mfa/enroll", mfaHandler.EnrollLoginHandler).Methods("POST")
+	router.HandleFunc("/login/mfa/activate", mfaHandler.ActivateLoginHandler).Methods("POST")
+	router.HandleFunc("/token/refresh", refreshTokenHandler.Handle).Methods("POST")
 	router.HandleFunc("/logout", logoutHandler.Handle).Methods("POST")
-	router.HandleFunc("/add-valid-curp", addValidCURPHandler.Handle).Methods("POST")
+	router.HandleFunc("/password/forgot", passwordResetHandler.ForgotPasswordHandler).Methods("POST")
+	router.HandleFunc("/password/reset", passwordResetHandler.ResetPasswordHandler).Methods("POST")
+
+	// Endpoints de administración
+	router.Handle("/add-valid-curp", requireAuth.Require(auth.PermCURPsWrite, addValidCURPHandler.Handle)).Methods("POST")
+	router.Handle("/invitations", requireAuth.Require(auth.PermInvitesManage, invitationsHandler.GetInvitationsHandler)).Methods("GET")
+	router.Handle("/invitations", requireAuth.Require(auth.PermInvitesManage, invitationsHandler.CreateInvitationHandler)).Methods("POST")
+	router.Handle("/invitations", requireAuth.Require(auth.PermInvitesManage, invitationsHandler.RevokeInvitationHandler)).Methods("DELETE")
+	router.Handle("/welcome", requireAuth.Require(auth.PermUsersManage, welcomeHandler.Handle)).Methods("GET")
+	router.Handle("/sessions", requireAuth.Require(auth.PermSessionsManage, sessionsHandler.ListSessionsHandler)).Methods("GET")
+	router.Handle("/sessions", requireAuth.Require(auth.PermSessionsManage, sessionsHandler.RevokeSessionsHandler)).Methods("DELETE")
 
-	// Endpoint protegido utilizando el middleware RequireAuth para administradores
-	router.Handle("/welcome", requireAuthAdmin.Middleware(http.HandlerFunc(welcomeHandler.Handle))).Methods("GET")
+	// Endpoints de auditoría de login y bloqueos
+	router.Handle("/login/attempts", requireAuth.Require(auth.PermSecurityManage, loginSecurityHandler.GetLoginAttemptsHandler)).Methods("GET")
+	router.Handle("/login/lockouts", requireAuth.Require(auth.PermSecurityManage, loginSecurityHandler.GetLockoutsHandler)).Methods("GET")
+	router.Handle("/login/lockouts", requireAuth.Require(auth.PermSecurityManage, loginSecurityHandler.UnlockHandler)).Methods("DELETE")
 
-	// Endpoint protegido utilizando el middleware RequireAuth para recepcionistas y administradores
-	router.Handle("/all-users", requireAuthReceptionist.Middleware(http.HandlerFunc(allUsersHandler.Handle))).Methods("GET")
-	router.Handle("/user", requireAuthReceptionist.Middleware(http.HandlerFunc(userDataHandler.Handle))).Methods("GET")
-	router.HandleFunc("/create-client", createHandler.Handle).Methods("POST")
-	router.HandleFunc("/clients", clientsHandler.Handle).Methods("GET")
-	router.HandleFunc("/clients", clientsHandler.Update).Methods("PUT")
-	router.HandleFunc("/clients/search", clientsHandler.Search).Methods("GET")
+	// Endpoints de roles y permisos
+	router.Handle("/permissions", requireAuth.Require(auth.PermRolesManage, rolesHandler.GetPermissionsHandler)).Methods("GET")
+	router.Handle("/roles", requireAuth.Require(auth.PermRolesManage, rolesHandler.GetRolesHandler)).Methods("GET")
+	router.Handle("/roles", requireAuth.Require(auth.PermRolesManage, rolesHandler.CreateRoleHandler)).Methods("POST")
+	router.Handle("/roles", requireAuth.Require(auth.PermRolesManage, rolesHandler.UpdateRoleHandler)).Methods("PUT")
+	router.Handle("/roles", requireAuth.Require(auth.PermRolesManage, rolesHandler.DeleteRoleHandler)).Methods("DELETE")
+
+	// Endpoints de usuarios
+	router.Handle("/all-users", requireAuth.Require(auth.PermUsersRead, allUsersHandler.Handle)).Methods("GET")
+	router.Handle("/user", requireAuth.Require(auth.PermProfileRead, userDataHandler.Handle)).Methods("GET")
+	router.Handle("/user/password", requireAuth.Require(auth.PermProfileWrite, usersHandler.ChangePasswordHandler)).Methods("PUT")
+	router.Handle("/users", requireAuth.Require(auth.PermUsersManage, usersHandler.UpdateUserHandler)).Methods("PUT")
+	router.Handle("/users/role", requireAuth.Require(auth.PermUsersManage, usersHandler.ChangeRoleHandler)).Methods("PUT")
+	router.Handle("/users/status", requireAuth.Require(auth.PermUsersManage, usersHandler.SetUserStatusHandler)).Methods("PUT")
+
+	// Endpoints de segundo factor del usuario autenticado
+	router.Handle("/mfa/totp/enroll", requireAuth.Require(auth.PermProfileWrite, mfaHandler.EnrollHandler)).Methods("POST")
+	router.Handle("/mfa/totp/activate", requireAuth.Require(auth.PermProfileWrite, mfaHandler.ActivateHandler)).Methods("POST")
+	router.Handle("/mfa/totp/disable", requireAuth.Require(auth.PermProfileWrite, mfaHandler.DisableHandler)).Methods("POST")
+	router.Handle("/mfa/recovery-codes", requireAuth.Require(auth.PermProfileWrite, mfaHandler.RegenerateRecoveryCodesHandler)).Methods("POST")
+
+	// Endpoints clients
+	router.Handle("/create-client", requireAuth.Require(auth.PermClientsWrite, createHandler.Handle)).Methods("POST")
+	router.Handle("/clients", requireAuth.Require(auth.PermClientsRead, clientsHandler.Handle)).Methods("GET")
+	router.Handle("/clients", requireAuth.Require(auth.PermClientsWrite, clientsHandler.Update)).Methods("PUT")
+	router.Handle("/clients/search", requireAuth.Require(auth.PermClientsRead, clientsHandler.Search)).Methods("GET")
 
 	// Endpoint rooms
-	router.HandleFunc("/rooms", roomHandler.CreateRoomHandler).Methods("POST")
-	router.HandleFunc("/rooms", roomHandler.GetAllRoomsHandler).Methods("GET")
-	router.HandleFunc("/rooms/status", roomHandler.UpdateRoomStatusHandler).Methods("PUT")
-	router.HandleFunc("/rooms/occupant", roomHandler.GetRoomOccupantHandler).Methods("GET")
-	router.HandleFunc("/rooms/assign", roomHandler.AssignOccupantHandler).Methods("PUT")
+	router.Handle("/rooms", requireAuth.Require(auth.PermRoomsWrite, roomHandler.CreateRoomHandler)).Methods("POST")
+	router.Handle("/rooms", requireAuth.Require(auth.PermRoomsRead, roomHandler.GetAllRoomsHandler)).Methods("GET")
+	router.Handle("/rooms", requireAuth.Require(auth.PermRoomsWrite, roomHandler.UpdateRoomHandler)).Methods("PUT")
+	router.Handle("/rooms", requireAuth.Require(auth.PermRoomsWrite, roomHandler.DeleteRoomHandler)).Methods("DELETE")
+	router.Handle("/room", requireAuth.Require(auth.PermRoomsRead, roomHandler.GetRoomHandler)).Methods("GET")
+	router.Handle("/rooms/photos", requireAuth.Require(auth.PermRoomsWrite, roomHandler.AddRoomPhotosHandler)).Methods("POST")
+	router.Handle("/rooms/status", requireAuth.Require(auth.PermRoomsStatus, roomHandler.UpdateRoomStatusHandler)).Methods("PUT")
+	router.Handle("/rooms/status/history", requireAuth.Require(auth.PermRoomsRead, roomHandler.GetRoomStatusHistoryHandler)).Methods("GET")
+	router.Handle("/rooms/occupant", requireAuth.Require(auth.PermRoomsRead, roomHandler.GetRoomOccupantHandler)).Methods("GET")
+	router.Handle("/rooms/assign", requireAuth.Require(auth.PermRoomsAssign, staysHandler.AssignOccupantHandler)).Methods("PUT")
+	router.Handle("/rooms/check-in", requireAuth.Require(auth.PermRoomsAssign, staysHandler.CheckInHandler)).Methods("POST")
+	router.Handle("/rooms/check-out", requireAuth.Require(auth.PermRoomsAssign, staysHandler.CheckOutHandler)).Methods("POST")
+
+	// Endpoints housekeeping
+	router.Handle("/housekeeping/tasks", requireAuth.Require(auth.PermHousekeepingWork, housekeepingHandler.GetTasksHandler)).Methods("GET")
+	router.Handle("/housekeeping/tasks", requireAuth.Require(auth.PermHousekeepingManage, housekeepingHandler.CreateTaskHandler)).Methods("POST")
+	router.Handle("/housekeeping/tasks/assign", requireAuth.Require(auth.PermHousekeepingManage, housekeepingHandler.AssignTaskHandler)).Methods("PUT")
+	router.Handle("/housekeeping/tasks/start", requireAuth.Require(auth.PermHousekeepingWork, housekeepingHandler.StartTaskHandler)).Methods("POST")
+	router.Handle("/housekeeping/tasks/finish", requireAuth.Require(auth.PermHousekeepingWork, housekeepingHandler.FinishTaskHandler)).Methods("POST")
+	router.Handle("/housekeeping/tasks/inspect", requireAuth.Require(auth.PermHousekeepingManage, housekeepingHandler.InspectTaskHandler)).Methods("POST")
+
+	// Endpoints maintenance
+	router.Handle("/maintenance/tickets", requireAuth.Require(auth.PermMaintenanceReport, maintenanceHandler.GetTicketsHandler)).Methods("GET")
+	router.Handle("/maintenance/tickets", requireAuth.Require(auth.PermMaintenanceReport, maintenanceHandler.CreateTicketHandler)).Methods("POST")
+	router.Handle("/maintenance/tickets", requireAuth.Require(auth.PermMaintenanceManage, maintenanceHandler.UpdateTicketHandler)).Methods("PUT")
+	router.Handle("/maintenance/tickets/photos", requireAuth.Require(auth.PermMaintenanceReport, maintenanceHandler.AddPhotosHandler)).Methods("POST")
+	router.Handle("/maintenance/tickets/close", requireAuth.Require(auth.PermMaintenanceManage, maintenanceHandler.CloseTicketHandler)).Methods("POST")
+
+	// Endpoints payments
+	router.Handle("/payments", requireAuth.Require(auth.PermPaymentsRead, paymentsHandler.GetPaymentsHandler)).Methods("GET")
+	router.Handle("/payments", requireAuth.Require(auth.PermPaymentsWrite, paymentsHandler.RegisterPaymentHandler)).Methods("POST")
+	router.Handle("/payments/void", requireAuth.Require(auth.PermPaymentsVoid, paymentsHandler.VoidPaymentHandler)).Methods("POST")
+	router.Handle("/clients/balance", requireAuth.Require(auth.PermPaymentsRead, paymentsHandler.GetBalanceHandler)).Methods("GET")
+	router.Handle("/payments/receipt", requireAuth.Require(auth.PermPaymentsRead, documentsHandler.GetReceiptHandler)).Methods("GET")
+	router.Handle("/payments/receipt", requireAuth.Require(auth.PermPaymentsWrite, documentsHandler.StoreReceiptHandler)).Methods("POST")
+	router.Handle("/clients/statement", requireAuth.Require(auth.PermPaymentsRead, documentsHandler.GetStatementHandler)).Methods("GET")
+	router.Handle("/clients/statement", requireAuth.Require(auth.PermPaymentsWrite, documentsHandler.StoreStatementHandler)).Methods("POST")
+
+	// Endpoints shifts
+	router.Handle("/shifts", requireAuth.Require(auth.PermShiftsRead, shiftsHandler.GetShiftsHandler)).Methods("GET")
+	router.Handle("/shifts/open", requireAuth.Require(auth.PermShiftsOperate, shiftsHandler.OpenShiftHandler)).Methods("POST")
+	router.Handle("/shifts/current", requireAuth.Require(auth.PermShiftsOperate, shiftsHandler.GetCurrentShiftHandler)).Methods("GET")
+	router.Handle("/shifts/close", requireAuth.Require(auth.PermShiftsOperate, shiftsHandler.CloseShiftHandler)).Methods("POST")
+	router.Handle("/shifts/report", requireAuth.Require(auth.PermShiftsRead, shiftsHandler.GetShiftReportHandler)).Methods("GET")
+
+	// Endpoints invoices
+	router.Handle("/clients/fiscal", requireAuth.Require(auth.PermInvoicesIssue, invoicesHandler.UpdateFiscalDataHandler)).Methods("PUT")
+	router.Handle("/invoices", requireAuth.Require(auth.PermInvoicesRead, invoicesHandler.GetInvoicesHandler)).Methods("GET")
+	router.Handle("/invoices", requireAuth.Require(auth.PermInvoicesIssue, invoicesHandler.CreateInvoiceHandler)).Methods("POST")
+	router.Handle("/invoices/xml", requireAuth.Require(auth.PermInvoicesRead, invoicesHandler.GetInvoiceXMLHandler)).Methods("GET")
+
+	// Endpoints leases
+	router.Handle("/leases", requireAuth.Require(auth.PermLeasesRead, leasesHandler.GetLeasesHandler)).Methods("GET")
+	router.Handle("/leases", requireAuth.Require(auth.PermLeasesManage, leasesHandler.CreateLeaseHandler)).Methods("POST")
+	router.Handle("/lease", requireAuth.Require(auth.PermLeasesRead, leasesHandler.GetLeaseHandler)).Methods("GET")
+	router.Handle("/leases/renew", requireAuth.Require(auth.PermLeasesManage, leasesHandler.RenewLeaseHandler)).Methods("POST")
+	router.Handle("/leases/terminate", requireAuth.Require(auth.PermLeasesManage, leasesHandler.TerminateLeaseHandler)).Methods("POST")
+	router.Handle("/leases/overdue", requireAuth.Require(auth.PermLeasesRead, leasesHandler.GetOverdueTenantsHandler)).Methods("GET")
+	router.Handle("/leases/overdue/scan", requireAuth.Require(auth.PermLeasesManage, leasesHandler.ScanOverdueHandler)).Methods("POST")
+
+	// Endpoints rates
+	router.Handle("/rates/plans", requireAuth.Require(auth.PermRatesRead, pricingHandler.GetRatePlansHandler)).Methods("GET")
+	router.Handle("/rates/plans", requireAuth.Require(auth.PermRatesManage, pricingHandler.CreateRatePlanHandler)).Methods("POST")
+	router.Handle("/rates/plans", requireAuth.Require(auth.PermRatesManage, pricingHandler.UpdateRatePlanHandler)).Methods("PUT")
+	router.Handle("/rates/plans", requireAuth.Require(auth.PermRatesManage, pricingHandler.DeleteRatePlanHandler)).Methods("DELETE")
+	router.Handle("/rates/quote", requireAuth.Require(auth.PermRatesRead, pricingHandler.QuoteHandler)).Methods("GET")
+
+	// Endpoints reservations
+	router.Handle("/reservations", requireAuth.Require(auth.PermReservationsRead, reservationsHandler.GetReservationsHandler)).Methods("GET")
+	router.Handle("/reservations", requireAuth.Require(auth.PermReservationsWrite, reservationsHandler.CreateReservationHandler)).Methods("POST")
+	router.Handle("/reservations", requireAuth.Require(auth.PermReservationsWrite, reservationsHandler.UpdateReservationHandler)).Methods("PUT")
+	router.Handle("/reservations", requireAuth.Require(auth.PermReservationsWrite, reservationsHandler.CancelReservationHandler)).Methods("DELETE")
+	router.Handle("/reservations/availability", requireAuth.Require(auth.PermReservationsRead, reservationsHandler.GetAvailabilityHandler)).Methods("GET")
 
 	// Endpoint analytics
-	router.HandleFunc("/analytics", analyticsHandler.GetAnalyticsHandler).Methods("GET")
+	router.Handle("/analytics", requireAuth.Require(auth.PermAnalyticsRead, analyticsHandler.GetAnalyticsHandler)).Methods("GET")
+
+	// Endpoints reports
+	router.Handle("/reports/export", requireAuth.Require(auth.PermReportsRead, reportsHandler.ExportReportHandler)).Methods("GET")
+	router.Handle("/reports/runs", requireAuth.Require(auth.PermReportsRead, reportsHandler.GetReportRunsHandler)).Methods("GET")
+	router.Handle("/reports/runs/download", requireAuth.Require(auth.PermReportsRead, reportsHandler.DownloadReportRunHandler)).Methods("GET")
+	router.Handle("/reports/schedules", requireAuth.Require(auth.PermReportsRead, reportsHandler.GetReportSchedulesHandler)).Methods("GET")
+	router.Handle("/reports/schedules", requireAuth.Require(auth.PermReportsManage, reportsHandler.CreateReportScheduleHandler)).Methods("POST")
+	router.Handle("/reports/schedules", requireAuth.Require(auth.PermReportsManage, reportsHandler.UpdateReportScheduleHandler)).Methods("PUT")
+	router.Handle("/reports/schedules", requireAuth.Require(auth.PermReportsManage, reportsHandler.DeleteReportScheduleHandler)).Methods("DELETE")
+	router.Handle("/reports/schedules/run", requireAuth.Require(auth.PermReportsManage, reportsHandler.RunReportScheduleHandler)).Methods("POST")
 
 	// Content Serve
-	router.HandleFunc("/serve", serveHandler.Handle).Methods("GET")
+	router.Handle("/serve", requireAuth.Require(auth.PermDocumentsRead, serveHandler.Handle)).Methods("GET")
 }
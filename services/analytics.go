@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AnalyticsRoomTypeAll incluye las habitaciones de hospedaje y de renta en la ocupación
+const AnalyticsRoomTypeAll = "all"
+
+// AnalyticsService calcula la ocupación y los ingresos a partir de los check-in/out y de los pagos
+type AnalyticsService struct {
+	Client *mongo.Client
+}
+
+// NewAnalyticsService crea una nueva instancia de AnalyticsService
+func NewAnalyticsService(client *mongo.Client) *AnalyticsService {
+	return &AnalyticsService{Client: client}
+}
+
+func (s *AnalyticsService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+// analyticsRoom es la parte de una habitación que se necesita para saber si estaba disponible un día
+type analyticsRoom struct {
+	RoomNumber string     `bson:"roomNumber"`
+	RoomType   string     `bson:"roomType"`
+	CreatedAt  time.Time  `bson:"createdAt"`
+	Deleted    bool       `bson:"deleted"`
+	DeletedAt  *time.Time `bson:"deletedAt"`
+}
+
+// availableOn indica si la habitación existía y no estaba eliminada la noche del día indicado
+func (r analyticsRoom) availableOn(day time.Time) bool {
+	if !r.CreatedAt.IsZero() && !r.CreatedAt.Before(day.AddDate(0, 0, 1)) {
+		return false
+	}
+	if r.Deleted && (r.DeletedAt == nil || !r.DeletedAt.After(day)) {
+		return false
+	}
+	return true
+}
+
+// analyticsStay es una estancia armada con un check-in y su check-out; en las abiertas CheckOut es cero
+type analyticsStay struct {
+	RoomNumber string
+	CheckIn    time.Time
+	CheckOut   time.Time
+}
+
+// analyticsPayment es un pago vigente con el customID de su cliente, que solo tienen los huéspedes
+type analyticsPayment struct {
+	Amount     float64   `bson:"amount"`
+	Method     string    `bson:"method"`
+	ReceivedAt time.Time `bson:"receivedAt"`
+	CustomID   string    `bson:"customID"`
+}
+
+// Report calcula las métricas entre start y end (exclusivo), agrupadas por bucket. roomType es guest, rental o
+// all y decide qué habitaciones cuentan en la ocupación y qué ingresos en el ADR y el RevPAR.
+func (s *AnalyticsService) Report(ctx context.Context, start, end time.Time, bucket, roomType string) (*models.AnalyticsReport, error) {
+	rooms, err := s.rooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stays, err := s.stays(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.payments(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AnalyticsReport{
+		StartDate: start,
+		EndDate:   end,
+		Bucket:    bucket,
+		RoomType:  roomType,
+		Summary:   newAnalyticsPeriod(start, end),
+		Series:    []models.AnalyticsPeriod{},
+	}
+	for from := start; from.Before(end); {
+		to := nextBucket(from, bucket)
+		if to.After(end) {
+			to = end
+		}
+		report.Series = append(report.Series, newAnalyticsPeriod(from, to))
+		from = to
+	}
+	if len(report.Series) == 0 {
+		return report, nil
+	}
+
+	// Noches vendidas por día de las habitaciones del tipo pedido
+	roomTypes := map[string]string{}
+	for _, room := range rooms {
+		roomTypes[room.RoomNumber] = room.RoomType
+	}
+	sold := map[time.Time]map[string]bool{}
+	for _, stay := range stays {
+		if !matchesRoomType(roomTypes[stay.RoomNumber], roomType) {
+			continue
+		}
+		first := truncateDay(stay.CheckIn)
+		var last time.Time
+		if !stay.CheckOut.IsZero() {
+			last = truncateDay(stay.CheckOut).AddDate(0, 0, -1)
+		} else {
+			last = truncateDay(minTime(time.Now(), end))
+		}
+		if last.Before(first) {
+			last = first
+		}
+		for day := maxTime(first, start); !day.After(last) && day.Before(end); day = day.AddDate(0, 0, 1) {
+			if sold[day] == nil {
+				sold[day] = map[string]bool{}
+			}
+			sold[day][stay.RoomNumber] = true
+		}
+	}
+
+	period := 0
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		for !day.Before(report.Series[period].End) {
+			period++
+		}
+		for _, room := range rooms {
+			if !matchesRoomType(room.RoomType, roomType) || !room.availableOn(day) {
+				continue
+			}
+			report.Series[period].RoomsAvailable++
+			if sold[day][room.RoomNumber] {
+				report.Series[period].RoomsSold++
+			}
+		}
+	}
+
+	period = 0
+	for _, payment := range payments {
+		for !payment.ReceivedAt.Before(report.Series[period].End) {
+			period++
+		}
+		revenue := &report.Series[period].Revenue
+		revenue.Total += payment.Amount
+		if payment.CustomID != "" {
+			revenue.Guest += payment.Amount
+		} else {
+			revenue.Rental += payment.Amount
+		}
+		revenue.ByMethod[payment.Method] += payment.Amount
+	}
+
+	for i := range report.Series {
+		current := &report.Series[i]
+		report.Summary.RoomsAvailable += current.RoomsAvailable
+		report.Summary.RoomsSold += current.RoomsSold
+		report.Summary.Revenue.Total += current.Revenue.Total
+		report.Summary.Revenue.Guest += current.Revenue.Guest
+		report.Summary.Revenue.Rental += current.Revenue.Rental
+		for method, amount := range current.Revenue.ByMethod {
+			report.Summary.Revenue.ByMethod[method] += amount
+		}
+		computeRates(current, roomType)
+	}
+	computeRates(&report.Summary, roomType)
+	return report, nil
+}
+
+// rooms devuelve todas las habitaciones, incluidas las eliminadas, que pudieron estar disponibles en el rango
+func (s *AnalyticsService) rooms(ctx context.Context) ([]analyticsRoom, error) {
+	cursor, err := s.db().Collection(constants.CollectionRooms).Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"roomNumber": 1, "roomType": 1, "createdAt": 1, "deleted": 1, "deletedAt": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rooms: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	rooms := []analyticsRoom{}
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, fmt.Errorf("unable to decode rooms: %v", err)
+	}
+	return rooms, nil
+}
+
+// stays arma las estancias que se cruzan con el rango emparejando cada check-in del historial de un cliente
+// con el siguiente check-out de la misma habitación
+func (s *AnalyticsService) stays(ctx context.Context, start, end time.Time) ([]analyticsStay, error) {
+	cursor, err := s.db().Collection(constants.CollectionClients).Find(ctx,
+		bson.M{"history": bson.M{"$elemMatch": bson.M{"action": models.HistoryCheckIn, "dateTime": bson.M{"$lt": end}}}},
+		options.Find().SetProjection(bson.M{"history": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list client history: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	stays := []analyticsStay{}
+	for cursor.Next(ctx) {
+		var client struct {
+			History []models.HistoryRecord `bson:"history"`
+		}
+		if err := cursor.Decode(&client); err != nil {
+			return nil, fmt.Errorf("unable to decode client history: %v", err)
+		}
+		sort.SliceStable(client.History, func(i, j int) bool {
+			return client.History[i].DateTime.Before(client.History[j].DateTime)
+		})
+
+		open := map[string]time.Time{}
+		for _, record := range client.History {
+			switch record.Action {
+			case models.HistoryCheckIn:
+				open[record.RoomNumber] = record.DateTime
+			case models.HistoryCheckOut:
+				checkIn, ok := open[record.RoomNumber]
+				if !ok {
+					continue
+				}
+				delete(open, record.RoomNumber)
+				if checkIn.Before(end) && !record.DateTime.Before(start) {
+					stays = append(stays, analyticsStay{RoomNumber: record.RoomNumber, CheckIn: checkIn, CheckOut: record.DateTime})
+				}
+			}
+		}
+		for roomNumber, checkIn := range open {
+			if checkIn.Before(end) {
+				stays = append(stays, analyticsStay{RoomNumber: roomNumber, CheckIn: checkIn})
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read client history: %v", err)
+	}
+	return stays, nil
+}
+
+// payments devuelve los pagos vigentes recibidos en el rango, ordenados por fecha
+func (s *AnalyticsService) payments(ctx context.Context, start, end time.Time) ([]analyticsPayment, error) {
+	cursor, err := s.db().Collection(constants.CollectionPayments).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":     models.PaymentValid,
+			"receivedAt": bson.M{"$gte": start, "$lt": end},
+		}}},
+		{{Key: "$sort", Value: bson.M{"receivedAt": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         constants.CollectionClients,
+			"localField":   "clientId",
+			"foreignField": "_id",
+			"as":           "client",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"amount":     1,
+			"method":     1,
+			"receivedAt": 1,
+			"customID":   bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$client.customID", 0}}, ""}},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to aggregate payments: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	payments := []analyticsPayment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, fmt.Errorf("unable to decode payments: %v", err)
+	}
+	return payments, nil
+}
+
+func newAnalyticsPeriod(start, end time.Time) models.AnalyticsPeriod {
+	return models.AnalyticsPeriod{Start: start, End: end, Revenue: models.Revenue{ByMethod: map[string]float64{}}}
+}
+
+// computeRates redondea los ingresos del periodo y calcula la ocupación, el ADR y el RevPAR
+func computeRates(period *models.AnalyticsPeriod, roomType string) {
+	period.Revenue.Total = roundMoney(period.Revenue.Total)
+	period.Revenue.Guest = roundMoney(period.Revenue.Guest)
+	period.Revenue.Rental = roundMoney(period.Revenue.Rental)
+	for method, amount := range period.Revenue.ByMethod {
+		period.Revenue.ByMethod[method] = roundMoney(amount)
+	}
+
+	roomRevenue := period.Revenue.Total
+	switch roomType {
+	case models.RoomTypeGuest:
+		roomRevenue = period.Revenue.Guest
+	case models.RoomTypeRental:
+		roomRevenue = period.Revenue.Rental
+	}
+	if period.RoomsAvailable > 0 {
+		period.OccupancyRate = float64(period.RoomsSold) / float64(period.RoomsAvailable)
+		period.RevPAR = roundMoney(roomRevenue / float64(period.RoomsAvailable))
+	}
+	if period.RoomsSold > 0 {
+		period.ADR = roundMoney(roomRevenue / float64(period.RoomsSold))
+	}
+}
+
+// nextBucket devuelve el inicio del periodo siguiente al que contiene from; las semanas empiezan en lunes
+func nextBucket(from time.Time, bucket string) time.Time {
+	day := truncateDay(from)
+	switch bucket {
+	case models.BucketWeek:
+		return day.AddDate(0, 0, 7-(int(day.Weekday())+6)%7)
+	case models.BucketMonth:
+		return time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+func matchesRoomType(value, roomType string) bool {
+	return roomType == AnalyticsRoomTypeAll || value == roomType
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"hotelman-backend/models"
+)
+
+func TestNextBucket(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		bucket string
+		want   time.Time
+	}{
+		{"día a media mañana", at(10, 15, 9), models.BucketDay, at(10, 16, 0)},
+		{"día al cierre de mes", at(10, 31, 0), models.BucketDay, at(11, 1, 0)},
+		{"semana desde jueves", at(10, 15, 9), models.BucketWeek, at(10, 19, 0)},
+		{"semana desde lunes", at(10, 19, 0), models.BucketWeek, at(10, 26, 0)},
+		{"semana desde domingo", at(10, 25, 23), models.BucketWeek, at(10, 26, 0)},
+		{"mes a mitad", at(10, 15, 9), models.BucketMonth, at(11, 1, 0)},
+		{"mes de diciembre", at(12, 1, 0), models.BucketMonth, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"bucket desconocido como día", at(10, 15, 9), "", at(10, 16, 0)},
+		{"zona horaria se normaliza a UTC", time.Date(2026, 10, 15, 20, 0, 0, 0, time.FixedZone("CST", -6*3600)), models.BucketDay, at(10, 17, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBucket(tt.from, tt.bucket); !got.Equal(tt.want) {
+				t.Errorf("nextBucket(%s, %q) = %s, se esperaba %s", tt.from, tt.bucket, got, tt.want)
+			}
+		})
+	}
+}
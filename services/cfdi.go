@@ -0,0 +1,191 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+	_ "time/tzdata" // La zona del lugar de expedición debe resolverse aunque el servidor no tenga zoneinfo
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+)
+
+// cfdiDateLayout es el formato de fecha de los atributos Fecha y FechaTimbrado, en la hora local del lugar de expedición
+const cfdiDateLayout = "2006-01-02T15:04:05"
+
+// cfdiDate formatea la fecha en la zona horaria del lugar de expedición; el PAC rechaza un CFDI cuya Fecha
+// quede en el futuro para esa zona, como pasaría con la hora UTC del servidor
+func cfdiDate(t time.Time) string {
+	location, err := time.LoadLocation(constants.CFDITimeZone)
+	if err != nil || constants.CFDITimeZone == "" {
+		location = time.FixedZone("CST", -6*60*60) // Hora del centro de México, sin horario de verano
+	}
+	return t.In(location).Format(cfdiDateLayout)
+}
+
+// emptyComplemento es el nodo que el PAC reemplaza por el TimbreFiscalDigital
+const emptyComplemento = "<cfdi:Complemento></cfdi:Complemento>"
+
+// Claves de los catálogos del SAT que usa el hotel
+const (
+	claveProdServHospedaje     = "90111800" // Servicios de alojamiento en hoteles
+	claveProdServArrendamiento = "80131502" // Arrendamiento de propiedades residenciales
+	claveUnidadServicio        = "E48"      // Unidad de servicio
+	ivaRate                    = 0.16
+)
+
+// formasPago relaciona el método de un pago con la clave del catálogo c_FormaPago
+var formasPago = map[string]string{
+	models.PaymentCash:     "01",
+	models.PaymentTransfer: "03",
+	models.PaymentCard:     "04",
+}
+
+type cfdiComprobante struct {
+	XMLName           xml.Name       `xml:"cfdi:Comprobante"`
+	XmlnsCfdi         string         `xml:"xmlns:cfdi,attr"`
+	XmlnsXsi          string         `xml:"xmlns:xsi,attr"`
+	SchemaLocation    string         `xml:"xsi:schemaLocation,attr"`
+	Version           string         `xml:"Version,attr"`
+	Serie             string         `xml:"Serie,attr"`
+	Folio             string         `xml:"Folio,attr"`
+	Fecha             string         `xml:"Fecha,attr"`
+	FormaPago         string         `xml:"FormaPago,attr"`
+	SubTotal          string         `xml:"SubTotal,attr"`
+	Moneda            string         `xml:"Moneda,attr"`
+	Total             string         `xml:"Total,attr"`
+	TipoDeComprobante string         `xml:"TipoDeComprobante,attr"`
+	Exportacion       string         `xml:"Exportacion,attr"`
+	MetodoPago        string         `xml:"MetodoPago,attr"`
+	LugarExpedicion   string         `xml:"LugarExpedicion,attr"`
+	Emisor            cfdiEmisor     `xml:"cfdi:Emisor"`
+	Receptor          cfdiReceptor   `xml:"cfdi:Receptor"`
+	Conceptos         []cfdiConcepto `xml:"cfdi:Conceptos>cfdi:Concepto"`
+	Impuestos         cfdiImpuestos  `xml:"cfdi:Impuestos"`
+	Complemento       struct{}       `xml:"cfdi:Complemento"`
+}
+
+type cfdiEmisor struct {
+	Rfc           string `xml:"Rfc,attr"`
+	Nombre        string `xml:"Nombre,attr"`
+	RegimenFiscal string `xml:"RegimenFiscal,attr"`
+}
+
+type cfdiReceptor struct {
+	Rfc                     string `xml:"Rfc,attr"`
+	Nombre                  string `xml:"Nombre,attr"`
+	DomicilioFiscalReceptor string `xml:"DomicilioFiscalReceptor,attr"`
+	RegimenFiscalReceptor   string `xml:"RegimenFiscalReceptor,attr"`
+	UsoCFDI                 string `xml:"UsoCFDI,attr"`
+}
+
+type cfdiConcepto struct {
+	ClaveProdServ string        `xml:"ClaveProdServ,attr"`
+	Cantidad      string        `xml:"Cantidad,attr"`
+	ClaveUnidad   string        `xml:"ClaveUnidad,attr"`
+	Descripcion   string        `xml:"Descripcion,attr"`
+	ValorUnitario string        `xml:"ValorUnitario,attr"`
+	Importe       string        `xml:"Importe,attr"`
+	ObjetoImp     string        `xml:"ObjetoImp,attr"`
+	Impuestos     cfdiImpuestos `xml:"cfdi:Impuestos"`
+}
+
+type cfdiImpuestos struct {
+	TotalImpuestosTrasladados string         `xml:"TotalImpuestosTrasladados,attr,omitempty"`
+	Traslados                 []cfdiTraslado `xml:"cfdi:Traslados>cfdi:Traslado"`
+}
+
+// cfdiTraslado es un IVA trasladado; los exentos no llevan TasaOCuota ni Importe
+type cfdiTraslado struct {
+	Base       string `xml:"Base,attr"`
+	Impuesto   string `xml:"Impuesto,attr"`
+	TipoFactor string `xml:"TipoFactor,attr"`
+	TasaOCuota string `xml:"TasaOCuota,attr,omitempty"`
+	Importe    string `xml:"Importe,attr,omitempty"`
+}
+
+// buildCFDI arma el XML sin sellar del CFDI 4.0 de ingreso de la factura, pagado en una sola exhibición (PUE)
+func buildCFDI(invoice *models.Invoice, issuedAt time.Time) ([]byte, error) {
+	comprobante := cfdiComprobante{
+		XmlnsCfdi:         "http://www.sat.gob.mx/cfd/4",
+		XmlnsXsi:          "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation:    "http://www.sat.gob.mx/cfd/4 http://www.sat.gob.mx/sitio_internet/cfd/4/cfdv40.xsd",
+		Version:           "4.0",
+		Serie:             invoice.Serie,
+		Folio:             fmt.Sprintf("%d", invoice.Folio),
+		Fecha:             cfdiDate(issuedAt),
+		FormaPago:         invoice.FormaPago,
+		SubTotal:          cfdiAmount(invoice.Subtotal),
+		Moneda:            "MXN",
+		Total:             cfdiAmount(invoice.Total),
+		TipoDeComprobante: "I",
+		Exportacion:       "01",
+		MetodoPago:        "PUE",
+		LugarExpedicion:   constants.CFDIPostalCode,
+		Emisor: cfdiEmisor{
+			Rfc:           constants.HotelRFC,
+			Nombre:        constants.CFDIIssuerName,
+			RegimenFiscal: constants.CFDIRegimenFiscal,
+		},
+		Receptor: cfdiReceptor{
+			Rfc:                     invoice.Receptor.RFC,
+			Nombre:                  invoice.Receptor.RazonSocial,
+			DomicilioFiscalReceptor: invoice.Receptor.CodigoPostal,
+			RegimenFiscalReceptor:   invoice.Receptor.RegimenFiscal,
+			UsoCFDI:                 invoice.Receptor.UsoCFDI,
+		},
+	}
+
+	var taxedBase, exemptBase float64
+	for _, concept := range invoice.Concepts {
+		traslado := cfdiTraslado{Base: cfdiAmount(concept.Base), Impuesto: "002"}
+		if concept.Exempt {
+			traslado.TipoFactor = "Exento"
+			exemptBase += concept.Base
+		} else {
+			traslado.TipoFactor = "Tasa"
+			traslado.TasaOCuota = "0.160000"
+			traslado.Importe = cfdiAmount(concept.IVA)
+			taxedBase += concept.Base
+		}
+		comprobante.Conceptos = append(comprobante.Conceptos, cfdiConcepto{
+			ClaveProdServ: concept.ClaveProdServ,
+			Cantidad:      "1",
+			ClaveUnidad:   claveUnidadServicio,
+			Descripcion:   concept.Description,
+			ValorUnitario: cfdiAmount(concept.Base),
+			Importe:       cfdiAmount(concept.Base),
+			ObjetoImp:     "02",
+			Impuestos:     cfdiImpuestos{Traslados: []cfdiTraslado{traslado}},
+		})
+	}
+
+	// El resumen agrupa los traslados por tasa y solo lleva total si hubo IVA
+	if taxedBase > 0 {
+		comprobante.Impuestos.TotalImpuestosTrasladados = cfdiAmount(invoice.IVA)
+		comprobante.Impuestos.Traslados = append(comprobante.Impuestos.Traslados, cfdiTraslado{
+			Base:       cfdiAmount(taxedBase),
+			Impuesto:   "002",
+			TipoFactor: "Tasa",
+			TasaOCuota: "0.160000",
+			Importe:    cfdiAmount(invoice.IVA),
+		})
+	}
+	if exemptBase > 0 {
+		comprobante.Impuestos.Traslados = append(comprobante.Impuestos.Traslados, cfdiTraslado{
+			Base:       cfdiAmount(exemptBase),
+			Impuesto:   "002",
+			TipoFactor: "Exento",
+		})
+	}
+
+	body, err := xml.Marshal(comprobante)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build cfdi: %v", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func cfdiAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", roundMoney(amount))
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyConcepts(t *testing.T) {
+	stay := func(amount float64, method string) invoiceLine {
+		return invoiceLine{Payment: models.Payment{ID: primitive.NewObjectID(), Amount: amount, Method: method}, Kind: models.ChargeStay}
+	}
+	rent := func(amount float64, method string) invoiceLine {
+		return invoiceLine{Payment: models.Payment{ID: primitive.NewObjectID(), Amount: amount, Method: method}, Kind: models.ChargeRent}
+	}
+
+	tests := []struct {
+		name      string
+		lines     []invoiceLine
+		subtotal  float64
+		iva       float64
+		total     float64
+		exempt    []bool
+		formaPago string
+	}{
+		{
+			name:      "hospedaje con IVA incluido",
+			lines:     []invoiceLine{stay(1160, models.PaymentCard)},
+			subtotal:  1000,
+			iva:       160,
+			total:     1160,
+			exempt:    []bool{false},
+			formaPago: "04",
+		},
+		{
+			name:      "renta exenta",
+			lines:     []invoiceLine{rent(5000, models.PaymentTransfer)},
+			subtotal:  5000,
+			iva:       0,
+			total:     5000,
+			exempt:    []bool{true},
+			formaPago: "03",
+		},
+		{
+			name:      "redondeo del IVA desglosado",
+			lines:     []invoiceLine{stay(100, models.PaymentCash)},
+			subtotal:  86.21,
+			iva:       13.79,
+			total:     100,
+			exempt:    []bool{false},
+			formaPago: "01",
+		},
+		{
+			name:      "mezcla de gravados y exentos",
+			lines:     []invoiceLine{stay(580, models.PaymentCash), rent(3000, models.PaymentTransfer), stay(99.99, models.PaymentCash)},
+			subtotal:  3586.20,
+			iva:       93.79,
+			total:     3679.99,
+			exempt:    []bool{false, true, false},
+			formaPago: "03",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice models.Invoice
+			applyConcepts(&invoice, tt.lines)
+
+			if invoice.Subtotal != tt.subtotal || invoice.IVA != tt.iva || invoice.Total != tt.total {
+				t.Errorf("totales = %.2f + %.2f = %.2f, se esperaba %.2f + %.2f = %.2f",
+					invoice.Subtotal, invoice.IVA, invoice.Total, tt.subtotal, tt.iva, tt.total)
+			}
+			if roundMoney(invoice.Subtotal+invoice.IVA) != invoice.Total {
+				t.Errorf("subtotal + IVA = %.2f, no coincide con el total %.2f", invoice.Subtotal+invoice.IVA, invoice.Total)
+			}
+			if invoice.FormaPago != tt.formaPago {
+				t.Errorf("formaPago = %q, se esperaba %q", invoice.FormaPago, tt.formaPago)
+			}
+			if len(invoice.Concepts) != len(tt.exempt) || len(invoice.PaymentIDs) != len(tt.lines) {
+				t.Fatalf("%d conceptos y %d pagos, se esperaban %d", len(invoice.Concepts), len(invoice.PaymentIDs), len(tt.lines))
+			}
+			for i, concept := range invoice.Concepts {
+				if concept.Exempt != tt.exempt[i] {
+					t.Errorf("concepto %d: exempt = %v, se esperaba %v", i, concept.Exempt, tt.exempt[i])
+				}
+				if concept.Exempt && (concept.IVA != 0 || concept.ClaveProdServ != claveProdServArrendamiento) {
+					t.Errorf("concepto %d exento con IVA %.2f y clave %s", i, concept.IVA, concept.ClaveProdServ)
+				}
+				if !concept.Exempt && roundMoney(concept.Base+concept.IVA) != concept.Amount {
+					t.Errorf("concepto %d: base %.2f + IVA %.2f no suma el importe %.2f", i, concept.Base, concept.IVA, concept.Amount)
+				}
+			}
+		})
+	}
+}
+
+// testComprobante lee del CFDI los atributos que se revisan en las pruebas
+type testComprobante struct {
+	SubTotal  string `xml:"SubTotal,attr"`
+	Total     string `xml:"Total,attr"`
+	FormaPago string `xml:"FormaPago,attr"`
+	Conceptos []struct {
+		Traslados []testTraslado `xml:"Impuestos>Traslados>Traslado"`
+	} `xml:"Conceptos>Concepto"`
+	Impuestos struct {
+		TotalImpuestosTrasladados string         `xml:"TotalImpuestosTrasladados,attr"`
+		Traslados                 []testTraslado `xml:"Traslados>Traslado"`
+	} `xml:"Impuestos"`
+	Complemento struct {
+		Timbre *struct {
+			UUID string `xml:"UUID,attr"`
+		} `xml:"TimbreFiscalDigital"`
+	} `xml:"Complemento"`
+}
+
+type testTraslado struct {
+	Base       string `xml:"Base,attr"`
+	TipoFactor string `xml:"TipoFactor,attr"`
+	Importe    string `xml:"Importe,attr"`
+}
+
+func testInvoice(lines ...invoiceLine) *models.Invoice {
+	invoice := &models.Invoice{
+		Serie:    "H",
+		Folio:    1,
+		Receptor: models.FiscalData{RFC: "XAXX010101000", RazonSocial: "PUBLICO EN GENERAL", RegimenFiscal: "616", CodigoPostal: "01000", UsoCFDI: "S01"},
+	}
+	applyConcepts(invoice, lines)
+	return invoice
+}
+
+func TestBuildCFDI(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []invoiceLine
+		subTotal  string
+		total     string
+		totalIVA  string
+		traslados []testTraslado
+	}{
+		{
+			name:      "solo gravados",
+			lines:     []invoiceLine{{Payment: models.Payment{Amount: 1160, Method: models.PaymentCash}, Kind: models.ChargeStay}},
+			subTotal:  "1000.00",
+			total:     "1160.00",
+			totalIVA:  "160.00",
+			traslados: []testTraslado{{Base: "1000.00", TipoFactor: "Tasa", Importe: "160.00"}},
+		},
+		{
+			name:      "solo exentos",
+			lines:     []invoiceLine{{Payment: models.Payment{Amount: 4500, Method: models.PaymentTransfer}, Kind: models.ChargeRent}},
+			subTotal:  "4500.00",
+			total:     "4500.00",
+			totalIVA:  "",
+			traslados: []testTraslado{{Base: "4500.00", TipoFactor: "Exento"}},
+		},
+		{
+			name: "gravados y exentos",
+			lines: []invoiceLine{
+				{Payment: models.Payment{Amount: 580, Method: models.PaymentCard}, Kind: models.ChargeStay},
+				{Payment: models.Payment{Amount: 3000, Method: models.PaymentCard}, Kind: models.ChargeLateFee},
+			},
+			subTotal: "3500.00",
+			total:    "3580.00",
+			totalIVA: "80.00",
+			traslados: []testTraslado{
+				{Base: "500.00", TipoFactor: "Tasa", Importe: "80.00"},
+				{Base: "3000.00", TipoFactor: "Exento"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := testInvoice(tt.lines...)
+			content, err := buildCFDI(invoice, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("buildCFDI: %v", err)
+			}
+			if !bytes.Contains(content, []byte(emptyComplemento)) {
+				t.Errorf("el CFDI sin timbrar no trae el Complemento vacío")
+			}
+
+			var comprobante testComprobante
+			if err := xml.Unmarshal(content, &comprobante); err != nil {
+				t.Fatalf("el CFDI no es XML válido: %v", err)
+			}
+			if comprobante.SubTotal != tt.subTotal || comprobante.Total != tt.total {
+				t.Errorf("SubTotal %s y Total %s, se esperaba %s y %s", comprobante.SubTotal, comprobante.Total, tt.subTotal, tt.total)
+			}
+			if comprobante.Impuestos.TotalImpuestosTrasladados != tt.totalIVA {
+				t.Errorf("TotalImpuestosTrasladados = %q, se esperaba %q", comprobante.Impuestos.TotalImpuestosTrasladados, tt.totalIVA)
+			}
+			if len(comprobante.Impuestos.Traslados) != len(tt.traslados) {
+				t.Fatalf("%d traslados en el resumen, se esperaban %d", len(comprobante.Impuestos.Traslados), len(tt.traslados))
+			}
+			for i, traslado := range comprobante.Impuestos.Traslados {
+				if traslado != tt.traslados[i] {
+					t.Errorf("traslado %d = %+v, se esperaba %+v", i, traslado, tt.traslados[i])
+				}
+			}
+			if len(comprobante.Conceptos) != len(tt.lines) {
+				t.Errorf("%d conceptos, se esperaban %d", len(comprobante.Conceptos), len(tt.lines))
+			}
+		})
+	}
+}
+
+func TestFakePACStamp(t *testing.T) {
+	invoice := testInvoice(invoiceLine{Payment: models.Payment{Amount: 1160, Method: models.PaymentCash}, Kind: models.ChargeStay})
+	content, err := buildCFDI(invoice, time.Now())
+	if err != nil {
+		t.Fatalf("buildCFDI: %v", err)
+	}
+
+	result, err := FakePAC{}.Stamp(context.Background(), content)
+	if err != nil {
+		t.Fatalf("Stamp: %v", err)
+	}
+	if result.UUID == "" || result.StampedAt.IsZero() {
+		t.Fatalf("timbre sin UUID o sin fecha: %+v", result)
+	}
+	if bytes.Contains(result.XML, []byte(emptyComplemento)) {
+		t.Errorf("el Complemento vacío no se reemplazó")
+	}
+
+	var comprobante testComprobante
+	if err := xml.Unmarshal(result.XML, &comprobante); err != nil {
+		t.Fatalf("el CFDI timbrado no es XML válido: %v", err)
+	}
+	if comprobante.Complemento.Timbre == nil {
+		t.Fatalf("el CFDI timbrado no trae el TimbreFiscalDigital")
+	}
+	if comprobante.Complemento.Timbre.UUID != result.UUID {
+		t.Errorf("UUID del timbre %q, se esperaba %q", comprobante.Complemento.Timbre.UUID, result.UUID)
+	}
+	if comprobante.Total != "1160.00" {
+		t.Errorf("el timbrado cambió el Total a %s", comprobante.Total)
+	}
+
+	if _, err := (FakePAC{}).Stamp(context.Background(), []byte("<cfdi:Comprobante/>")); err == nil {
+		t.Errorf("se esperaba error al timbrar un CFDI sin Complemento")
+	}
+}
+
+func TestCFDIDate(t *testing.T) {
+	previous := constants.CFDITimeZone
+	defer func() { constants.CFDITimeZone = previous }()
+
+	issuedAt := time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		zone string
+		want string
+	}{
+		{"America/Mexico_City", "2026-10-17T12:30:00"},
+		{"America/Tijuana", "2026-10-17T11:30:00"},
+		{"America/Cancun", "2026-10-17T13:30:00"},
+		{"", "2026-10-17T12:30:00"},
+		{"Zona/Invalida", "2026-10-17T12:30:00"},
+	}
+	for _, tt := range tests {
+		constants.CFDITimeZone = tt.zone
+		if got := cfdiDate(issuedAt); got != tt.want {
+			t.Errorf("cfdiDate con zona %q = %s, se esperaba %s", tt.zone, got, tt.want)
+		}
+	}
+}
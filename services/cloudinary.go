@@ -36,3 +36,19 @@ func (s *CloudinaryService) UploadINEPicture(file multipart.File, handler *multi
 	}
 	return resp.SecureURL, nil
 }
+
+func (s *CloudinaryService) UploadMaintenancePhoto(file multipart.File, handler *multipart.FileHeader) (string, error) {
+	resp, err := s.Cloudinary.Upload.Upload(context.Background(), file, uploader.UploadParams{Folder: "maintenance_photos"})
+	if err != nil {
+		return "", err
+	}
+	return resp.SecureURL, nil
+}
+
+func (s *CloudinaryService) UploadRoomPhoto(file multipart.File, handler *multipart.FileHeader) (string, error) {
+	resp, err := s.Cloudinary.Upload.Upload(context.Background(), file, uploader.UploadParams{Folder: "room_photos"})
+	if err != nil {
+		return "", err
+	}
+	return resp.SecureURL, nil
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"github.com/go-pdf/fpdf"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentService genera los recibos de pago y los estados de cuenta de los clientes en PDF
+type DocumentService struct {
+	Client   *mongo.Client
+	Payments *PaymentService
+}
+
+// NewDocumentService crea una nueva instancia de DocumentService
+func NewDocumentService(client *mongo.Client, payments *PaymentService) *DocumentService {
+	return &DocumentService{Client: client, Payments: payments}
+}
+
+func (s *DocumentService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+// documentClient son los datos de un huésped o inquilino que aparecen en los documentos
+type documentClient struct {
+	Nombres    string `bson:"nombres"`
+	Apellidos  string `bson:"apellidos"`
+	CustomID   string `bson:"customID"`
+	RentalRoom string `bson:"RoomNumber"`
+	GuestRoom  string `bson:"roomNumber"`
+}
+
+// name devuelve el nombre del inquilino o, para un huésped, su ID personalizado
+func (c documentClient) name() string {
+	if c.Nombres != "" {
+		return strings.TrimSpace(c.Nombres + " " + c.Apellidos)
+	}
+	return "Huésped " + c.CustomID
+}
+
+func (c documentClient) room() string {
+	if c.RentalRoom != "" {
+		return c.RentalRoom
+	}
+	return c.GuestRoom
+}
+
+func (s *DocumentService) client(ctx context.Context, id primitive.ObjectID) (*documentClient, error) {
+	var client documentClient
+	err := s.db().Collection(constants.CollectionClients).FindOne(ctx, bson.M{"_id": id}).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client: %v", err)
+	}
+	return &client, nil
+}
+
+// Receipt genera el recibo del pago; si el pago fue anulado el recibo lo indica
+func (s *DocumentService) Receipt(ctx context.Context, id primitive.ObjectID) (*models.Payment, []byte, error) {
+	var payment models.Payment
+	err := s.db().Collection(constants.CollectionPayments).FindOne(ctx, bson.M{"_id": id}).Decode(&payment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get payment: %v", err)
+	}
+	client, err := s.client(ctx, payment.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	concept := "Abono a cuenta"
+	if payment.ChargeID != nil {
+		var charge models.Charge
+		err := s.db().Collection(constants.CollectionCharges).FindOne(ctx, bson.M{"_id": *payment.ChargeID}).Decode(&charge)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, nil, fmt.Errorf("unable to get charge: %v", err)
+		}
+		if err == nil {
+			concept = charge.Description
+		}
+	}
+
+	doc := newPDFDocument("Recibo de pago")
+	doc.field("Folio", payment.ID.Hex())
+	doc.field("Fecha", payment.ReceivedAt.Local().Format("02/01/2006 15:04"))
+	doc.field("Cliente", client.name())
+	doc.field("Habitación", client.room())
+	doc.field("Concepto", concept)
+	doc.field("Método", paymentMethodLabels[payment.Method])
+	if payment.Reference != "" {
+		doc.field("Referencia", payment.Reference)
+	}
+	doc.field("Recibió", payment.ReceivedBy)
+	doc.Ln(4)
+	doc.SetFont("Helvetica", "B", 14)
+	doc.field("Importe", formatMoney(payment.Amount))
+
+	if payment.Status == models.PaymentVoided {
+		doc.Ln(6)
+		doc.SetTextColor(200, 0, 0)
+		doc.SetFont("Helvetica", "B", 14)
+		doc.cell(0, 8, "PAGO ANULADO", "L")
+		doc.Ln(8)
+		doc.SetFont("Helvetica", "", 10)
+		doc.field("Motivo", payment.VoidReason)
+		doc.SetTextColor(0, 0, 0)
+	}
+
+	content, err := doc.bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &payment, content, nil
+}
+
+// SaveReceiptURL guarda en el pago la URL de su recibo almacenado
+func (s *DocumentService) SaveReceiptURL(ctx context.Context, id primitive.ObjectID, url string) error {
+	_, err := s.db().Collection(constants.CollectionPayments).UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"receiptUrl": url}})
+	if err != nil {
+		return fmt.Errorf("unable to save receipt url: %v", err)
+	}
+	return nil
+}
+
+// Statement genera el estado de cuenta del cliente con sus cargos no anulados, sus pagos vigentes y su saldo
+func (s *DocumentService) Statement(ctx context.Context, clientID primitive.ObjectID) ([]byte, error) {
+	balance, err := s.Payments.Balance(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.client(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var charges []models.Charge
+	cursor, err := s.db().Collection(constants.CollectionCharges).Find(ctx,
+		bson.M{"clientId": clientID, "status": bson.M{"$ne": models.ChargeCancelled}},
+		options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list charges: %v", err)
+	}
+	if err := cursor.All(ctx, &charges); err != nil {
+		return nil, fmt.Errorf("unable to decode charges: %v", err)
+	}
+
+	var payments []models.Payment
+	cursor, err = s.db().Collection(constants.CollectionPayments).Find(ctx,
+		bson.M{"clientId": clientID, "status": models.PaymentValid},
+		options.Find().SetSort(bson.D{{Key: "receivedAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list payments: %v", err)
+	}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, fmt.Errorf("unable to decode payments: %v", err)
+	}
+
+	doc := newPDFDocument("Estado de cuenta")
+	doc.field("Cliente", client.name())
+	doc.field("Habitación", client.room())
+	doc.Ln(4)
+
+	chargeRows := [][]string{}
+	for _, charge := range charges {
+		chargeRows = append(chargeRows, []string{
+			charge.DueDate.Format("02/01/2006"),
+			charge.Description,
+			chargeStatusLabels[charge.Status],
+			formatMoney(charge.Amount),
+			formatMoney(charge.Paid),
+		})
+	}
+	doc.section("Cargos")
+	doc.table([]float64{25, 80, 25, 27, 28}, "LLLRR",
+		[]string{"Vencimiento", "Concepto", "Estado", "Importe", "Abonado"}, chargeRows)
+
+	paymentRows := [][]string{}
+	for _, payment := range payments {
+		paymentRows = append(paymentRows, []string{
+			payment.ReceivedAt.Local().Format("02/01/2006"),
+			paymentMethodLabels[payment.Method],
+			payment.Reference,
+			payment.ReceivedBy,
+			formatMoney(payment.Amount),
+		})
+	}
+	doc.section("Pagos")
+	doc.table([]float64{25, 30, 50, 52, 28}, "LLLLR",
+		[]string{"Fecha", "Método", "Referencia", "Recibió", "Importe"}, paymentRows)
+
+	doc.Ln(4)
+	doc.field("Total cargos", formatMoney(balance.Charged))
+	doc.field("Total pagos", formatMoney(balance.Paid))
+	doc.SetFont("Helvetica", "B", 12)
+	if balance.Balance < 0 {
+		doc.field("Saldo a favor", formatMoney(-balance.Balance))
+	} else {
+		doc.field("Saldo", formatMoney(balance.Balance))
+	}
+	return doc.bytes()
+}
+
+// paymentMethodLabels y chargeStatusLabels traducen los valores guardados para mostrarlos en los documentos
+var paymentMethodLabels = map[string]string{
+	models.PaymentCash:     "Efectivo",
+	models.PaymentCard:     "Tarjeta",
+	models.PaymentTransfer: "Transferencia",
+}
+
+var chargeStatusLabels = map[string]string{
+	models.ChargePending: "Pendiente",
+	models.ChargeOverdue: "Vencido",
+	models.ChargePaid:    "Pagado",
+}
+
+// formatMoney da formato de pesos a un importe
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// pdfDocument es una hoja tamaño carta con el encabezado del hotel. Las fuentes base de PDF no son UTF-8,
+// así que todo el texto pasa por tr.
+type pdfDocument struct {
+	*fpdf.Fpdf
+	tr func(string) string
+}
+
+func newPDFDocument(title string) *pdfDocument {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+	doc := &pdfDocument{Fpdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	doc.SetFont("Helvetica", "B", 16)
+	doc.cell(0, 8, constants.HotelName, "L")
+	doc.Ln(8)
+	doc.SetFont("Helvetica", "", 9)
+	for _, line := range []string{constants.HotelAddress, constants.HotelPhone, constants.HotelRFC} {
+		if line != "" {
+			doc.cell(0, 5, line, "L")
+			doc.Ln(5)
+		}
+	}
+	doc.Ln(4)
+
+	doc.SetFont("Helvetica", "B", 13)
+	doc.cell(120, 8, title, "L")
+	doc.SetFont("Helvetica", "", 9)
+	doc.cell(0, 8, "Generado el "+time.Now().Format("02/01/2006 15:04"), "R")
+	doc.Ln(10)
+	doc.SetFont("Helvetica", "", 10)
+	return doc
+}
+
+func (d *pdfDocument) cell(width, height float64, text, align string) {
+	d.CellFormat(width, height, d.tr(text), "", 0, align, false, 0, "")
+}
+
+// field escribe una línea "etiqueta: valor" con la fuente actual
+func (d *pdfDocument) field(label, value string) {
+	size, _ := d.GetFontSize()
+	d.SetFont("Helvetica", "B", size)
+	d.cell(40, 6, label+":", "L")
+	d.SetFont("Helvetica", "", size)
+	d.cell(0, 6, value, "L")
+	d.Ln(6)
+}
+
+func (d *pdfDocument) section(title string) {
+	d.Ln(2)
+	d.SetFont("Helvetica", "B", 11)
+	d.cell(0, 7, title, "L")
+	d.Ln(7)
+	d.SetFont("Helvetica", "", 9)
+}
+
+// table escribe una tabla con encabezado; aligns tiene la alineación de cada columna (L, C o R)
+func (d *pdfDocument) table(widths []float64, aligns string, header []string, rows [][]string) {
+	d.SetFont("Helvetica", "B", 9)
+	d.SetFillColor(230, 230, 230)
+	for i, text := range header {
+		d.CellFormat(widths[i], 6, d.tr(text), "1", 0, string(aligns[i]), true, 0, "")
+	}
+	d.Ln(6)
+
+	d.SetFont("Helvetica", "", 9)
+	for _, row := range rows {
+		for i, text := range row {
+			d.CellFormat(widths[i], 6, d.tr(text), "1", 0, string(aligns[i]), false, 0, "")
+		}
+		d.Ln(6)
+	}
+	if len(rows) == 0 {
+		d.CellFormat(0, 6, d.tr("Sin movimientos"), "1", 0, "C", false, 0, "")
+		d.Ln(6)
+	}
+	d.SetFont("Helvetica", "", 10)
+}
+
+func (d *pdfDocument) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Output(&buf); err != nil {
+		return nil, fmt.Errorf("unable to render pdf: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrTaskNotFound indica que no existe la tarea de limpieza
+	ErrTaskNotFound = errors.New("housekeeping task not found")
+	// ErrTaskState indica que la tarea no está en el estado que exige la operación
+	ErrTaskState = errors.New("housekeeping task is not in the required state")
+	// ErrTaskNotAssignee indica que la tarea está asignada a otro usuario
+	ErrTaskNotAssignee = errors.New("housekeeping task is assigned to another user")
+	// ErrTaskExists indica que la habitación ya tiene una tarea de limpieza abierta
+	ErrTaskExists = errors.New("room already has an open housekeeping task")
+	// ErrAssigneeInvalid indica que el usuario al que se quiere asignar la tarea no existe o está desactivado
+	ErrAssigneeInvalid = errors.New("assignee does not exist or is disabled")
+)
+
+// openHousekeepingStatuses son los estados de una tarea que todavía requiere trabajo o inspección
+var openHousekeepingStatuses = []string{models.HousekeepingPending, models.HousekeepingInProgress, models.HousekeepingFinished}
+
+// HousekeepingService maneja el tablero de tareas de limpieza y mueve las habitaciones por
+// dirty → cleaning → inspected → available a medida que avanzan las tareas
+type HousekeepingService struct {
+	Client *mongo.Client
+}
+
+// NewHousekeepingService crea una nueva instancia de HousekeepingService
+func NewHousekeepingService(client *mongo.Client) *HousekeepingService {
+	return &HousekeepingService{Client: client}
+}
+
+func (s *HousekeepingService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *HousekeepingService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionHousekeeping)
+}
+
+// Create abre manualmente una tarea de limpieza para una habitación que no tenga otra abierta
+func (s *HousekeepingService) Create(ctx context.Context, ref RoomRef, notes string) (*models.HousekeepingTask, error) {
+	var task *models.HousekeepingTask
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		var room models.Room
+		err := s.db().Collection(constants.CollectionRooms).FindOne(sc, ref.filter()).Decode(&room)
+		if err == mongo.ErrNoDocuments {
+			return ErrRoomNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("unable to get room: %v", err)
+		}
+
+		task, err = createCleaningTask(sc, s.db(), room, notes)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return ErrTaskExists
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Assign asigna la tarea abierta a un usuario del personal activo
+func (s *HousekeepingService) Assign(ctx context.Context, id primitive.ObjectID, assignee, assignedBy string) (*models.HousekeepingTask, error) {
+	users := s.db().Collection(constants.CollectionUsers)
+	count, err := users.CountDocuments(ctx, bson.M{"correo": assignee, "disabled": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up assignee: %v", err)
+	}
+	if count == 0 {
+		return nil, ErrAssigneeInvalid
+	}
+
+	now := time.Now()
+	return s.update(ctx, bson.M{"_id": id, "status": bson.M{"$in": openHousekeepingStatuses}},
+		bson.M{"$set": bson.M{"assignedTo": assignee, "assignedBy": assignedBy, "assignedAt": now}})
+}
+
+// Start marca la tarea como en curso y la habitación como en limpieza. Una tarea sin asignar se asigna a quien la empieza.
+func (s *HousekeepingService) Start(ctx context.Context, id primitive.ObjectID, user string) (*models.HousekeepingTask, error) {
+	var task *models.HousekeepingTask
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.get(sc, id)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.HousekeepingPending {
+			return ErrTaskState
+		}
+		if current.AssignedTo != "" && current.AssignedTo != user {
+			return ErrTaskNotAssignee
+		}
+
+		if _, err := transitionRoomStatus(sc, s.db(), bson.M{"_id": current.RoomID}, models.RoomStatusCleaning, user, "housekeeping"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		set := bson.M{"status": models.HousekeepingInProgress, "startedAt": now}
+		if current.AssignedTo == "" {
+			set["assignedTo"] = user
+			set["assignedAt"] = now
+		}
+		task, err = s.update(sc, bson.M{"_id": id, "status": models.HousekeepingPending}, bson.M{"$set": set})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Finish marca la tarea como terminada y la habitación como pendiente de inspección
+func (s *HousekeepingService) Finish(ctx context.Context, id primitive.ObjectID, user, notes string) (*models.HousekeepingTask, error) {
+	var task *models.HousekeepingTask
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.get(sc, id)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.HousekeepingInProgress {
+			return ErrTaskState
+		}
+		if current.AssignedTo != user {
+			return ErrTaskNotAssignee
+		}
+
+		if _, err := transitionRoomStatus(sc, s.db(), bson.M{"_id": current.RoomID}, models.RoomStatusInspected, user, "housekeeping"); err != nil {
+			return err
+		}
+
+		set := bson.M{"status": models.HousekeepingFinished, "finishedAt": time.Now()}
+		if notes != "" {
+			set["notes"] = notes
+		}
+		task, err = s.update(sc, bson.M{"_id": id, "status": models.HousekeepingInProgress}, bson.M{"$set": set})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Inspect registra la inspección de una tarea terminada. Si pasa, la habitación queda disponible;
+// si no, la habitación vuelve a "dirty" y se abre una nueva tarea para el mismo usuario.
+func (s *HousekeepingService) Inspect(ctx context.Context, id primitive.ObjectID, passed bool, inspector, notes string) (*models.HousekeepingTask, error) {
+	var task *models.HousekeepingTask
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.get(sc, id)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.HousekeepingFinished {
+			return ErrTaskState
+		}
+
+		status, roomStatus := models.HousekeepingInspected, models.RoomStatusAvailable
+		if !passed {
+			status, roomStatus = models.HousekeepingRejected, models.RoomStatusDirty
+		}
+		room, err := transitionRoomStatus(sc, s.db(), bson.M{"_id": current.RoomID}, roomStatus, inspector, "inspection")
+		if err != nil {
+			return err
+		}
+
+		set := bson.M{"status": status, "inspectedBy": inspector, "inspectedAt": time.Now()}
+		if notes != "" {
+			set["notes"] = notes
+		}
+		task, err = s.update(sc, bson.M{"_id": id, "status": models.HousekeepingFinished}, bson.M{"$set": set})
+		if err != nil {
+			return err
+		}
+
+		if !passed {
+			retry, err := createCleaningTask(sc, s.db(), *room, notes)
+			if err != nil {
+				return err
+			}
+			if retry != nil && current.AssignedTo != "" {
+				now := time.Now()
+				_, err = s.collection().UpdateOne(sc, bson.M{"_id": retry.ID},
+					bson.M{"$set": bson.M{"assignedTo": current.AssignedTo, "assignedBy": inspector, "assignedAt": now}})
+				if err != nil {
+					return fmt.Errorf("unable to assign housekeeping task: %v", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// List devuelve las tareas que cumplen el filtro, de la más reciente a la más antigua
+func (s *HousekeepingService) List(ctx context.Context, filter bson.M) ([]models.HousekeepingTask, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(500)
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list housekeeping tasks: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.HousekeepingTask{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("unable to decode housekeeping tasks: %v", err)
+	}
+	return tasks, nil
+}
+
+func (s *HousekeepingService) get(ctx context.Context, id primitive.ObjectID) (*models.HousekeepingTask, error) {
+	var task models.HousekeepingTask
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get housekeeping task: %v", err)
+	}
+	return &task, nil
+}
+
+// update aplica la actualización a la tarea que cumple el filtro y la devuelve actualizada.
+// Si no la encuentra distingue entre una tarea inexistente y una que no está en el estado esperado.
+func (s *HousekeepingService) update(ctx context.Context, filter, update bson.M) (*models.HousekeepingTask, error) {
+	var task models.HousekeepingTask
+	err := s.collection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.get(ctx, filter["_id"].(primitive.ObjectID)); err != nil {
+			return nil, err
+		}
+		return nil, ErrTaskState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update housekeeping task: %v", err)
+	}
+	return &task, nil
+}
+
+// createCleaningTask abre una tarea de limpieza pendiente para la habitación dentro de la transacción en curso.
+// Devuelve nil si la habitación ya tiene una tarea abierta.
+func createCleaningTask(sc mongo.SessionContext, db *mongo.Database, room models.Room, notes string) (*models.HousekeepingTask, error) {
+	collection := db.Collection(constants.CollectionHousekeeping)
+	open, err := collection.CountDocuments(sc, bson.M{"roomId": room.ID, "status": bson.M{"$in": openHousekeepingStatuses}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up housekeeping tasks: %v", err)
+	}
+	if open > 0 {
+		return nil, nil
+	}
+
+	task := models.HousekeepingTask{
+		ID:         primitive.NewObjectID(),
+		RoomID:     room.ID,
+		RoomNumber: room.RoomNumber,
+		Status:     models.HousekeepingPending,
+		Notes:      notes,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := collection.InsertOne(sc, task); err != nil {
+		return nil, fmt.Errorf("unable to create housekeeping task: %v", err)
+	}
+	return &task, nil
+}
+
+// resetCleaningTasks regresa a "pending" las tareas en curso o terminadas de la habitación dentro de la transacción
+// en curso, conservando la asignación, para que se puedan volver a empezar cuando la habitación vuelva a "dirty"
+func resetCleaningTasks(sc mongo.SessionContext, db *mongo.Database, roomID primitive.ObjectID) error {
+	_, err := db.Collection(constants.CollectionHousekeeping).UpdateMany(sc,
+		bson.M{"roomId": roomID, "status": bson.M{"$in": []string{models.HousekeepingInProgress, models.HousekeepingFinished}}},
+		bson.M{
+			"$set":   bson.M{"status": models.HousekeepingPending},
+			"$unset": bson.M{"startedAt": "", "finishedAt": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to reset housekeeping tasks: %v", err)
+	}
+	return nil
+}
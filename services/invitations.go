@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvitationDefaultTTL es la vigencia de una invitación cuando el administrador no indica otra
+const InvitationDefaultTTL = 72 * time.Hour
+
+// InvitationMaxTTL es la vigencia máxima que se puede asignar a una invitación
+const InvitationMaxTTL = 30 * 24 * time.Hour
+
+// ErrInvitationInvalid indica que la invitación no existe, expiró, fue revocada, ya se usó o es para otro correo
+var ErrInvitationInvalid = errors.New("invitation is invalid or expired")
+
+// InvitationStore maneja las invitaciones de registro guardadas como hash en MongoDB
+type InvitationStore struct {
+	Client *mongo.Client
+}
+
+// NewInvitationStore crea una nueva instancia de InvitationStore
+func NewInvitationStore(client *mongo.Client) *InvitationStore {
+	return &InvitationStore{Client: client}
+}
+
+func (s *InvitationStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionInvitations)
+}
+
+// Create guarda la invitación y devuelve el token en claro, que solo se muestra una vez
+func (s *InvitationStore) Create(ctx context.Context, invitation *models.Invitation) (string, error) {
+	plain, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	invitation.ID = primitive.NewObjectID()
+	invitation.TokenHash = hashOpaqueToken(plain)
+	invitation.CreatedAt = time.Now()
+	if _, err := s.collection().InsertOne(ctx, invitation); err != nil {
+		return "", fmt.Errorf("unable to create invitation: %v", err)
+	}
+	return plain, nil
+}
+
+// Consume marca la invitación como usada por el correo dado de forma atómica y la devuelve
+func (s *InvitationStore) Consume(ctx context.Context, plain, correo string) (*models.Invitation, error) {
+	if plain == "" {
+		return nil, ErrInvitationInvalid
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"tokenHash": hashOpaqueToken(plain),
+		"revoked":   false,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+		"$or": bson.A{
+			bson.M{"correo": bson.M{"$exists": false}},
+			bson.M{"correo": ""},
+			bson.M{"correo": correo},
+		},
+	}
+	var invitation models.Invitation
+	err := s.collection().FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"usedAt": now, "usedBy": correo}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to consume invitation: %v", err)
+	}
+	return &invitation, nil
+}
+
+// Release devuelve una invitación consumida a su estado pendiente, p. ej. si falló la creación del usuario
+func (s *InvitationStore) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"usedAt": "", "usedBy": ""}})
+	if err != nil {
+		return fmt.Errorf("unable to release invitation: %v", err)
+	}
+	return nil
+}
+
+// ListPending devuelve las invitaciones que todavía se pueden usar
+func (s *InvitationStore) ListPending(ctx context.Context) ([]models.Invitation, error) {
+	filter := bson.M{
+		"revoked":   false,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list invitations: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, fmt.Errorf("unable to decode invitations: %v", err)
+	}
+	return invitations, nil
+}
+
+// Revoke revoca una invitación pendiente. Devuelve false si no existía o ya se había usado
+func (s *InvitationStore) Revoke(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"_id": id, "revoked": false, "usedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("unable to revoke invitation: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrInvoiceNotFound indica que no existe la factura
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrFiscalDataMissing indica que el cliente no tiene datos fiscales capturados
+	ErrFiscalDataMissing = errors.New("client has no fiscal data")
+	// ErrIssuerNotConfigured indica que faltan el RFC, el nombre o el código postal del emisor en la configuración
+	ErrIssuerNotConfigured = errors.New("invoice issuer is not configured")
+	// ErrPaymentNotInvoiceable indica que algún pago no existe, es de otro cliente, está anulado o ya se facturó
+	ErrPaymentNotInvoiceable = errors.New("payment cannot be invoiced")
+)
+
+// InvoiceService emite facturas CFDI 4.0 por los pagos de los clientes y las timbra con el PAC
+type InvoiceService struct {
+	Client *mongo.Client
+	PAC    PACClient
+}
+
+// NewInvoiceService crea una nueva instancia de InvoiceService
+func NewInvoiceService(client *mongo.Client, pac PACClient) *InvoiceService {
+	return &InvoiceService{Client: client, PAC: pac}
+}
+
+func (s *InvoiceService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *InvoiceService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionInvoices)
+}
+
+// SetFiscalData guarda los datos fiscales del huésped o inquilino
+func (s *InvoiceService) SetFiscalData(ctx context.Context, clientID primitive.ObjectID, data models.FiscalData) error {
+	result, err := s.db().Collection(constants.CollectionClients).UpdateOne(ctx,
+		bson.M{"_id": clientID},
+		bson.M{"$set": bson.M{"fiscalData": data, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update fiscal data: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+// Issue factura los pagos del cliente: aparta el folio y los pagos, arma el CFDI y lo timbra con el PAC. Si
+// el PAC lo rechaza, los pagos se liberan y el folio queda sin usar.
+func (s *InvoiceService) Issue(ctx context.Context, clientID primitive.ObjectID, paymentIDs []primitive.ObjectID, user string) (*models.Invoice, error) {
+	if constants.HotelRFC == "" || constants.CFDIIssuerName == "" || constants.CFDIPostalCode == "" {
+		return nil, ErrIssuerNotConfigured
+	}
+
+	var client struct {
+		Nombres    string             `bson:"nombres"`
+		FiscalData *models.FiscalData `bson:"fiscalData"`
+	}
+	err := s.db().Collection(constants.CollectionClients).FindOne(ctx, bson.M{"_id": clientID}).Decode(&client)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client: %v", err)
+	}
+	if client.FiscalData == nil {
+		return nil, ErrFiscalDataMissing
+	}
+	// Los pagos a cuenta de un inquilino se facturan como renta y los de un huésped como hospedaje
+	defaultKind := models.ChargeStay
+	if client.Nombres != "" {
+		defaultKind = models.ChargeRent
+	}
+
+	invoice := models.Invoice{
+		ID:        primitive.NewObjectID(),
+		ClientID:  clientID,
+		Serie:     constants.CFDISerie,
+		Receptor:  *client.FiscalData,
+		Status:    models.InvoicePending,
+		IssuedBy:  user,
+		CreatedAt: time.Now(),
+	}
+	err = runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		payments, err := s.invoiceablePayments(sc, clientID, paymentIDs)
+		if err != nil {
+			return err
+		}
+		if err := s.fillConcepts(sc, &invoice, payments, defaultKind); err != nil {
+			return err
+		}
+
+		var folio struct {
+			Seq int64 `bson:"seq"`
+		}
+		err = s.db().Collection(constants.CollectionInvoiceFolios).FindOneAndUpdate(sc,
+			bson.M{"_id": invoice.Serie},
+			bson.M{"$inc": bson.M{"seq": 1}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&folio)
+		if err != nil {
+			return fmt.Errorf("unable to reserve folio: %v", err)
+		}
+		invoice.Folio = folio.Seq
+
+		if _, err := s.collection().InsertOne(sc, invoice); err != nil {
+			return fmt.Errorf("unable to create invoice: %v", err)
+		}
+		_, err = s.db().Collection(constants.CollectionPayments).UpdateMany(sc,
+			bson.M{"_id": bson.M{"$in": invoice.PaymentIDs}},
+			bson.M{"$set": bson.M{"invoiceId": invoice.ID}},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to mark payments as invoiced: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	xml, err := buildCFDI(&invoice, invoice.CreatedAt)
+	if err != nil {
+		s.release(ctx, &invoice)
+		return nil, err
+	}
+	stamp, err := s.PAC.Stamp(ctx, xml)
+	if err != nil {
+		s.release(ctx, &invoice)
+		return nil, fmt.Errorf("unable to stamp cfdi: %v", err)
+	}
+
+	invoice.Status = models.InvoiceStamped
+	invoice.UUID = stamp.UUID
+	invoice.StampedAt = &stamp.StampedAt
+	invoice.XML = string(stamp.XML)
+	_, err = s.collection().UpdateOne(ctx, bson.M{"_id": invoice.ID}, bson.M{"$set": bson.M{
+		"status":    invoice.Status,
+		"uuid":      invoice.UUID,
+		"stampedAt": invoice.StampedAt,
+		"xml":       invoice.XML,
+	}})
+	if err != nil {
+		// El CFDI ya está timbrado ante el SAT; no se liberan los pagos para no facturarlos dos veces
+		return nil, fmt.Errorf("unable to save stamped invoice %s: %v", invoice.UUID, err)
+	}
+	return &invoice, nil
+}
+
+// invoiceablePayments devuelve los pagos vigentes y sin facturar del cliente; falla si alguno no lo es
+func (s *InvoiceService) invoiceablePayments(sc mongo.SessionContext, clientID primitive.ObjectID, ids []primitive.ObjectID) ([]models.Payment, error) {
+	unique := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+
+	cursor, err := s.db().Collection(constants.CollectionPayments).Find(sc, bson.M{
+		"_id":       bson.M{"$in": ids},
+		"clientId":  clientID,
+		"status":    models.PaymentValid,
+		"invoiceId": bson.M{"$exists": false},
+	}, options.Find().SetSort(bson.D{{Key: "receivedAt", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get payments: %v", err)
+	}
+	var payments []models.Payment
+	if err := cursor.All(sc, &payments); err != nil {
+		return nil, fmt.Errorf("unable to decode payments: %v", err)
+	}
+	if len(payments) == 0 || len(payments) != len(unique) {
+		return nil, ErrPaymentNotInvoiceable
+	}
+	return payments, nil
+}
+
+// invoiceLine es un pago con el tipo y la descripción del cargo al que se aplicó
+type invoiceLine struct {
+	Payment     models.Payment
+	Kind        string
+	Description string
+}
+
+// fillConcepts busca el cargo al que se aplicó cada pago y agrega a la factura una línea por pago con sus totales
+func (s *InvoiceService) fillConcepts(sc mongo.SessionContext, invoice *models.Invoice, payments []models.Payment, defaultKind string) error {
+	lines := make([]invoiceLine, 0, len(payments))
+	for _, payment := range payments {
+		line := invoiceLine{Payment: payment, Kind: defaultKind, Description: "Abono a cuenta"}
+		if payment.ChargeID != nil {
+			var charge models.Charge
+			if err := s.db().Collection(constants.CollectionCharges).FindOne(sc, bson.M{"_id": *payment.ChargeID}).Decode(&charge); err != nil {
+				return fmt.Errorf("unable to get charge: %v", err)
+			}
+			line.Kind, line.Description = charge.Kind, charge.Description
+		}
+		lines = append(lines, line)
+	}
+	applyConcepts(invoice, lines)
+	return nil
+}
+
+// applyConcepts agrega los conceptos y calcula los totales y la forma de pago de la factura. Los importes
+// cobrados ya incluyen el IVA; la renta de casa habitación y sus recargos están exentos.
+func applyConcepts(invoice *models.Invoice, lines []invoiceLine) {
+	methodTotals := map[string]float64{}
+	for _, line := range lines {
+		payment := line.Payment
+		concept := models.InvoiceConcept{PaymentID: payment.ID, Description: line.Description, Amount: payment.Amount}
+		if line.Kind == models.ChargeStay {
+			concept.ClaveProdServ = claveProdServHospedaje
+			concept.Base = roundMoney(payment.Amount / (1 + ivaRate))
+			concept.IVA = roundMoney(payment.Amount - concept.Base)
+		} else {
+			concept.ClaveProdServ = claveProdServArrendamiento
+			concept.Base = payment.Amount
+			concept.Exempt = true
+		}
+
+		invoice.PaymentIDs = append(invoice.PaymentIDs, payment.ID)
+		invoice.Concepts = append(invoice.Concepts, concept)
+		invoice.Subtotal = roundMoney(invoice.Subtotal + concept.Base)
+		invoice.IVA = roundMoney(invoice.IVA + concept.IVA)
+		invoice.Total = roundMoney(invoice.Total + concept.Amount)
+		methodTotals[payment.Method] += payment.Amount
+	}
+
+	// Con pago en una sola exhibición la forma de pago es la del método con el que se pagó más
+	var top float64
+	for method, total := range methodTotals {
+		if total > top {
+			top = total
+			invoice.FormaPago = formasPago[method]
+		}
+	}
+}
+
+// release borra la factura sin timbrar y libera sus pagos para que se puedan volver a facturar
+func (s *InvoiceService) release(ctx context.Context, invoice *models.Invoice) {
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		if _, err := s.collection().DeleteOne(sc, bson.M{"_id": invoice.ID, "status": models.InvoicePending}); err != nil {
+			return err
+		}
+		_, err := s.db().Collection(constants.CollectionPayments).UpdateMany(sc,
+			bson.M{"invoiceId": invoice.ID},
+			bson.M{"$unset": bson.M{"invoiceId": ""}},
+		)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error liberando la factura %s-%d: %v", invoice.Serie, invoice.Folio, err)
+	}
+}
+
+// AttachFiles guarda en la factura las URLs del XML timbrado y de su PDF
+func (s *InvoiceService) AttachFiles(ctx context.Context, id primitive.ObjectID, xmlURL, pdfURL string) error {
+	_, err := s.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"xmlUrl": xmlURL, "pdfUrl": pdfURL}})
+	if err != nil {
+		return fmt.Errorf("unable to update invoice: %v", err)
+	}
+	return nil
+}
+
+// Get devuelve la factura por su ID
+func (s *InvoiceService) Get(ctx context.Context, id primitive.ObjectID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&invoice)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get invoice: %v", err)
+	}
+	return &invoice, nil
+}
+
+// List devuelve las facturas que cumplen el filtro, de la más reciente a la más antigua
+func (s *InvoiceService) List(ctx context.Context, filter bson.M) ([]models.Invoice, error) {
+	cursor, err := s.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list invoices: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	invoices := []models.Invoice{}
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, fmt.Errorf("unable to decode invoices: %v", err)
+	}
+	return invoices, nil
+}
+
+// PDF genera la representación impresa de la factura timbrada
+func (s *InvoiceService) PDF(invoice *models.Invoice) ([]byte, error) {
+	doc := newPDFDocument(fmt.Sprintf("Factura %s-%d", invoice.Serie, invoice.Folio))
+	doc.field("Folio fiscal", invoice.UUID)
+	if invoice.StampedAt != nil {
+		doc.field("Timbrado", invoice.StampedAt.Format("02/01/2006 15:04:05"))
+	}
+	doc.field("Emisor", fmt.Sprintf("%s - %s", constants.HotelRFC, constants.CFDIIssuerName))
+	doc.field("Régimen emisor", constants.CFDIRegimenFiscal)
+	doc.field("Lugar de exp.", constants.CFDIPostalCode)
+	doc.Ln(2)
+	doc.field("Receptor", fmt.Sprintf("%s - %s", invoice.Receptor.RFC, invoice.Receptor.RazonSocial))
+	doc.field("Régimen", invoice.Receptor.RegimenFiscal)
+	doc.field("C.P. fiscal", invoice.Receptor.CodigoPostal)
+	doc.field("Uso CFDI", invoice.Receptor.UsoCFDI)
+	doc.field("Forma de pago", invoice.FormaPago)
+	doc.field("Método de pago", "PUE - Pago en una sola exhibición")
+
+	rows := [][]string{}
+	for _, concept := range invoice.Concepts {
+		iva := formatMoney(concept.IVA)
+		if concept.Exempt {
+			iva = "Exento"
+		}
+		rows = append(rows, []string{concept.ClaveProdServ, concept.Description, formatMoney(concept.Base), iva, formatMoney(concept.Amount)})
+	}
+	doc.section("Conceptos")
+	doc.table([]float64{25, 85, 25, 25, 25}, "LLRRR", []string{"Clave", "Descripción", "Importe", "IVA", "Total"}, rows)
+
+	doc.Ln(4)
+	doc.field("Subtotal", formatMoney(invoice.Subtotal))
+	doc.field("IVA 16%", formatMoney(invoice.IVA))
+	doc.SetFont("Helvetica", "B", 12)
+	doc.field("Total", formatMoney(invoice.Total))
+	doc.Ln(4)
+	doc.SetFont("Helvetica", "", 8)
+	doc.cell(0, 5, "Este documento es una representación impresa de un CFDI 4.0", "L")
+	return doc.bytes()
+}
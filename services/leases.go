@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrLeaseNotFound indica que no existe el contrato
+	ErrLeaseNotFound = errors.New("lease not found")
+	// ErrLeaseActive indica que el inquilino ya tiene un contrato vigente en esas fechas
+	ErrLeaseActive = errors.New("rental already has an active lease")
+	// ErrLeaseClosed indica que el contrato ya fue renovado o terminado
+	ErrLeaseClosed = errors.New("lease is no longer active")
+	// ErrLeaseDates indica que las fechas del contrato no son válidas
+	ErrLeaseDates = errors.New("invalid lease dates")
+	// ErrRentalNotFound indica que no existe el inquilino
+	ErrRentalNotFound = errors.New("rental not found")
+)
+
+// LeaseRenewal son las condiciones del contrato que sustituye al actual; los campos nil conservan las anteriores
+type LeaseRenewal struct {
+	EndDate       time.Time
+	MonthlyAmount *float64
+	DueDay        *int
+}
+
+// LeaseService maneja los contratos de los inquilinos y su calendario de cargos mensuales
+type LeaseService struct {
+	Client *mongo.Client
+}
+
+// NewLeaseService crea una nueva instancia de LeaseService
+func NewLeaseService(client *mongo.Client) *LeaseService {
+	return &LeaseService{Client: client}
+}
+
+func (s *LeaseService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *LeaseService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionLeases)
+}
+
+func (s *LeaseService) charges() *mongo.Collection {
+	return s.db().Collection(constants.CollectionCharges)
+}
+
+// Create guarda el contrato, genera sus cargos y actualiza la renta y el estado del inquilino
+func (s *LeaseService) Create(ctx context.Context, lease *models.Lease) error {
+	if !lease.EndDate.After(lease.StartDate) {
+		return ErrLeaseDates
+	}
+
+	return runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		var rental models.Rental
+		err := s.db().Collection(constants.CollectionClients).FindOne(sc,
+			bson.M{"_id": lease.RentalID, "nombres": bson.M{"$exists": true}},
+		).Decode(&rental)
+		if err == mongo.ErrNoDocuments {
+			return ErrRentalNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("unable to get rental: %v", err)
+		}
+
+		active, err := s.collection().CountDocuments(sc, bson.M{
+			"rentalId": lease.RentalID,
+			"status":   models.LeaseActive,
+			"endDate":  bson.M{"$gt": lease.StartDate},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to look up leases: %v", err)
+		}
+		if active > 0 {
+			return ErrLeaseActive
+		}
+
+		if lease.RoomNumber == "" {
+			lease.RoomNumber = rental.RoomNumber
+		}
+		return s.insert(sc, lease)
+	})
+}
+
+// Get devuelve el contrato y su calendario de cargos ordenado por vencimiento
+func (s *LeaseService) Get(ctx context.Context, id primitive.ObjectID) (*models.Lease, []models.Charge, error) {
+	var lease models.Lease
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&lease)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, ErrLeaseNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get lease: %v", err)
+	}
+
+	cursor, err := s.charges().Find(ctx, bson.M{"leaseId": id}, options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list charges: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	charges := []models.Charge{}
+	if err := cursor.All(ctx, &charges); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode charges: %v", err)
+	}
+	return &lease, charges, nil
+}
+
+// List devuelve los contratos que cumplen el filtro, del más reciente al más antiguo
+func (s *LeaseService) List(ctx context.Context, filter bson.M) ([]models.Lease, error) {
+	cursor, err := s.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "startDate", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list leases: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	leases := []models.Lease{}
+	if err := cursor.All(ctx, &leases); err != nil {
+		return nil, fmt.Errorf("unable to decode leases: %v", err)
+	}
+	return leases, nil
+}
+
+// Renew sustituye el contrato vigente por uno nuevo que empieza donde termina el actual.
+// El depósito ya cobrado se conserva, así que el nuevo contrato no genera otro.
+func (s *LeaseService) Renew(ctx context.Context, id primitive.ObjectID, renewal LeaseRenewal, user string) (*models.Lease, error) {
+	var renewed models.Lease
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.active(sc, id)
+		if err != nil {
+			return err
+		}
+		if !renewal.EndDate.After(current.EndDate) {
+			return ErrLeaseDates
+		}
+
+		renewed = models.Lease{
+			RentalID:      current.RentalID,
+			RoomNumber:    current.RoomNumber,
+			StartDate:     current.EndDate,
+			EndDate:       renewal.EndDate,
+			MonthlyAmount: current.MonthlyAmount,
+			DueDay:        current.DueDay,
+			RenewedFrom:   &current.ID,
+			CreatedBy:     user,
+		}
+		if renewal.MonthlyAmount != nil {
+			renewed.MonthlyAmount = *renewal.MonthlyAmount
+		}
+		if renewal.DueDay != nil {
+			renewed.DueDay = *renewal.DueDay
+		}
+		if err := s.insert(sc, &renewed); err != nil {
+			return err
+		}
+
+		_, err = s.collection().UpdateOne(sc, bson.M{"_id": current.ID}, bson.M{"$set": bson.M{
+			"status":    models.LeaseRenewed,
+			"renewedBy": renewed.ID,
+			"updatedAt": time.Now(),
+		}})
+		if err != nil {
+			return fmt.Errorf("unable to update lease: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &renewed, nil
+}
+
+// Terminate termina el contrato vigente en la fecha indicada, anula los cargos de renta pendientes de los
+// meses que empiezan a partir de esa fecha y marca al inquilino como finalizado
+func (s *LeaseService) Terminate(ctx context.Context, id primitive.ObjectID, date time.Time, reason, user string) (*models.Lease, error) {
+	var lease models.Lease
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.active(sc, id)
+		if err != nil {
+			return err
+		}
+		if date.Before(current.StartDate) || date.After(current.EndDate) {
+			return ErrLeaseDates
+		}
+
+		now := time.Now()
+		err = s.collection().FindOneAndUpdate(sc, bson.M{"_id": id}, bson.M{"$set": bson.M{
+			"status":            models.LeaseTerminated,
+			"endDate":           date,
+			"terminatedAt":      now,
+			"terminatedBy":      user,
+			"terminationReason": reason,
+			"updatedAt":         now,
+		}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&lease)
+		if err != nil {
+			return fmt.Errorf("unable to terminate lease: %v", err)
+		}
+
+		_, err = s.charges().UpdateMany(sc,
+			bson.M{"leaseId": id, "kind": models.ChargeRent, "status": models.ChargePending, "periodStart": bson.M{"$gte": date}},
+			bson.M{"$set": bson.M{"status": models.ChargeCancelled, "updatedAt": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to cancel charges: %v", err)
+		}
+
+		_, err = s.db().Collection(constants.CollectionClients).UpdateOne(sc,
+			bson.M{"_id": current.RentalID},
+			bson.M{"$set": bson.M{"estado": models.RentalEstadoFinalizado, "updatedAt": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to update rental: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// active devuelve el contrato si sigue vigente dentro de la transacción en curso
+func (s *LeaseService) active(sc mongo.SessionContext, id primitive.ObjectID) (*models.Lease, error) {
+	var lease models.Lease
+	err := s.collection().FindOne(sc, bson.M{"_id": id}).Decode(&lease)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrLeaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get lease: %v", err)
+	}
+	if lease.Status != models.LeaseActive {
+		return nil, ErrLeaseClosed
+	}
+	return &lease, nil
+}
+
+// insert guarda el contrato con sus cargos y actualiza la renta y el estado del inquilino
+func (s *LeaseService) insert(sc mongo.SessionContext, lease *models.Lease) error {
+	now := time.Now()
+	lease.ID = primitive.NewObjectID()
+	lease.Status = models.LeaseActive
+	lease.CreatedAt = now
+	lease.UpdatedAt = now
+	if _, err := s.collection().InsertOne(sc, lease); err != nil {
+		return fmt.Errorf("unable to create lease: %v", err)
+	}
+
+	charges := leaseSchedule(*lease)
+	documents := make([]interface{}, len(charges))
+	for i := range charges {
+		documents[i] = charges[i]
+	}
+	if len(documents) > 0 {
+		if _, err := s.charges().InsertMany(sc, documents); err != nil {
+			return fmt.Errorf("unable to create charges: %v", err)
+		}
+	}
+
+	_, err := s.db().Collection(constants.CollectionClients).UpdateOne(sc,
+		bson.M{"_id": lease.RentalID},
+		bson.M{"$set": bson.M{"rentalPrice": lease.MonthlyAmount, "estado": models.RentalEstadoActivo, "updatedAt": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update rental: %v", err)
+	}
+	return nil
+}
+
+// leaseSchedule genera el cargo del depósito y un cargo de renta por cada mes del contrato. Cada mes empieza
+// el mismo día que el contrato y vence el primer DueDay a partir de su inicio; no se prorratean meses incompletos.
+func leaseSchedule(lease models.Lease) []models.Charge {
+	now := time.Now()
+	charges := []models.Charge{}
+	if lease.Deposit > 0 {
+		charges = append(charges, models.Charge{
+			ID:          primitive.NewObjectID(),
+			ClientID:    lease.RentalID,
+			LeaseID:     &lease.ID,
+			Kind:        models.ChargeDeposit,
+			Description: "Depósito",
+			DueDate:     lease.StartDate,
+			Amount:      lease.Deposit,
+			Status:      models.ChargePending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	for i := 0; ; i++ {
+		periodStart := addMonths(lease.StartDate, i)
+		if !periodStart.Before(lease.EndDate) {
+			break
+		}
+		periodEnd := addMonths(lease.StartDate, i+1)
+		if periodEnd.After(lease.EndDate) {
+			periodEnd = lease.EndDate
+		}
+
+		dueDate := time.Date(periodStart.Year(), periodStart.Month(), lease.DueDay, 0, 0, 0, 0, time.UTC)
+		if dueDate.Before(periodStart) {
+			dueDate = dueDate.AddDate(0, 1, 0)
+		}
+
+		charges = append(charges, models.Charge{
+			ID:          primitive.NewObjectID(),
+			ClientID:    lease.RentalID,
+			LeaseID:     &lease.ID,
+			Kind:        models.ChargeRent,
+			Description: "Renta " + periodStart.Format("2006-01"),
+			PeriodStart: &periodStart,
+			PeriodEnd:   &periodEnd,
+			DueDate:     dueDate,
+			Amount:      lease.MonthlyAmount,
+			Status:      models.ChargePending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	return charges
+}
+
+// addMonths suma meses a una fecha sin desbordar al mes siguiente: 31 de enero + 1 mes es el 28 o 29 de febrero
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
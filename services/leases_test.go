@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func leaseDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{leaseDate(2026, 1, 15), 1, leaseDate(2026, 2, 15)},
+		{leaseDate(2026, 1, 31), 1, leaseDate(2026, 2, 28)},
+		{leaseDate(2028, 1, 31), 1, leaseDate(2028, 2, 29)},
+		{leaseDate(2026, 1, 31), 2, leaseDate(2026, 3, 31)},
+		{leaseDate(2026, 3, 31), 1, leaseDate(2026, 4, 30)},
+		{leaseDate(2026, 11, 30), 3, leaseDate(2027, 2, 28)},
+		{leaseDate(2026, 12, 1), 1, leaseDate(2027, 1, 1)},
+		{leaseDate(2026, 5, 10), 0, leaseDate(2026, 5, 10)},
+	}
+
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.months); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, se esperaba %s", reportDate(tt.from), tt.months, reportDate(got), reportDate(tt.want))
+		}
+	}
+}
+
+func TestLeaseSchedule(t *testing.T) {
+	type rent struct{ start, end, due time.Time }
+
+	tests := []struct {
+		name    string
+		lease   models.Lease
+		deposit bool
+		rents   []rent
+	}{
+		{
+			name:    "tres meses con depósito",
+			lease:   models.Lease{StartDate: leaseDate(2026, 1, 10), EndDate: leaseDate(2026, 4, 10), DueDay: 5, MonthlyAmount: 4500, Deposit: 4500},
+			deposit: true,
+			rents: []rent{
+				{leaseDate(2026, 1, 10), leaseDate(2026, 2, 10), leaseDate(2026, 2, 5)},
+				{leaseDate(2026, 2, 10), leaseDate(2026, 3, 10), leaseDate(2026, 3, 5)},
+				{leaseDate(2026, 3, 10), leaseDate(2026, 4, 10), leaseDate(2026, 4, 5)},
+			},
+		},
+		{
+			name:  "vence dentro del mismo periodo",
+			lease: models.Lease{StartDate: leaseDate(2026, 1, 1), EndDate: leaseDate(2026, 3, 1), DueDay: 10, MonthlyAmount: 3000},
+			rents: []rent{
+				{leaseDate(2026, 1, 1), leaseDate(2026, 2, 1), leaseDate(2026, 1, 10)},
+				{leaseDate(2026, 2, 1), leaseDate(2026, 3, 1), leaseDate(2026, 2, 10)},
+			},
+		},
+		{
+			name:  "fin de mes sin desbordar y último periodo incompleto",
+			lease: models.Lease{StartDate: leaseDate(2026, 1, 31), EndDate: leaseDate(2026, 3, 15), DueDay: 15, MonthlyAmount: 5000},
+			rents: []rent{
+				{leaseDate(2026, 1, 31), leaseDate(2026, 2, 28), leaseDate(2026, 2, 15)},
+				{leaseDate(2026, 2, 28), leaseDate(2026, 3, 15), leaseDate(2026, 3, 15)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.lease.ID = primitive.NewObjectID()
+			tt.lease.RentalID = primitive.NewObjectID()
+			charges := leaseSchedule(tt.lease)
+
+			if tt.deposit {
+				if len(charges) == 0 || charges[0].Kind != models.ChargeDeposit {
+					t.Fatalf("falta el cargo de depósito")
+				}
+				if charges[0].Amount != tt.lease.Deposit || !charges[0].DueDate.Equal(tt.lease.StartDate) {
+					t.Errorf("depósito de %.2f al %s", charges[0].Amount, reportDate(charges[0].DueDate))
+				}
+				charges = charges[1:]
+			}
+			if len(charges) != len(tt.rents) {
+				t.Fatalf("%d rentas, se esperaban %d", len(charges), len(tt.rents))
+			}
+			for i, charge := range charges {
+				want := tt.rents[i]
+				if charge.Kind != models.ChargeRent || charge.Amount != tt.lease.MonthlyAmount || charge.Status != models.ChargePending {
+					t.Errorf("renta %d: %s de %.2f en %s", i, charge.Kind, charge.Amount, charge.Status)
+				}
+				if !charge.PeriodStart.Equal(want.start) || !charge.PeriodEnd.Equal(want.end) || !charge.DueDate.Equal(want.due) {
+					t.Errorf("renta %d: %s a %s vence %s, se esperaba %s a %s vence %s", i,
+						reportDate(*charge.PeriodStart), reportDate(*charge.PeriodEnd), reportDate(charge.DueDate),
+						reportDate(want.start), reportDate(want.end), reportDate(want.due))
+				}
+				if charge.ClientID != tt.lease.RentalID || charge.LeaseID == nil || *charge.LeaseID != tt.lease.ID {
+					t.Errorf("renta %d no apunta al contrato ni al inquilino", i)
+				}
+			}
+		})
+	}
+}
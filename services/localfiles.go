@@ -80,6 +80,69 @@ func (l *LocalFileSystemService) UploadFilePDF(file multipart.File, handler *mul
 	return url, nil
 }
 
+// UploadFileXML maneja la carga de archivos XML, como las facturas timbradas, al sistema de archivos local
+func (l *LocalFileSystemService) UploadFileXML(file multipart.File, handler *multipart.FileHeader) (string, error) {
+	fmt.Println("Starting XML file upload to local file system")
+
+	// Verifica que el archivo sea un XML
+	if filepath.Ext(handler.Filename) != ".xml" {
+		return "", fmt.Errorf("file is not an XML")
+	}
+
+	// Crea la ruta completa del archivo
+	filePath := filepath.Join(l.BasePath, "documents", handler.Filename)
+
+	// Crea el archivo en el sistema de archivos local
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to create file: %v", err)
+	}
+	defer dst.Close()
+
+	// Copia el contenido del archivo cargado al nuevo archivo en el sistema de archivos local
+	_, err = io.Copy(dst, file)
+	if err != nil {
+		return "", fmt.Errorf("unable to copy file content: %v", err)
+	}
+
+	fmt.Printf("XML file uploaded successfully: %s\n", filePath)
+
+	url := fmt.Sprintf("https://api-v1.hotelman.dna-nova.tech:8000/serve?folder=documents&filename=%s", filepath.Base(filePath))
+	return url, nil
+}
+
+// UploadFileReport maneja la carga de reportes exportados en CSV o XLSX al sistema de archivos local
+func (l *LocalFileSystemService) UploadFileReport(file multipart.File, handler *multipart.FileHeader) (string, error) {
+	fmt.Println("Starting report file upload to local file system")
+
+	// Verifica que el archivo sea un CSV o un XLSX
+	ext := filepath.Ext(handler.Filename)
+	if ext != ".csv" && ext != ".xlsx" {
+		return "", fmt.Errorf("file is not a CSV or XLSX report")
+	}
+
+	// Crea la ruta completa del archivo
+	filePath := filepath.Join(l.BasePath, "documents", handler.Filename)
+
+	// Crea el archivo en el sistema de archivos local
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to create file: %v", err)
+	}
+	defer dst.Close()
+
+	// Copia el contenido del archivo cargado al nuevo archivo en el sistema de archivos local
+	_, err = io.Copy(dst, file)
+	if err != nil {
+		return "", fmt.Errorf("unable to copy file content: %v", err)
+	}
+
+	fmt.Printf("Report file uploaded successfully: %s\n", filePath)
+
+	url := fmt.Sprintf("https://api-v1.hotelman.dna-nova.tech:8000/serve?folder=documents&filename=%s", filepath.Base(filePath))
+	return url, nil
+}
+
 // UploadFileImage maneja la carga de archivos de imagen al sistema de archivos local
 func (l *LocalFileSystemService) UploadFileImage(file multipart.File, handler *multipart.FileHeader) (string, error) {
 	fmt.Println("Starting image file upload to local file system")
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Política de protección contra fuerza bruta
+const (
+	throttleFreeFailures = 3                // Fallos permitidos antes de aplicar retardos
+	throttleMaxDelay     = 30 * time.Second // Retardo máximo entre intentos
+	accountMaxFailures   = 10               // Fallos que bloquean una cuenta
+	ipMaxFailures        = 50               // Fallos que bloquean una IP
+	resetMaxRequests     = 10               // Solicitudes de restablecimiento de contraseña que bloquean una IP
+	lockoutDuration      = 15 * time.Minute // Duración del bloqueo temporal
+	failureWindow        = time.Hour        // Los fallos más antiguos que esto ya no cuentan
+)
+
+// LoginThrottle aplica retardos progresivos y bloqueos temporales por cuenta y por IP,
+// y guarda el historial de intentos de login
+type LoginThrottle struct {
+	Client *mongo.Client
+}
+
+// NewLoginThrottle crea una nueva instancia de LoginThrottle
+func NewLoginThrottle(client *mongo.Client) *LoginThrottle {
+	return &LoginThrottle{Client: client}
+}
+
+func (t *LoginThrottle) throttles() *mongo.Collection {
+	return t.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionLoginThrottles)
+}
+
+func (t *LoginThrottle) attempts() *mongo.Collection {
+	return t.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionLoginAttempts)
+}
+
+// EnsureIndexes crea el índice único por clave, que impide que dos upserts simultáneos dupliquen un contador
+func (t *LoginThrottle) EnsureIndexes(ctx context.Context) error {
+	_, err := t.throttles().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("key_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create login throttle index: %v", err)
+	}
+	return nil
+}
+
+// AccountKey devuelve la clave de throttling de una cuenta
+func AccountKey(username string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// IPKey devuelve la clave de throttling de una IP
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// PasswordResetKey devuelve la clave de throttling de las solicitudes de restablecimiento de una IP
+func PasswordResetKey(ip string) string {
+	return "reset:" + ip
+}
+
+// Check indica si se debe rechazar un intento para la cuenta y la IP dadas.
+// Devuelve cuánto tiempo debe esperar el cliente y si el rechazo se debe a un bloqueo.
+func (t *LoginThrottle) Check(ctx context.Context, username, ip string) (time.Duration, bool, error) {
+	return t.check(ctx, AccountKey(username), IPKey(ip))
+}
+
+// CheckPasswordReset indica si se debe rechazar una solicitud de restablecimiento de contraseña desde la IP
+func (t *LoginThrottle) CheckPasswordReset(ctx context.Context, ip string) (time.Duration, bool, error) {
+	return t.check(ctx, PasswordResetKey(ip))
+}
+
+// RecordPasswordReset cuenta una solicitud de restablecimiento de la IP; cuentan todas, exista o no el correo,
+// para no revelar qué cuentas están registradas
+func (t *LoginThrottle) RecordPasswordReset(ctx context.Context, ip string) error {
+	return t.recordFailure(ctx, PasswordResetKey(ip), resetMaxRequests)
+}
+
+func (t *LoginThrottle) check(ctx context.Context, keys ...string) (time.Duration, bool, error) {
+	var wait time.Duration
+	var locked bool
+
+	for _, key := range keys {
+		state, err := t.get(ctx, key)
+		if err != nil {
+			return 0, false, err
+		}
+		if state == nil {
+			continue
+		}
+
+		now := time.Now()
+		if state.LockedUntil != nil && state.LockedUntil.After(now) {
+			if d := state.LockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+			locked = true
+			continue
+		}
+		if d := progressiveDelay(state.Failures) - now.Sub(state.LastFailureAt); d > 0 && d > wait {
+			wait = d
+		}
+	}
+	return wait, locked, nil
+}
+
+// RecordFailure suma un fallo a la cuenta y a la IP y las bloquea al superar el límite
+func (t *LoginThrottle) RecordFailure(ctx context.Context, username, ip string) error {
+	if err := t.recordFailure(ctx, AccountKey(username), accountMaxFailures); err != nil {
+		return err
+	}
+	return t.recordFailure(ctx, IPKey(ip), ipMaxFailures)
+}
+
+// recordFailure suma el fallo de forma atómica: el contador se incrementa en el servidor y se reinicia si el
+// último fallo quedó fuera de failureWindow, así los intentos en paralelo no pisan la cuenta de los demás
+func (t *LoginThrottle) recordFailure(ctx context.Context, key string, maxFailures int) error {
+	now := time.Now()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"key": key,
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$lastFailureAt", now.Add(-failureWindow)}},
+				bson.M{"$add": bson.A{"$failures", 1}},
+				1,
+			}},
+			"lastFailureAt": now,
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var state models.LoginThrottle
+	err := t.throttles().FindOneAndUpdate(ctx, bson.M{"key": key}, update, opts).Decode(&state)
+	if mongo.IsDuplicateKeyError(err) {
+		// Otro upsert simultáneo creó el contador primero; ahora la actualización lo encuentra
+		err = t.throttles().FindOneAndUpdate(ctx, bson.M{"key": key}, update, opts).Decode(&state)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to record login failure: %v", err)
+	}
+
+	if state.Failures >= maxFailures {
+		_, err = t.throttles().UpdateOne(ctx, bson.M{"key": key}, bson.M{"$set": bson.M{"lockedUntil": now.Add(lockoutDuration)}})
+		if err != nil {
+			return fmt.Errorf("unable to lock %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// RecordSuccess reinicia el contador de fallos de la cuenta
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, username string) error {
+	_, err := t.throttles().DeleteOne(ctx, bson.M{"key": AccountKey(username)})
+	if err != nil {
+		return fmt.Errorf("unable to reset login failures: %v", err)
+	}
+	return nil
+}
+
+// Unlock elimina el bloqueo y los fallos acumulados de la clave dada. Devuelve false si no había registro
+func (t *LoginThrottle) Unlock(ctx context.Context, key string) (bool, error) {
+	result, err := t.throttles().DeleteOne(ctx, bson.M{"key": key})
+	if err != nil {
+		return false, fmt.Errorf("unable to unlock: %v", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// ListLocked devuelve las cuentas e IPs bloqueadas actualmente
+func (t *LoginThrottle) ListLocked(ctx context.Context) ([]models.LoginThrottle, error) {
+	cursor, err := t.throttles().Find(ctx, bson.M{"lockedUntil": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list lockouts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	locked := []models.LoginThrottle{}
+	if err := cursor.All(ctx, &locked); err != nil {
+		return nil, fmt.Errorf("unable to decode lockouts: %v", err)
+	}
+	return locked, nil
+}
+
+// LogAttempt guarda un intento de login
+func (t *LoginThrottle) LogAttempt(ctx context.Context, username, ip, userAgent, outcome string) error {
+	attempt := models.LoginAttempt{
+		Username:  username,
+		IP:        ip,
+		UserAgent: userAgent,
+		Outcome:   outcome,
+		CreatedAt: time.Now(),
+	}
+	if _, err := t.attempts().InsertOne(ctx, attempt); err != nil {
+		return fmt.Errorf("unable to log login attempt: %v", err)
+	}
+	return nil
+}
+
+// ListAttempts devuelve los intentos que coinciden con el filtro, del más reciente al más antiguo, y el total
+func (t *LoginThrottle) ListAttempts(ctx context.Context, filter bson.M, page, pageSize int) ([]models.LoginAttempt, int64, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := t.attempts().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list login attempts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []models.LoginAttempt{}
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode login attempts: %v", err)
+	}
+
+	total, err := t.attempts().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count login attempts: %v", err)
+	}
+	return attempts, total, nil
+}
+
+func (t *LoginThrottle) get(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	var state models.LoginThrottle
+	err := t.throttles().FindOne(ctx, bson.M{"key": key}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read login throttle: %v", err)
+	}
+	return &state, nil
+}
+
+// progressiveDelay duplica el retardo por cada fallo a partir de throttleFreeFailures: 1s, 2s, 4s... hasta throttleMaxDelay
+func progressiveDelay(failures int) time.Duration {
+	if failures < throttleFreeFailures {
+		return 0
+	}
+	shift := failures - throttleFreeFailures
+	if shift >= 5 {
+		return throttleMaxDelay
+	}
+	return time.Second << uint(shift)
+}
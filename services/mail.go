@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+)
+
+// MailSender envía correos electrónicos de texto plano
+type MailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailSender crea el MailSender configurado en constants.MailSender ("smtp" o "log")
+func NewMailSender() (MailSender, error) {
+	switch constants.MailSender {
+	case "smtp":
+		return &SMTPMailSender{
+			Host:     constants.SMTPHost,
+			Port:     constants.SMTPPort,
+			Username: constants.SMTPUsername,
+			Password: constants.SMTPPassword,
+			From:     constants.SMTPFrom,
+		}, nil
+	case "log", "":
+		return NewFileMailSender(constants.MailOutboxFolder)
+	default:
+		return nil, fmt.Errorf("unknown mail sender: %s", constants.MailSender)
+	}
+}
+
+// SMTPMailSender envía correos a través de un servidor SMTP
+type SMTPMailSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send envía el correo usando autenticación PLAIN si hay usuario configurado
+func (s *SMTPMailSender) Send(ctx context.Context, to, subject, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	addr := s.Host + ":" + s.Port
+	if err := smtp.SendMail(addr, auth, s.From, []string{to}, buildMessage(s.From, to, subject, body)); err != nil {
+		return fmt.Errorf("unable to send mail: %v", err)
+	}
+	return nil
+}
+
+// FileMailSender escribe cada correo como un archivo .eml y lo registra en el log; pensado para desarrollo local
+type FileMailSender struct {
+	Folder string
+}
+
+// NewFileMailSender crea un FileMailSender que escribe en la carpeta relativa a la raíz del proyecto
+func NewFileMailSender(relativePath string) (*FileMailSender, error) {
+	rootPath, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get root directory: %v", err)
+	}
+
+	folder := filepath.Join(rootPath, relativePath)
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create outbox directory: %v", err)
+	}
+	return &FileMailSender{Folder: folder}, nil
+}
+
+// Send guarda el correo en la carpeta de salida
+func (s *FileMailSender) Send(ctx context.Context, to, subject, body string) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), sanitizeFileName(to))
+	path := filepath.Join(s.Folder, name)
+
+	if err := os.WriteFile(path, buildMessage(constants.SMTPFrom, to, subject, body), 0o600); err != nil {
+		return fmt.Errorf("unable to write mail: %v", err)
+	}
+	log.Printf("Correo para %s guardado en %s", to, path)
+	return nil
+}
+
+// buildMessage arma un mensaje RFC 822 sencillo en texto plano
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
+
+func sanitizeFileName(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, value)
+}
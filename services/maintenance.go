@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrTicketNotFound indica que no existe el ticket de mantenimiento
+	ErrTicketNotFound = errors.New("maintenance ticket not found")
+	// ErrTicketClosed indica que el ticket ya está cerrado
+	ErrTicketClosed = errors.New("maintenance ticket is closed")
+)
+
+// TicketChanges son los cambios que se pueden aplicar a un ticket abierto; los campos nil no se modifican
+type TicketChanges struct {
+	Title       *string
+	Description *string
+	Priority    *string
+	Blocking    *bool
+	Status      *string
+	AssignedTo  *string
+}
+
+// MaintenanceService maneja los tickets de mantenimiento y pone fuera de servicio las habitaciones con fallas bloqueantes
+type MaintenanceService struct {
+	Client *mongo.Client
+}
+
+// NewMaintenanceService crea una nueva instancia de MaintenanceService
+func NewMaintenanceService(client *mongo.Client) *MaintenanceService {
+	return &MaintenanceService{Client: client}
+}
+
+func (s *MaintenanceService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *MaintenanceService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionMaintenance)
+}
+
+// Create abre el ticket sobre la habitación; si es bloqueante la habitación pasa a "out-of-order"
+func (s *MaintenanceService) Create(ctx context.Context, ref RoomRef, ticket *models.MaintenanceTicket) error {
+	return runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		var room models.Room
+		err := s.db().Collection(constants.CollectionRooms).FindOne(sc, ref.filter()).Decode(&room)
+		if err == mongo.ErrNoDocuments {
+			return ErrRoomNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("unable to get room: %v", err)
+		}
+
+		now := time.Now()
+		ticket.ID = primitive.NewObjectID()
+		ticket.RoomID = room.ID
+		ticket.RoomNumber = room.RoomNumber
+		ticket.Status = models.MaintenanceOpen
+		ticket.CreatedAt = now
+		ticket.UpdatedAt = now
+		if ticket.Photos == nil {
+			ticket.Photos = []string{}
+		}
+		if _, err := s.collection().InsertOne(sc, ticket); err != nil {
+			return fmt.Errorf("unable to create maintenance ticket: %v", err)
+		}
+
+		if ticket.Blocking {
+			_, err = syncMaintenanceBlock(sc, s.db(), room.ID, ticket.ReportedBy)
+		}
+		return err
+	})
+}
+
+// Update aplica los cambios a un ticket abierto y actualiza el bloqueo de la habitación si cambió Blocking
+func (s *MaintenanceService) Update(ctx context.Context, id primitive.ObjectID, changes TicketChanges, user string) (*models.MaintenanceTicket, error) {
+	var ticket models.MaintenanceTicket
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		set := bson.M{"updatedAt": time.Now()}
+		if changes.Title != nil {
+			set["title"] = *changes.Title
+		}
+		if changes.Description != nil {
+			set["description"] = *changes.Description
+		}
+		if changes.Priority != nil {
+			set["priority"] = *changes.Priority
+		}
+		if changes.Blocking != nil {
+			set["blocking"] = *changes.Blocking
+		}
+		if changes.Status != nil {
+			set["status"] = *changes.Status
+		}
+		if changes.AssignedTo != nil {
+			set["assignedTo"] = *changes.AssignedTo
+		}
+
+		var previous models.MaintenanceTicket
+		err := s.collection().FindOneAndUpdate(sc,
+			bson.M{"_id": id, "status": bson.M{"$ne": models.MaintenanceClosed}},
+			bson.M{"$set": set},
+		).Decode(&previous)
+		if err == mongo.ErrNoDocuments {
+			return s.missingTicket(sc, id)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to update maintenance ticket: %v", err)
+		}
+
+		if err := s.collection().FindOne(sc, bson.M{"_id": id}).Decode(&ticket); err != nil {
+			return fmt.Errorf("unable to get maintenance ticket: %v", err)
+		}
+		if previous.Blocking != ticket.Blocking {
+			_, err = syncMaintenanceBlock(sc, s.db(), ticket.RoomID, user)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// Close cierra el ticket; si era el último bloqueante abierto, la habitación sale de "out-of-order"
+// y queda pendiente de limpieza
+func (s *MaintenanceService) Close(ctx context.Context, id primitive.ObjectID, resolution, user string) (*models.MaintenanceTicket, error) {
+	var ticket models.MaintenanceTicket
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		now := time.Now()
+		err := s.collection().FindOneAndUpdate(sc,
+			bson.M{"_id": id, "status": bson.M{"$ne": models.MaintenanceClosed}},
+			bson.M{"$set": bson.M{
+				"status":     models.MaintenanceClosed,
+				"resolution": resolution,
+				"closedBy":   user,
+				"closedAt":   now,
+				"updatedAt":  now,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&ticket)
+		if err == mongo.ErrNoDocuments {
+			return s.missingTicket(sc, id)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to close maintenance ticket: %v", err)
+		}
+
+		if ticket.Blocking {
+			_, err = syncMaintenanceBlock(sc, s.db(), ticket.RoomID, user)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// AddPhotos agrega las URLs de fotos ya subidas a un ticket abierto
+func (s *MaintenanceService) AddPhotos(ctx context.Context, id primitive.ObjectID, urls []string) (*models.MaintenanceTicket, error) {
+	var ticket models.MaintenanceTicket
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": models.MaintenanceClosed}},
+		bson.M{
+			"$push": bson.M{"photos": bson.M{"$each": urls}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&ticket)
+	if err == mongo.ErrNoDocuments {
+		return nil, s.missingTicket(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to add photos: %v", err)
+	}
+	return &ticket, nil
+}
+
+// List devuelve una página de tickets que cumplen el filtro, del más reciente al más antiguo, y el total
+func (s *MaintenanceService) List(ctx context.Context, filter bson.M, page, pageSize int) ([]models.MaintenanceTicket, int64, error) {
+	total, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count maintenance tickets: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list maintenance tickets: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	tickets := []models.MaintenanceTicket{}
+	if err := cursor.All(ctx, &tickets); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode maintenance tickets: %v", err)
+	}
+	return tickets, total, nil
+}
+
+// missingTicket distingue entre un ticket inexistente y uno ya cerrado
+func (s *MaintenanceService) missingTicket(ctx context.Context, id primitive.ObjectID) error {
+	count, err := s.collection().CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("unable to get maintenance ticket: %v", err)
+	}
+	if count == 0 {
+		return ErrTicketNotFound
+	}
+	return ErrTicketClosed
+}
+
+// syncMaintenanceBlock ajusta el estado de la habitación a sus tickets bloqueantes abiertos dentro de la transacción
+// en curso: con alguno abierto la pasa a "out-of-order" y su tarea de limpieza abierta vuelve a "pending"; sin
+// ninguno, si estaba "out-of-order", la pasa a "dirty" y deja una sola tarea pendiente, la que se regresó al
+// bloquearla o una nueva. Una habitación ocupada no cambia; se bloquea en su check-out.
+// Devuelve si la habitación quedó bloqueada.
+func syncMaintenanceBlock(sc mongo.SessionContext, db *mongo.Database, roomID primitive.ObjectID, user string) (bool, error) {
+	blocking, err := db.Collection(constants.CollectionMaintenance).CountDocuments(sc, bson.M{
+		"roomId":   roomID,
+		"blocking": true,
+		"status":   bson.M{"$ne": models.MaintenanceClosed},
+	})
+	if err != nil {
+		return false, fmt.Errorf("unable to count blocking maintenance tickets: %v", err)
+	}
+
+	var room models.Room
+	if err := db.Collection(constants.CollectionRooms).FindOne(sc, bson.M{"_id": roomID}).Decode(&room); err != nil {
+		return false, fmt.Errorf("unable to get room: %v", err)
+	}
+	if room.OccupantID != nil || room.Status == models.RoomStatusOccupied {
+		return blocking > 0, nil
+	}
+
+	switch {
+	case blocking > 0 && room.Status != models.RoomStatusOutOfOrder:
+		_, err = transitionRoomStatus(sc, db, bson.M{"_id": roomID}, models.RoomStatusOutOfOrder, user, "maintenance ticket")
+	case blocking == 0 && room.Status == models.RoomStatusOutOfOrder:
+		var released *models.Room
+		released, err = transitionRoomStatus(sc, db, bson.M{"_id": roomID}, models.RoomStatusDirty, user, "maintenance tickets closed")
+		if err == nil {
+			_, err = createCleaningTask(sc, db, *released, "")
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return blocking > 0, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMaintenanceBlockResetsCleaning(t *testing.T) {
+	client := testMongoClient(t)
+	ctx := context.Background()
+	housekeeping := NewHousekeepingService(client)
+	maintenance := NewMaintenanceService(client)
+	db := client.Database(constants.MongoDBDatabase)
+	const user = "camarista@hotel.test"
+
+	tests := []struct {
+		name       string
+		number     string
+		finished   bool   // Si la limpieza se terminó antes de reportar la falla
+		roomBefore string // Estado de la habitación al reportar la falla
+	}{
+		{name: "limpieza en curso", number: "T-101", roomBefore: models.RoomStatusCleaning},
+		{name: "limpieza pendiente de inspección", number: "T-102", finished: true, roomBefore: models.RoomStatusInspected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := models.Room{
+				ID:         primitive.NewObjectID(),
+				RoomType:   models.RoomTypeGuest,
+				RoomNumber: tt.number,
+				Status:     models.RoomStatusDirty,
+			}
+			if _, err := db.Collection(constants.CollectionRooms).InsertOne(ctx, room); err != nil {
+				t.Fatalf("no se pudo crear la habitación: %v", err)
+			}
+			ref := RoomRef{ID: &room.ID}
+			roomStatus := func() string {
+				var current models.Room
+				if err := db.Collection(constants.CollectionRooms).FindOne(ctx, bson.M{"_id": room.ID}).Decode(&current); err != nil {
+					t.Fatalf("no se pudo leer la habitación: %v", err)
+				}
+				return current.Status
+			}
+
+			task, err := housekeeping.Create(ctx, ref, "")
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := housekeeping.Start(ctx, task.ID, user); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			if tt.finished {
+				if _, err := housekeeping.Finish(ctx, task.ID, user, ""); err != nil {
+					t.Fatalf("Finish: %v", err)
+				}
+			}
+			if status := roomStatus(); status != tt.roomBefore {
+				t.Fatalf("habitación en %s antes de la falla, se esperaba %s", status, tt.roomBefore)
+			}
+
+			// Falla bloqueante: la habitación sale de servicio y la limpieza vuelve a empezar
+			ticket := &models.MaintenanceTicket{Title: "Fuga", Priority: models.MaintenancePriorityHigh, Blocking: true, ReportedBy: user}
+			if err := maintenance.Create(ctx, ref, ticket); err != nil {
+				t.Fatalf("Create ticket: %v", err)
+			}
+			if status := roomStatus(); status != models.RoomStatusOutOfOrder {
+				t.Fatalf("habitación en %s con la falla abierta, se esperaba out-of-order", status)
+			}
+			if _, err := housekeeping.Start(ctx, task.ID, user); err == nil {
+				t.Fatalf("se pudo empezar la limpieza de una habitación fuera de servicio")
+			}
+
+			// Al cerrar la falla queda una sola tarea pendiente, la misma de antes y con la misma asignación
+			if _, err := maintenance.Close(ctx, ticket.ID, "Reparada", user); err != nil {
+				t.Fatalf("Close ticket: %v", err)
+			}
+			if status := roomStatus(); status != models.RoomStatusDirty {
+				t.Fatalf("habitación en %s al cerrar la falla, se esperaba dirty", status)
+			}
+			tasks, err := housekeeping.List(ctx, bson.M{"roomId": room.ID, "status": bson.M{"$in": openHousekeepingStatuses}})
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(tasks) != 1 || tasks[0].ID != task.ID || tasks[0].Status != models.HousekeepingPending || tasks[0].AssignedTo != user {
+				t.Fatalf("tareas abiertas = %+v, se esperaba solo la tarea original pendiente", tasks)
+			}
+
+			if _, err := housekeeping.Start(ctx, task.ID, user); err != nil {
+				t.Fatalf("Start tras liberar: %v", err)
+			}
+			if _, err := housekeeping.Finish(ctx, task.ID, user, ""); err != nil {
+				t.Fatalf("Finish tras liberar: %v", err)
+			}
+			if _, err := housekeeping.Inspect(ctx, task.ID, true, "supervisor@hotel.test", ""); err != nil {
+				t.Fatalf("Inspect tras liberar: %v", err)
+			}
+			if status := roomStatus(); status != models.RoomStatusAvailable {
+				t.Errorf("habitación en %s tras la inspección, se esperaba available", status)
+			}
+		})
+	}
+}
+
+func TestRoomStatusChangeKeepsBlockedRoomOutOfService(t *testing.T) {
+	client := testMongoClient(t)
+	ctx := context.Background()
+	maintenance := NewMaintenanceService(client)
+	statuses := NewRoomStatusService(client)
+	const user = "recepcion@hotel.test"
+
+	room := models.Room{ID: primitive.NewObjectID(), RoomType: models.RoomTypeGuest, RoomNumber: "T-201", Status: models.RoomStatusAvailable}
+	if _, err := client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms).InsertOne(ctx, room); err != nil {
+		t.Fatalf("no se pudo crear la habitación: %v", err)
+	}
+	ref := RoomRef{ID: &room.ID}
+
+	tickets := make([]*models.MaintenanceTicket, 2)
+	for i := range tickets {
+		tickets[i] = &models.MaintenanceTicket{Title: "Falla", Priority: models.MaintenancePriorityHigh, Blocking: true, ReportedBy: user}
+		if err := maintenance.Create(ctx, ref, tickets[i]); err != nil {
+			t.Fatalf("Create ticket: %v", err)
+		}
+	}
+
+	// Entre estados fuera de servicio sí se puede mover, pero no regresar al servicio
+	if _, err := statuses.Change(ctx, ref, models.RoomStatusMaintenance, user, ""); err != nil {
+		t.Fatalf("Change a maintenance: %v", err)
+	}
+	if _, err := statuses.Change(ctx, ref, models.RoomStatusDirty, user, ""); err != ErrRoomBlocked {
+		t.Fatalf("Change a dirty con tickets abiertos: error %v, se esperaba ErrRoomBlocked", err)
+	}
+
+	if _, err := maintenance.Close(ctx, tickets[0].ID, "Reparada", user); err != nil {
+		t.Fatalf("Close ticket: %v", err)
+	}
+	if _, err := statuses.Change(ctx, ref, models.RoomStatusDirty, user, ""); err != ErrRoomBlocked {
+		t.Fatalf("Change a dirty con un ticket abierto: error %v, se esperaba ErrRoomBlocked", err)
+	}
+
+	if _, err := maintenance.Close(ctx, tickets[1].ID, "Reparada", user); err != nil {
+		t.Fatalf("Close ticket: %v", err)
+	}
+	if _, err := statuses.Change(ctx, ref, models.RoomStatusDirty, user, ""); err != nil {
+		t.Errorf("Change a dirty sin tickets abiertos: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"hotelman-backend/constants"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoClient se conecta a la base de pruebas indicada en HOTELMAN_TEST_MONGODB_URI, que debe ser un replica
+// set porque los servicios usan transacciones. Cada prueba usa una base de datos propia que se elimina al terminar.
+func testMongoClient(t *testing.T) *mongo.Client {
+	t.Helper()
+	uri := os.Getenv("HOTELMAN_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("HOTELMAN_TEST_MONGODB_URI no está definida")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("no se pudo conectar a MongoDB: %v", err)
+	}
+
+	previous := constants.MongoDBDatabase
+	constants.MongoDBDatabase = "hotelman_test_" + primitive.NewObjectID().Hex()
+	t.Cleanup(func() {
+		client.Database(constants.MongoDBDatabase).Drop(context.Background())
+		constants.MongoDBDatabase = previous
+		client.Disconnect(context.Background())
+	})
+	return client
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OverdueService detecta las rentas vencidas, les aplica recargos y mantiene el estado de los inquilinos
+type OverdueService struct {
+	Client *mongo.Client
+}
+
+// NewOverdueService crea una nueva instancia de OverdueService
+func NewOverdueService(client *mongo.Client) *OverdueService {
+	return &OverdueService{Client: client}
+}
+
+func (s *OverdueService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *OverdueService) charges() *mongo.Collection {
+	return s.db().Collection(constants.CollectionCharges)
+}
+
+// Start revisa los cargos cada interval en segundo plano hasta que se cancele ctx
+func (s *OverdueService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if marked, err := s.Scan(ctx, time.Now()); err != nil {
+				log.Printf("Error al revisar rentas vencidas: %v", err)
+			} else if marked > 0 {
+				log.Printf("%d rentas marcadas como vencidas", marked)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Scan marca como vencidas las rentas pendientes cuyo periodo de gracia terminó antes de now, les agrega su
+// recargo y actualiza el estado de los inquilinos. Devuelve cuántas rentas marcó.
+func (s *OverdueService) Scan(ctx context.Context, now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, -constants.RentGraceDays)
+	cursor, err := s.charges().Find(ctx, bson.M{
+		"kind":    models.ChargeRent,
+		"status":  models.ChargePending,
+		"dueDate": bson.M{"$lt": cutoff},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find due charges: %v", err)
+	}
+	var due []models.Charge
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, fmt.Errorf("unable to decode charges: %v", err)
+	}
+
+	marked := 0
+	for _, charge := range due {
+		ok, err := s.markOverdue(ctx, charge, now)
+		if err != nil {
+			return marked, err
+		}
+		if ok {
+			marked++
+		}
+	}
+
+	if err := s.syncRentalEstados(ctx, now); err != nil {
+		return marked, err
+	}
+	return marked, nil
+}
+
+// markOverdue marca la renta como vencida y le agrega un recargo, salvo que ya lo tenga. Devuelve false si la
+// renta dejó de estar pendiente antes de marcarla.
+func (s *OverdueService) markOverdue(ctx context.Context, charge models.Charge, now time.Time) (bool, error) {
+	var marked bool
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		marked = false
+		result, err := s.charges().UpdateOne(sc,
+			bson.M{"_id": charge.ID, "status": models.ChargePending},
+			bson.M{"$set": bson.M{"status": models.ChargeOverdue, "updatedAt": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to update charge: %v", err)
+		}
+		if result.ModifiedCount == 0 {
+			return nil
+		}
+		marked = true
+
+		fee := lateFee(charge.Amount)
+		if fee <= 0 {
+			return nil
+		}
+		// Una renta que se pagó, se anuló el pago y volvió a vencer conserva su recargo original
+		count, err := s.charges().CountDocuments(sc, bson.M{"kind": models.ChargeLateFee, "relatedTo": charge.ID})
+		if err != nil {
+			return fmt.Errorf("unable to look up late fee: %v", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		_, err = s.charges().InsertOne(sc, models.Charge{
+			ID:          primitive.NewObjectID(),
+			ClientID:    charge.ClientID,
+			LeaseID:     charge.LeaseID,
+			RelatedTo:   &charge.ID,
+			Kind:        models.ChargeLateFee,
+			Description: "Recargo: " + charge.Description,
+			DueDate:     now,
+			Amount:      fee,
+			Status:      models.ChargePending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create late fee: %v", err)
+		}
+		return nil
+	})
+	return marked, err
+}
+
+// syncRentalEstados marca como atrasados a los inquilinos activos con rentas vencidas y devuelve a activos a
+// los atrasados que ya no tienen ninguna
+func (s *OverdueService) syncRentalEstados(ctx context.Context, now time.Time) error {
+	late, err := s.charges().Distinct(ctx, "clientId", bson.M{"kind": models.ChargeRent, "status": models.ChargeOverdue})
+	if err != nil {
+		return fmt.Errorf("unable to find overdue tenants: %v", err)
+	}
+
+	rentals := s.db().Collection(constants.CollectionClients)
+	_, err = rentals.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": late}, "estado": models.RentalEstadoActivo},
+		bson.M{"$set": bson.M{"estado": models.RentalEstadoAtrasado, "updatedAt": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update overdue tenants: %v", err)
+	}
+	_, err = rentals.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$nin": late}, "estado": models.RentalEstadoAtrasado},
+		bson.M{"$set": bson.M{"estado": models.RentalEstadoActivo, "updatedAt": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update tenants: %v", err)
+	}
+	return nil
+}
+
+// Overdue devuelve los inquilinos con rentas vencidas, primero los de mayor atraso y, a igual atraso, los que
+// más deben
+func (s *OverdueService) Overdue(ctx context.Context, now time.Time) ([]models.OverdueTenant, error) {
+	cursor, err := s.charges().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": []bson.M{
+			{"kind": models.ChargeRent, "status": models.ChargeOverdue},
+			{"kind": models.ChargeLateFee, "status": models.ChargePending},
+		}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$clientId",
+			"amountOwed":     bson.M{"$sum": bson.M{"$subtract": bson.A{"$amount", "$paid"}}},
+			"overdueCharges": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$kind", models.ChargeRent}}, 1, 0}}},
+			"oldestDueDate":  bson.M{"$min": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$kind", models.ChargeRent}}, "$dueDate", nil}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"overdueCharges": bson.M{"$gt": 0}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         constants.CollectionClients,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "rental",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$rental", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$set", Value: bson.M{
+			"nombres":    "$rental.nombres",
+			"apellidos":  "$rental.apellidos",
+			"roomNumber": "$rental.RoomNumber",
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to aggregate overdue charges: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	tenants := []models.OverdueTenant{}
+	if err := cursor.All(ctx, &tenants); err != nil {
+		return nil, fmt.Errorf("unable to decode overdue tenants: %v", err)
+	}
+	for i := range tenants {
+		tenants[i].AmountOwed = roundMoney(tenants[i].AmountOwed)
+		tenants[i].DaysLate = int(now.Sub(tenants[i].OldestDueDate).Hours() / 24)
+	}
+	sort.SliceStable(tenants, func(i, j int) bool {
+		if tenants[i].DaysLate != tenants[j].DaysLate {
+			return tenants[i].DaysLate > tenants[j].DaysLate
+		}
+		return tenants[i].AmountOwed > tenants[j].AmountOwed
+	})
+	return tenants, nil
+}
+
+// lateFee calcula el recargo de una renta vencida: el monto fijo más el porcentaje configurado de la renta
+func lateFee(amount float64) float64 {
+	return roundMoney(constants.LateFeeFixed + amount*constants.LateFeePercent/100)
+}
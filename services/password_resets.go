@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PasswordResetTTL es la vigencia de un token de restablecimiento de contraseña
+const PasswordResetTTL = 30 * time.Minute
+
+// ErrPasswordResetInvalid indica que el token no existe, ya se usó o expiró
+var ErrPasswordResetInvalid = errors.New("password reset token is invalid or expired")
+
+// PasswordResetStore maneja los tokens de restablecimiento de contraseña guardados como hash en MongoDB
+type PasswordResetStore struct {
+	Client *mongo.Client
+}
+
+// NewPasswordResetStore crea una nueva instancia de PasswordResetStore
+func NewPasswordResetStore(client *mongo.Client) *PasswordResetStore {
+	return &PasswordResetStore{Client: client}
+}
+
+func (s *PasswordResetStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionPasswordResets)
+}
+
+// Create invalida los tokens pendientes del usuario y genera uno nuevo; devuelve su valor en claro
+func (s *PasswordResetStore) Create(ctx context.Context, correo, ip string) (string, error) {
+	now := time.Now()
+	_, err := s.collection().UpdateMany(ctx,
+		bson.M{"correo": correo, "used": false},
+		bson.M{"$set": bson.M{"used": true, "usedAt": now}},
+	)
+	if err != nil {
+		return "", fmt.Errorf("unable to invalidate previous reset tokens: %v", err)
+	}
+
+	plain, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := models.PasswordReset{
+		TokenHash: hashOpaqueToken(plain),
+		Correo:    correo,
+		IP:        ip,
+		CreatedAt: now,
+		ExpiresAt: now.Add(PasswordResetTTL),
+	}
+	if _, err := s.collection().InsertOne(ctx, record); err != nil {
+		return "", fmt.Errorf("unable to store reset token: %v", err)
+	}
+	return plain, nil
+}
+
+// Consume marca el token como usado de forma atómica y devuelve el registro correspondiente
+func (s *PasswordResetStore) Consume(ctx context.Context, plain string) (*models.PasswordReset, error) {
+	if plain == "" {
+		return nil, ErrPasswordResetInvalid
+	}
+
+	now := time.Now()
+	var record models.PasswordReset
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": hashOpaqueToken(plain),
+			"used":      false,
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used": true, "usedAt": now}},
+	).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPasswordResetInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to consume reset token: %v", err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrPaymentNotFound indica que no existe el pago
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentVoided indica que el pago ya fue anulado
+	ErrPaymentVoided = errors.New("payment is already voided")
+	// ErrPaymentInvoiced indica que el pago está incluido en una factura timbrada
+	ErrPaymentInvoiced = errors.New("payment is included in an invoice")
+	// ErrChargeNotFound indica que no existe el cargo o no pertenece al cliente
+	ErrChargeNotFound = errors.New("charge not found")
+	// ErrChargeClosed indica que el cargo ya está cubierto o anulado
+	ErrChargeClosed = errors.New("charge is already paid or cancelled")
+	// ErrPaymentExceedsCharge indica que el pago es mayor que lo que falta por cubrir del cargo
+	ErrPaymentExceedsCharge = errors.New("payment exceeds the outstanding amount of the charge")
+)
+
+// openChargeStatuses son los estados de un cargo que todavía se puede pagar
+var openChargeStatuses = []string{models.ChargePending, models.ChargeOverdue}
+
+// PaymentService maneja el libro de pagos y los cargos de los clientes
+type PaymentService struct {
+	Client *mongo.Client
+}
+
+// NewPaymentService crea una nueva instancia de PaymentService
+func NewPaymentService(client *mongo.Client) *PaymentService {
+	return &PaymentService{Client: client}
+}
+
+func (s *PaymentService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *PaymentService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionPayments)
+}
+
+func (s *PaymentService) charges() *mongo.Collection {
+	return s.db().Collection(constants.CollectionCharges)
+}
+
+// CreateCharge guarda un cargo pendiente para el cliente, p. ej. el precio de la estancia de un huésped
+func (s *PaymentService) CreateCharge(ctx context.Context, charge *models.Charge) error {
+	now := time.Now()
+	charge.ID = primitive.NewObjectID()
+	charge.Paid = 0
+	charge.Status = models.ChargePending
+	charge.CreatedAt = now
+	charge.UpdatedAt = now
+	if _, err := s.charges().InsertOne(ctx, charge); err != nil {
+		return fmt.Errorf("unable to create charge: %v", err)
+	}
+	return nil
+}
+
+// CreateGuestWithStayCharge guarda el huésped y, si tiene precio, el cargo de su estancia en una sola transacción,
+// para que un error al crear el cargo no deje un huésped sin saldo que se duplique al reintentar
+func (s *PaymentService) CreateGuestWithStayCharge(ctx context.Context, guest *models.Guest) error {
+	return runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		if _, err := s.db().Collection(constants.CollectionClients).InsertOne(sc, guest); err != nil {
+			return fmt.Errorf("unable to create guest: %v", err)
+		}
+		if guest.Price <= 0 {
+			return nil
+		}
+		return s.CreateCharge(sc, &models.Charge{
+			ClientID:    guest.ID,
+			Kind:        models.ChargeStay,
+			Description: fmt.Sprintf("Estancia habitación %s", guest.RoomNumber),
+			DueDate:     guest.CreatedAt,
+			Amount:      guest.Price,
+		})
+	})
+}
+
+// Register guarda el pago. Si está ligado a un cargo lo abona y, al cubrirlo por completo, lo marca como pagado.
+// Los pagos en efectivo se atribuyen al turno de caja abierto de quien los recibe; sin turno abierto se rechazan.
+func (s *PaymentService) Register(ctx context.Context, payment *models.Payment) error {
+	return runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		count, err := s.db().Collection(constants.CollectionClients).CountDocuments(sc, bson.M{"_id": payment.ClientID})
+		if err != nil {
+			return fmt.Errorf("unable to look up client: %v", err)
+		}
+		if count == 0 {
+			return ErrClientNotFound
+		}
+
+		if payment.ChargeID != nil {
+			var charge models.Charge
+			err := s.charges().FindOne(sc, bson.M{"_id": *payment.ChargeID, "clientId": payment.ClientID}).Decode(&charge)
+			if err == mongo.ErrNoDocuments {
+				return ErrChargeNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("unable to get charge: %v", err)
+			}
+			if !containsStatus(openChargeStatuses, charge.Status) {
+				return ErrChargeClosed
+			}
+			paid := roundMoney(charge.Paid + payment.Amount)
+			if paid > charge.Amount {
+				return ErrPaymentExceedsCharge
+			}
+
+			set := bson.M{"paid": paid, "updatedAt": time.Now()}
+			if paid == charge.Amount {
+				set["status"] = models.ChargePaid
+			}
+			if _, err := s.charges().UpdateOne(sc, bson.M{"_id": charge.ID}, bson.M{"$set": set}); err != nil {
+				return fmt.Errorf("unable to update charge: %v", err)
+			}
+		}
+
+		if payment.Method == models.PaymentCash {
+			shift, err := openShift(sc, s.db().Collection(constants.CollectionShifts), payment.ReceivedBy)
+			if err != nil {
+				return err
+			}
+			// Escribir en el turno hace que un cierre concurrente choque con esta transacción en lugar de
+			// calcular el corte sin este pago
+			result, err := s.db().Collection(constants.CollectionShifts).UpdateOne(sc,
+				bson.M{"_id": shift.ID, "status": models.ShiftOpen},
+				bson.M{"$inc": bson.M{"cashReceived": payment.Amount}},
+			)
+			if err != nil {
+				return fmt.Errorf("unable to update shift: %v", err)
+			}
+			if result.MatchedCount == 0 {
+				return ErrNoOpenShift
+			}
+			payment.ShiftID = &shift.ID
+		}
+
+		payment.ID = primitive.NewObjectID()
+		payment.Status = models.PaymentValid
+		payment.ReceivedAt = time.Now()
+		if _, err := s.collection().InsertOne(sc, payment); err != nil {
+			return fmt.Errorf("unable to register payment: %v", err)
+		}
+		return nil
+	})
+}
+
+// Void anula el pago y, si estaba ligado a un cargo, le descuenta el abono y lo vuelve a dejar pendiente.
+// Los pagos facturados no se pueden anular.
+func (s *PaymentService) Void(ctx context.Context, id primitive.ObjectID, reason, user string) (*models.Payment, error) {
+	var payment models.Payment
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		now := time.Now()
+		err := s.collection().FindOneAndUpdate(sc,
+			bson.M{"_id": id, "status": models.PaymentValid, "invoiceId": bson.M{"$exists": false}},
+			bson.M{
+				"$set": bson.M{
+					"status":     models.PaymentVoided,
+					"voidedBy":   user,
+					"voidedAt":   now,
+					"voidReason": reason,
+				},
+				// El recibo guardado ya no refleja el pago; el siguiente se guarda como anulado
+				"$unset": bson.M{"receiptUrl": ""},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&payment)
+		if err == mongo.ErrNoDocuments {
+			err := s.collection().FindOne(sc, bson.M{"_id": id}).Decode(&payment)
+			if err == mongo.ErrNoDocuments {
+				return ErrPaymentNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("unable to get payment: %v", err)
+			}
+			if payment.Status == models.PaymentVoided {
+				return ErrPaymentVoided
+			}
+			return ErrPaymentInvoiced
+		}
+		if err != nil {
+			return fmt.Errorf("unable to void payment: %v", err)
+		}
+
+		// En un turno cerrado el corte guardado no cambia; en uno abierto se descuenta del efectivo recibido
+		if payment.ShiftID != nil {
+			_, err := s.db().Collection(constants.CollectionShifts).UpdateOne(sc,
+				bson.M{"_id": *payment.ShiftID, "status": models.ShiftOpen},
+				bson.M{"$inc": bson.M{"cashReceived": -payment.Amount}},
+			)
+			if err != nil {
+				return fmt.Errorf("unable to update shift: %v", err)
+			}
+		}
+
+		if payment.ChargeID == nil {
+			return nil
+		}
+		var charge models.Charge
+		if err := s.charges().FindOne(sc, bson.M{"_id": *payment.ChargeID}).Decode(&charge); err != nil {
+			return fmt.Errorf("unable to get charge: %v", err)
+		}
+		set := bson.M{"paid": roundMoney(charge.Paid - payment.Amount), "updatedAt": now}
+		if charge.Status == models.ChargePaid {
+			set["status"] = models.ChargePending
+		}
+		if _, err := s.charges().UpdateOne(sc, bson.M{"_id": charge.ID}, bson.M{"$set": set}); err != nil {
+			return fmt.Errorf("unable to update charge: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// List devuelve una página de pagos que cumplen el filtro, del más reciente al más antiguo, y el total
+func (s *PaymentService) List(ctx context.Context, filter bson.M, page, pageSize int) ([]models.Payment, int64, error) {
+	total, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count payments: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "receivedAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list payments: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	payments := []models.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode payments: %v", err)
+	}
+	return payments, total, nil
+}
+
+// Balance calcula el saldo del cliente como la suma de sus cargos no anulados menos la de sus pagos vigentes
+func (s *PaymentService) Balance(ctx context.Context, clientID primitive.ObjectID) (*models.Balance, error) {
+	count, err := s.db().Collection(constants.CollectionClients).CountDocuments(ctx, bson.M{"_id": clientID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up client: %v", err)
+	}
+	if count == 0 {
+		return nil, ErrClientNotFound
+	}
+
+	balance := &models.Balance{ClientID: clientID, PendingCharges: []models.Charge{}}
+
+	balance.Charged, err = sumAmounts(ctx, s.charges(), bson.M{"clientId": clientID, "status": bson.M{"$ne": models.ChargeCancelled}})
+	if err != nil {
+		return nil, err
+	}
+	balance.Paid, err = sumAmounts(ctx, s.collection(), bson.M{"clientId": clientID, "status": models.PaymentValid})
+	if err != nil {
+		return nil, err
+	}
+	balance.Balance = roundMoney(balance.Charged - balance.Paid)
+
+	cursor, err := s.charges().Find(ctx,
+		bson.M{"clientId": clientID, "status": bson.M{"$in": openChargeStatuses}},
+		options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list charges: %v", err)
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &balance.PendingCharges); err != nil {
+		return nil, fmt.Errorf("unable to decode charges: %v", err)
+	}
+	return balance, nil
+}
+
+// sumAmounts suma el campo amount de los documentos de la colección que cumplen el filtro
+func sumAmounts(ctx context.Context, collection *mongo.Collection, filter bson.M) (float64, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to sum amounts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, fmt.Errorf("unable to decode amounts: %v", err)
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return roundMoney(result[0].Total), nil
+}
+
+// containsStatus indica si el estado está en la lista
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
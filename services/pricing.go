@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrRatePlanNotFound indica que no existe el plan de tarifa
+	ErrRatePlanNotFound = errors.New("rate plan not found")
+	// ErrNoRatePlan indica que ningún plan ni la tarifa base de la habitación cubren la estancia
+	ErrNoRatePlan = errors.New("no rate plan applies to this stay")
+	// ErrQuoteDuration indica que la duración o su unidad no son válidas
+	ErrQuoteDuration = errors.New("invalid stay duration")
+)
+
+// PricingService maneja los planes de tarifa y calcula el precio de las estancias de huéspedes
+type PricingService struct {
+	Client *mongo.Client
+}
+
+// NewPricingService crea una nueva instancia de PricingService
+func NewPricingService(client *mongo.Client) *PricingService {
+	return &PricingService{Client: client}
+}
+
+func (s *PricingService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *PricingService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionRatePlans)
+}
+
+// ListPlans devuelve los planes que cumplen el filtro ordenados por tipo y nombre
+func (s *PricingService) ListPlans(ctx context.Context, filter bson.M) ([]models.RatePlan, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "kind", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rate plans: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	plans := []models.RatePlan{}
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, fmt.Errorf("unable to decode rate plans: %v", err)
+	}
+	return plans, nil
+}
+
+// CreatePlan guarda un nuevo plan de tarifa
+func (s *PricingService) CreatePlan(ctx context.Context, plan *models.RatePlan) error {
+	plan.ID = primitive.NewObjectID()
+	plan.CreatedAt = time.Now()
+	plan.UpdatedAt = plan.CreatedAt
+	if _, err := s.collection().InsertOne(ctx, plan); err != nil {
+		return fmt.Errorf("unable to create rate plan: %v", err)
+	}
+	return nil
+}
+
+// UpdatePlan reemplaza la definición del plan conservando quién y cuándo lo creó
+func (s *PricingService) UpdatePlan(ctx context.Context, id primitive.ObjectID, plan *models.RatePlan) error {
+	var current models.RatePlan
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		return ErrRatePlanNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get rate plan: %v", err)
+	}
+
+	plan.ID = id
+	plan.CreatedBy = current.CreatedBy
+	plan.CreatedAt = current.CreatedAt
+	plan.UpdatedAt = time.Now()
+	if _, err := s.collection().ReplaceOne(ctx, bson.M{"_id": id}, plan); err != nil {
+		return fmt.Errorf("unable to update rate plan: %v", err)
+	}
+	return nil
+}
+
+// DeletePlan elimina el plan; las estancias ya cotizadas conservan su nombre y tipo
+func (s *PricingService) DeletePlan(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.collection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("unable to delete rate plan: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrRatePlanNotFound
+	}
+	return nil
+}
+
+// Quote calcula el precio de una estancia en la habitación a partir del día start (UTC).
+// Por horas se usa el plan hourly. Por noches cada noche toma, en orden de prioridad, el plan seasonal que
+// la cubra, el weekend si es viernes o sábado, el nightly o la tarifa base de la habitación; al subtotal se
+// le aplica el mayor descuento longStay cuyo mínimo de noches se cumpla.
+func (s *PricingService) Quote(ctx context.Context, roomNumber, unit string, duration int, start time.Time) (*models.Quote, error) {
+	if duration <= 0 || (unit != models.DurationHours && unit != models.DurationNights) {
+		return nil, ErrQuoteDuration
+	}
+
+	var room models.Room
+	err := s.db().Collection(constants.CollectionRooms).FindOne(ctx, RoomRef{Number: roomNumber}.filter()).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get room: %v", err)
+	}
+
+	plans, err := s.plansFor(ctx, room.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	quote := &models.Quote{
+		RoomNumber: room.RoomNumber,
+		Category:   room.Category,
+		Unit:       unit,
+		Duration:   duration,
+		Start:      start,
+		Lines:      []models.QuoteLine{},
+		RatePlans:  []models.AppliedRatePlan{},
+	}
+
+	if unit == models.DurationHours {
+		plan := pickRatePlan(plans, models.RatePlanHourly, start)
+		if plan == nil {
+			return nil, ErrNoRatePlan
+		}
+		addQuoteLine(quote, start, duration, plan.Rate, plan)
+	} else {
+		for i := 0; i < duration; i++ {
+			night := start.AddDate(0, 0, i)
+			plan := pickRatePlan(plans, models.RatePlanSeasonal, night)
+			if plan == nil && (night.Weekday() == time.Friday || night.Weekday() == time.Saturday) {
+				plan = pickRatePlan(plans, models.RatePlanWeekend, night)
+			}
+			if plan == nil {
+				plan = pickRatePlan(plans, models.RatePlanNightly, night)
+			}
+			switch {
+			case plan != nil:
+				addQuoteLine(quote, night, 1, plan.Rate, plan)
+			case room.BaseNightlyRate > 0:
+				addQuoteLine(quote, night, 1, room.BaseNightlyRate, nil)
+				quote.BaseRateUsed = true
+			default:
+				return nil, ErrNoRatePlan
+			}
+		}
+
+		var discount *models.RatePlan
+		for i := range plans {
+			plan := &plans[i]
+			if plan.Kind == models.RatePlanLongStay && duration >= plan.MinNights &&
+				(discount == nil || plan.DiscountPercent > discount.DiscountPercent) {
+				discount = plan
+			}
+		}
+		if discount != nil {
+			quote.Discount = roundMoney(quote.Subtotal * discount.DiscountPercent / 100)
+			applyRatePlan(quote, discount)
+		}
+	}
+
+	quote.Total = roundMoney(quote.Subtotal - quote.Discount)
+	return quote, nil
+}
+
+// plansFor devuelve los planes activos generales y los de la categoría, con los de la categoría primero
+func (s *PricingService) plansFor(ctx context.Context, category string) ([]models.RatePlan, error) {
+	categories := []string{""}
+	if category != "" {
+		categories = append(categories, category)
+	}
+	plans, err := s.ListPlans(ctx, bson.M{
+		"active": true,
+		"$or": bson.A{
+			bson.M{"category": bson.M{"$in": categories}},
+			bson.M{"category": bson.M{"$exists": false}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].Category != "" && plans[j].Category == ""
+	})
+	return plans, nil
+}
+
+// pickRatePlan devuelve el primer plan del tipo indicado que aplica al día; los seasonal solo si lo cubren
+func pickRatePlan(plans []models.RatePlan, kind string, day time.Time) *models.RatePlan {
+	for i := range plans {
+		plan := &plans[i]
+		if plan.Kind != kind {
+			continue
+		}
+		if kind == models.RatePlanSeasonal &&
+			(plan.StartDate == nil || plan.EndDate == nil || day.Before(*plan.StartDate) || day.After(*plan.EndDate)) {
+			continue
+		}
+		return plan
+	}
+	return nil
+}
+
+// addQuoteLine agrega una línea a la cotización y suma su importe al subtotal
+func addQuoteLine(quote *models.Quote, date time.Time, quantity int, rate float64, plan *models.RatePlan) {
+	line := models.QuoteLine{Date: date, Quantity: quantity, Rate: rate, Amount: roundMoney(rate * float64(quantity))}
+	if plan != nil {
+		line.RatePlan = applyRatePlan(quote, plan)
+	}
+	quote.Lines = append(quote.Lines, line)
+	quote.Subtotal = roundMoney(quote.Subtotal + line.Amount)
+}
+
+// applyRatePlan registra el plan entre los usados por la cotización, sin repetirlo
+func applyRatePlan(quote *models.Quote, plan *models.RatePlan) *models.AppliedRatePlan {
+	applied := models.AppliedRatePlan{ID: plan.ID, Name: plan.Name, Kind: plan.Kind}
+	for _, existing := range quote.RatePlans {
+		if existing.ID == plan.ID {
+			return &applied
+		}
+	}
+	quote.RatePlans = append(quote.RatePlans, applied)
+	return &applied
+}
+
+// roundMoney redondea un importe a centavos
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
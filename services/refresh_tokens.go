@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrRefreshTokenInvalid indica que el refresh token no existe, expiró o fue revocado
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused indica que se presentó un refresh token ya rotado; la familia completa queda revocada
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenStore emite, rota y revoca refresh tokens guardados como hash en MongoDB
+type RefreshTokenStore struct {
+	Client *mongo.Client
+}
+
+// NewRefreshTokenStore crea una nueva instancia de RefreshTokenStore
+func NewRefreshTokenStore(client *mongo.Client) *RefreshTokenStore {
+	return &RefreshTokenStore{Client: client}
+}
+
+func (s *RefreshTokenStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRefreshTokens)
+}
+
+// Issue genera un nuevo refresh token para la familia dada y devuelve su valor en claro
+func (s *RefreshTokenStore) Issue(ctx context.Context, familyID, username, role string, expiresAt time.Time) (string, error) {
+	plain, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := models.RefreshToken{
+		TokenHash: hashOpaqueToken(plain),
+		FamilyID:  familyID,
+		Username:  username,
+		Role:      role,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if _, err := s.collection().InsertOne(ctx, record); err != nil {
+		return "", fmt.Errorf("unable to store refresh token: %v", err)
+	}
+	return plain, nil
+}
+
+// Rotate consume el refresh token presentado y emite uno nuevo de la misma familia.
+// Si el token ya había sido usado se revoca toda la familia y se devuelve ErrRefreshTokenReused
+// junto con el registro, para que el llamador pueda revocar también la sesión.
+func (s *RefreshTokenStore) Rotate(ctx context.Context, plain string) (*models.RefreshToken, string, error) {
+	current, err := s.Find(ctx, plain)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if current.Used || current.Revoked {
+		if err := s.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return current, "", ErrRefreshTokenReused
+	}
+
+	if !current.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	// Marcar como usado de forma atómica; si otra solicitud lo consumió primero se trata como reutilización
+	now := time.Now()
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"_id": current.ID, "used": false, "revoked": false},
+		bson.M{"$set": bson.M{"used": true, "usedAt": now}},
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to rotate refresh token: %v", err)
+	}
+	if result.ModifiedCount == 0 {
+		if err := s.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return current, "", ErrRefreshTokenReused
+	}
+
+	next, err := s.Issue(ctx, current.FamilyID, current.Username, current.Role, current.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return current, next, nil
+}
+
+// Find busca el registro correspondiente a un refresh token en claro
+func (s *RefreshTokenStore) Find(ctx context.Context, plain string) (*models.RefreshToken, error) {
+	if plain == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var record models.RefreshToken
+	err := s.collection().FindOne(ctx, bson.M{"tokenHash": hashOpaqueToken(plain)}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to find refresh token: %v", err)
+	}
+	return &record, nil
+}
+
+// RevokeFamily revoca todos los refresh tokens de una familia
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := s.collection().UpdateMany(ctx,
+		bson.M{"familyId": familyID, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to revoke refresh token family: %v", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser revoca todos los refresh tokens de un usuario
+func (s *RefreshTokenStore) RevokeAllForUser(ctx context.Context, username string) error {
+	_, err := s.collection().UpdateMany(ctx,
+		bson.M{"username": username, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to revoke refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// RevokeOthersForUser revoca los refresh tokens del usuario excepto los de la familia indicada
+func (s *RefreshTokenStore) RevokeOthersForUser(ctx context.Context, username, keepFamilyID string) error {
+	_, err := s.collection().UpdateMany(ctx,
+		bson.M{"username": username, "revoked": false, "familyId": bson.M{"$ne": keepFamilyID}},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to revoke refresh tokens: %v", err)
+	}
+	return nil
+}
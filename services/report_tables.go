@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reportTable es el contenido de un reporte antes de exportarlo; las celdas son string, int o float64
+type reportTable struct {
+	Title  string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// reportMethods es el orden de las columnas de métodos de pago en el reporte de ingresos
+var reportMethods = []string{models.PaymentCash, models.PaymentCard, models.PaymentTransfer}
+
+// durationUnitLabels traduce la unidad de la estancia de un huésped
+var durationUnitLabels = map[string]string{
+	"hours":  "horas",
+	"nights": "noches",
+}
+
+// buildTable arma las filas del reporte pedido
+func (s *ReportService) buildTable(ctx context.Context, params ReportParams, now time.Time) (*reportTable, error) {
+	switch params.Kind {
+	case models.ReportOccupancy, models.ReportRevenue:
+		report, err := s.Analytics.Report(ctx, params.StartDate, params.EndDate, params.Bucket, params.RoomType)
+		if err != nil {
+			return nil, err
+		}
+		if params.Kind == models.ReportOccupancy {
+			return occupancyTable(report), nil
+		}
+		return revenueTable(report), nil
+	case models.ReportOverdue:
+		tenants, err := s.Overdue.Overdue(ctx, now)
+		if err != nil {
+			return nil, err
+		}
+		return overdueTable(tenants), nil
+	case models.ReportGuests:
+		return s.guestsTable(ctx, params.StartDate, params.EndDate)
+	}
+	return nil, fmt.Errorf("unknown report kind %q", params.Kind)
+}
+
+func occupancyTable(report *models.AnalyticsReport) *reportTable {
+	table := &reportTable{
+		Title:  "Ocupación",
+		Header: []string{"Inicio", "Fin", "Habitaciones disponibles", "Habitaciones vendidas", "Ocupación (%)", "ADR", "RevPAR"},
+	}
+	row := func(label string, period models.AnalyticsPeriod) []interface{} {
+		start, end := reportDate(period.Start), reportDate(period.End.AddDate(0, 0, -1))
+		if label != "" {
+			start, end = label, ""
+		}
+		return []interface{}{
+			start, end, period.RoomsAvailable, period.RoomsSold,
+			roundMoney(period.OccupancyRate * 100), period.ADR, period.RevPAR,
+		}
+	}
+	for _, period := range report.Series {
+		table.Rows = append(table.Rows, row("", period))
+	}
+	table.Rows = append(table.Rows, row("Total", report.Summary))
+	return table
+}
+
+func revenueTable(report *models.AnalyticsReport) *reportTable {
+	table := &reportTable{
+		Title:  "Ingresos",
+		Header: []string{"Inicio", "Fin", "Total", "Huéspedes", "Inquilinos"},
+	}
+	for _, method := range reportMethods {
+		table.Header = append(table.Header, paymentMethodLabels[method])
+	}
+	row := func(label string, period models.AnalyticsPeriod) []interface{} {
+		start, end := reportDate(period.Start), reportDate(period.End.AddDate(0, 0, -1))
+		if label != "" {
+			start, end = label, ""
+		}
+		cells := []interface{}{start, end, period.Revenue.Total, period.Revenue.Guest, period.Revenue.Rental}
+		for _, method := range reportMethods {
+			cells = append(cells, period.Revenue.ByMethod[method])
+		}
+		return cells
+	}
+	for _, period := range report.Series {
+		table.Rows = append(table.Rows, row("", period))
+	}
+	table.Rows = append(table.Rows, row("Total", report.Summary))
+	return table
+}
+
+func overdueTable(tenants []models.OverdueTenant) *reportTable {
+	table := &reportTable{
+		Title:  "Rentas vencidas",
+		Header: []string{"Inquilino", "Habitación", "Rentas vencidas", "Adeudo", "Vencimiento más antiguo", "Días de atraso"},
+	}
+	for _, tenant := range tenants {
+		table.Rows = append(table.Rows, []interface{}{
+			strings.TrimSpace(tenant.Nombres + " " + tenant.Apellidos),
+			tenant.RoomNumber,
+			tenant.OverdueCharges,
+			tenant.AmountOwed,
+			reportDate(tenant.OldestDueDate),
+			tenant.DaysLate,
+		})
+	}
+	return table
+}
+
+// guestsTable lista una fila por cada check-in de huésped dentro del rango, con su check-out si ya salió
+func (s *ReportService) guestsTable(ctx context.Context, start, end time.Time) (*reportTable, error) {
+	cursor, err := s.db().Collection(constants.CollectionClients).Find(ctx,
+		bson.M{
+			"customID": bson.M{"$exists": true, "$ne": ""},
+			"history": bson.M{"$elemMatch": bson.M{
+				"action":   models.HistoryCheckIn,
+				"dateTime": bson.M{"$gte": start, "$lt": end},
+			}},
+		},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list guests: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var guests []models.Guest
+	if err := cursor.All(ctx, &guests); err != nil {
+		return nil, fmt.Errorf("unable to decode guests: %v", err)
+	}
+
+	table := &reportTable{
+		Title:  "Huéspedes",
+		Header: []string{"ID", "Habitación", "Check-in", "Check-out", "Duración", "Precio", "Descripción"},
+	}
+	for _, guest := range guests {
+		history := guest.History
+		sort.SliceStable(history, func(i, j int) bool { return history[i].DateTime.Before(history[j].DateTime) })
+		for i, record := range history {
+			if record.Action != models.HistoryCheckIn || record.DateTime.Before(start) || !record.DateTime.Before(end) {
+				continue
+			}
+			checkOut := ""
+			for _, next := range history[i+1:] {
+				if next.Action == models.HistoryCheckOut && next.RoomNumber == record.RoomNumber {
+					checkOut = reportDateTime(next.DateTime)
+					break
+				}
+			}
+			duration := ""
+			if guest.Duration > 0 {
+				duration = fmt.Sprintf("%d %s", guest.Duration, durationUnitLabels[guest.DurationUnit])
+			}
+			table.Rows = append(table.Rows, []interface{}{
+				guest.CustomID,
+				record.RoomNumber,
+				reportDateTime(record.DateTime),
+				checkOut,
+				strings.TrimSpace(duration),
+				guest.Price,
+				guest.ExtraDescription,
+			})
+		}
+	}
+	return table, nil
+}
+
+// encodeCSV exporta la tabla en CSV con BOM para que Excel reconozca los acentos
+func encodeCSV(table *reportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	writer := csv.NewWriter(&buf)
+	header := make([]string, len(table.Header))
+	for i, title := range table.Header {
+		header[i] = csvText(title)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("unable to write csv: %v", err)
+	}
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			switch value := cell.(type) {
+			case float64:
+				record[i] = fmt.Sprintf("%.2f", value)
+			case string:
+				record[i] = csvText(value)
+			default:
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("unable to write csv: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("unable to write csv: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvText antepone un apóstrofo a los textos que Excel interpretaría como fórmula (=, +, -, @, tabulador o
+// retorno de carro), para que un nombre o una descripción capturados por un usuario no se ejecuten al abrir el archivo
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// encodeXLSX exporta la tabla en una hoja de Excel con el encabezado en negritas
+func encodeXLSX(table *reportTable) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := table.Title
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, fmt.Errorf("unable to build xlsx: %v", err)
+	}
+	header := make([]interface{}, len(table.Header))
+	for i, title := range table.Header {
+		header[i] = title
+	}
+	if err := file.SetSheetRow(sheet, "A1", &header); err != nil {
+		return nil, fmt.Errorf("unable to build xlsx: %v", err)
+	}
+	for i, row := range table.Rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := file.SetSheetRow(sheet, cell, &row); err != nil {
+			return nil, fmt.Errorf("unable to build xlsx: %v", err)
+		}
+	}
+
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to build xlsx: %v", err)
+	}
+	money, err := file.NewStyle(&excelize.Style{NumFmt: 4}) // #,##0.00
+	if err != nil {
+		return nil, fmt.Errorf("unable to build xlsx: %v", err)
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(table.Header))
+	file.SetCellStyle(sheet, "A1", lastColumn+"1", bold)
+	for column := range table.Header {
+		if len(table.Rows) == 0 {
+			break
+		}
+		if _, ok := table.Rows[0][column].(float64); ok {
+			name, _ := excelize.ColumnNumberToName(column + 1)
+			file.SetCellStyle(sheet, name+"2", fmt.Sprintf("%s%d", name, len(table.Rows)+1), money)
+		}
+	}
+	file.SetColWidth(sheet, "A", lastColumn, 18)
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("unable to write xlsx: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func reportDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func reportDateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04")
+}
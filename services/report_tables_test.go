@@ -0,0 +1,53 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	table := &reportTable{
+		Title:  "Huéspedes",
+		Header: []string{"ID", "Descripción", "Noches", "Precio"},
+		Rows: [][]interface{}{
+			{"H-001", "Cliente frecuente", 2, 850.5},
+			{"=HYPERLINK(\"http://x\")", "+52 55 1234", -1, -12.345},
+			{"@SUM(A1)", "-cmd", 0, 0.0},
+			{"", "a=b", 3, 1000.0},
+			{"\t=1+1", "\r=1+1", 1, 1.0},
+		},
+	}
+
+	content, err := encodeCSV(table)
+	if err != nil {
+		t.Fatalf("encodeCSV: %v", err)
+	}
+	if !bytes.HasPrefix(content, []byte("\ufeff")) {
+		t.Errorf("el CSV no empieza con BOM")
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff")))).ReadAll()
+	if err != nil {
+		t.Fatalf("el CSV no se puede leer: %v", err)
+	}
+
+	want := [][]string{
+		{"ID", "Descripción", "Noches", "Precio"},
+		{"H-001", "Cliente frecuente", "2", "850.50"},
+		{"'=HYPERLINK(\"http://x\")", "'+52 55 1234", "-1", "-12.35"},
+		{"'@SUM(A1)", "'-cmd", "0", "0.00"},
+		{"", "a=b", "3", "1000.00"},
+		{"'\t=1+1", "'\r=1+1", "1", "1.00"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("%d filas, se esperaban %d", len(records), len(want))
+	}
+	for i := range want {
+		for j := range want[i] {
+			if records[i][j] != want[i][j] {
+				t.Errorf("celda [%d][%d] = %q, se esperaba %q", i, j, records[i][j], want[i][j])
+			}
+		}
+	}
+}
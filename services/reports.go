@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrReportScheduleNotFound indica que no existe la programación de reporte
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	// ErrReportRunNotFound indica que no existe la ejecución de reporte
+	ErrReportRunNotFound = errors.New("report run not found")
+	// ErrInvalidCron indica que la expresión cron de la programación no es válida
+	ErrInvalidCron = errors.New("invalid cron expression")
+)
+
+// ReportParams describe qué reporte generar: el rango va de StartDate a EndDate (exclusivo); Bucket y RoomType
+// solo aplican a ocupación e ingresos
+type ReportParams struct {
+	Kind      string
+	Format    string
+	StartDate time.Time
+	EndDate   time.Time
+	Bucket    string
+	RoomType  string
+}
+
+// ReportService exporta reportes en CSV y XLSX, los guarda en el almacenamiento configurado y los genera
+// según las programaciones
+type ReportService struct {
+	Client                 *mongo.Client
+	Analytics              *AnalyticsService
+	Overdue                *OverdueService
+	GoogleDriveService     *GoogleDriveService
+	LocalFileSystemService *LocalFileSystemService
+}
+
+// NewReportService crea una nueva instancia de ReportService
+func NewReportService(client *mongo.Client, analytics *AnalyticsService, overdue *OverdueService, drive *GoogleDriveService, local *LocalFileSystemService) *ReportService {
+	return &ReportService{
+		Client:                 client,
+		Analytics:              analytics,
+		Overdue:                overdue,
+		GoogleDriveService:     drive,
+		LocalFileSystemService: local,
+	}
+}
+
+func (s *ReportService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *ReportService) schedules() *mongo.Collection {
+	return s.db().Collection(constants.CollectionReportSchedules)
+}
+
+func (s *ReportService) runs() *mongo.Collection {
+	return s.db().Collection(constants.CollectionReportRuns)
+}
+
+// Generate genera el reporte, lo guarda en el almacenamiento y registra la ejecución; si falla también queda
+// registrada con el error. Devuelve la ejecución y el contenido del archivo.
+func (s *ReportService) Generate(ctx context.Context, params ReportParams, user string, scheduleID *primitive.ObjectID) (*models.ReportRun, []byte, error) {
+	now := time.Now()
+	run := &models.ReportRun{
+		ID:          primitive.NewObjectID(),
+		ScheduleID:  scheduleID,
+		Kind:        params.Kind,
+		Format:      params.Format,
+		StartDate:   params.StartDate,
+		EndDate:     params.EndDate,
+		Bucket:      params.Bucket,
+		RoomType:    params.RoomType,
+		Status:      models.ReportRunSucceeded,
+		GeneratedBy: user,
+		GeneratedAt: now,
+	}
+	run.Filename = fmt.Sprintf("reporte-%s-%s-%s-%s.%s", params.Kind,
+		params.StartDate.Format("20060102"), params.EndDate.AddDate(0, 0, -1).Format("20060102"),
+		run.ID.Hex(), params.Format)
+
+	content, err := s.export(ctx, params, now, run)
+	if err != nil {
+		run.Status = models.ReportRunFailed
+		run.Error = err.Error()
+	}
+	if _, insertErr := s.runs().InsertOne(ctx, run); insertErr != nil {
+		return nil, nil, fmt.Errorf("unable to save report run: %v", insertErr)
+	}
+	if err != nil {
+		return run, nil, err
+	}
+	return run, content, nil
+}
+
+// export arma el archivo del reporte y lo guarda; anota en run el número de filas y la URL
+func (s *ReportService) export(ctx context.Context, params ReportParams, now time.Time, run *models.ReportRun) ([]byte, error) {
+	table, err := s.buildTable(ctx, params, now)
+	if err != nil {
+		return nil, err
+	}
+	run.Rows = len(table.Rows)
+
+	var content []byte
+	if params.Format == models.ReportXLSX {
+		content, err = encodeXLSX(table)
+	} else {
+		content, err = encodeCSV(table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run.FileURL, err = StoreDocument(run.Filename, content, s.LocalFileSystemService, s.GoogleDriveService)
+	if err != nil {
+		return nil, fmt.Errorf("unable to store report: %v", err)
+	}
+	return content, nil
+}
+
+// GetRun devuelve una ejecución de reporte
+func (s *ReportService) GetRun(ctx context.Context, id primitive.ObjectID) (*models.ReportRun, error) {
+	var run models.ReportRun
+	err := s.runs().FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReportRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get report run: %v", err)
+	}
+	return &run, nil
+}
+
+// ListRuns devuelve una página de ejecuciones que cumplen el filtro, de la más reciente a la más antigua, y el total
+func (s *ReportService) ListRuns(ctx context.Context, filter bson.M, page, pageSize int) ([]models.ReportRun, int64, error) {
+	total, err := s.runs().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count report runs: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "generatedAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := s.runs().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list report runs: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.ReportRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode report runs: %v", err)
+	}
+	return runs, total, nil
+}
+
+// CreateSchedule guarda una programación nueva y calcula su siguiente ejecución
+func (s *ReportService) CreateSchedule(ctx context.Context, schedule *models.ReportSchedule, user string) error {
+	now := time.Now()
+	if err := scheduleNextRun(schedule, now); err != nil {
+		return err
+	}
+	schedule.ID = primitive.NewObjectID()
+	schedule.CreatedBy = user
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if _, err := s.schedules().InsertOne(ctx, schedule); err != nil {
+		return fmt.Errorf("unable to create report schedule: %v", err)
+	}
+	return nil
+}
+
+// UpdateSchedule reemplaza la definición de una programación y recalcula su siguiente ejecución
+func (s *ReportService) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule *models.ReportSchedule) (*models.ReportSchedule, error) {
+	now := time.Now()
+	if err := scheduleNextRun(schedule, now); err != nil {
+		return nil, err
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"name":      schedule.Name,
+			"kind":      schedule.Kind,
+			"format":    schedule.Format,
+			"cron":      schedule.Cron,
+			"period":    schedule.Period,
+			"bucket":    schedule.Bucket,
+			"roomType":  schedule.RoomType,
+			"enabled":   schedule.Enabled,
+			"updatedAt": now,
+		},
+	}
+	if schedule.NextRunAt != nil {
+		update["$set"].(bson.M)["nextRunAt"] = schedule.NextRunAt
+	} else {
+		update["$unset"] = bson.M{"nextRunAt": ""}
+	}
+
+	var updated models.ReportSchedule
+	err := s.schedules().FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReportScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update report schedule: %v", err)
+	}
+	return &updated, nil
+}
+
+// DeleteSchedule elimina una programación; sus ejecuciones pasadas se conservan
+func (s *ReportService) DeleteSchedule(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.schedules().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("unable to delete report schedule: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrReportScheduleNotFound
+	}
+	return nil
+}
+
+// ListSchedules devuelve todas las programaciones ordenadas por nombre
+func (s *ReportService) ListSchedules(ctx context.Context) ([]models.ReportSchedule, error) {
+	cursor, err := s.schedules().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list report schedules: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []models.ReportSchedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, fmt.Errorf("unable to decode report schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// RunSchedule genera en este momento el reporte de la programación, sin mover su siguiente ejecución
+func (s *ReportService) RunSchedule(ctx context.Context, id primitive.ObjectID, user string) (*models.ReportRun, error) {
+	var schedule models.ReportSchedule
+	err := s.schedules().FindOne(ctx, bson.M{"_id": id}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReportScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get report schedule: %v", err)
+	}
+	return s.runSchedule(ctx, schedule, user, time.Now())
+}
+
+// Start genera cada interval en segundo plano los reportes programados pendientes hasta que se cancele ctx
+func (s *ReportService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if generated, err := s.RunDue(ctx, time.Now()); err != nil {
+				log.Printf("Error al generar reportes programados: %v", err)
+			} else if generated > 0 {
+				log.Printf("%d reportes programados generados", generated)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunDue genera los reportes de las programaciones activas cuya siguiente ejecución ya pasó. Cada programación
+// se reclama moviendo su nextRunAt antes de generarla, así dos instancias no generan el mismo reporte.
+// Devuelve cuántos reportes generó.
+func (s *ReportService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	cursor, err := s.schedules().Find(ctx, bson.M{"enabled": true, "nextRunAt": bson.M{"$lte": now}})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find due report schedules: %v", err)
+	}
+	var due []models.ReportSchedule
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, fmt.Errorf("unable to decode report schedules: %v", err)
+	}
+
+	generated := 0
+	for _, schedule := range due {
+		claimed := schedule
+		if err := scheduleNextRun(&claimed, now); err != nil {
+			log.Printf("Programación de reporte %s inválida: %v", schedule.ID.Hex(), err)
+			continue
+		}
+		result, err := s.schedules().UpdateOne(ctx,
+			bson.M{"_id": schedule.ID, "nextRunAt": schedule.NextRunAt},
+			bson.M{"$set": bson.M{"nextRunAt": claimed.NextRunAt}},
+		)
+		if err != nil {
+			return generated, fmt.Errorf("unable to claim report schedule: %v", err)
+		}
+		if result.ModifiedCount == 0 {
+			continue // Otra instancia ya la tomó
+		}
+
+		// La ejecución fallida queda registrada en el historial; se sigue con las demás programaciones
+		if _, err := s.runSchedule(ctx, schedule, models.ReportScheduler, now); err != nil {
+			log.Printf("Error generando el reporte programado %s: %v", schedule.ID.Hex(), err)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+// runSchedule genera el reporte del periodo completo anterior a now y lo anota como la última ejecución
+func (s *ReportService) runSchedule(ctx context.Context, schedule models.ReportSchedule, user string, now time.Time) (*models.ReportRun, error) {
+	start, end := previousPeriod(schedule.Period, now)
+	run, _, err := s.Generate(ctx, ReportParams{
+		Kind:      schedule.Kind,
+		Format:    schedule.Format,
+		StartDate: start,
+		EndDate:   end,
+		Bucket:    schedule.Bucket,
+		RoomType:  schedule.RoomType,
+	}, user, &schedule.ID)
+	if run != nil {
+		_, updateErr := s.schedules().UpdateOne(ctx, bson.M{"_id": schedule.ID}, bson.M{"$set": bson.M{
+			"lastRunAt": run.GeneratedAt,
+			"lastRunId": run.ID,
+		}})
+		if updateErr != nil {
+			log.Printf("Error guardando la última ejecución de la programación %s: %v", schedule.ID.Hex(), updateErr)
+		}
+	}
+	return run, err
+}
+
+// cronParser solo acepta expresiones de 5 campos (minuto hora día mes día de la semana), sin descriptores como
+// @every ni @hourly
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// scheduleNextRun valida la expresión cron y fija la siguiente ejecución después de now, o la quita si la
+// programación está desactivada
+func scheduleNextRun(schedule *models.ReportSchedule, now time.Time) error {
+	// Contar los campos también rechaza los prefijos TZ= y CRON_TZ=, que el parser acepta en cualquier modo
+	if len(strings.Fields(schedule.Cron)) != 5 {
+		return ErrInvalidCron
+	}
+	parsed, err := cronParser.Parse(schedule.Cron)
+	if err != nil {
+		return ErrInvalidCron
+	}
+	if !schedule.Enabled {
+		schedule.NextRunAt = nil
+		return nil
+	}
+	next := parsed.Next(now.UTC())
+	schedule.NextRunAt = &next
+	return nil
+}
+
+// previousPeriod devuelve el día, la semana (de lunes a domingo) o el mes completo anterior al que contiene now
+func previousPeriod(period string, now time.Time) (time.Time, time.Time) {
+	today := truncateDay(now)
+	switch period {
+	case models.BucketWeek:
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, -7), monday
+	case models.BucketMonth:
+		first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, -1, 0), first
+	}
+	return today.AddDate(0, 0, -1), today
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"hotelman-backend/models"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		period     string
+		now        time.Time
+		start, end time.Time
+	}{
+		{"día anterior", models.BucketDay, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), day(2026, 10, 16), day(2026, 10, 17)},
+		{"día anterior en año nuevo", models.BucketDay, time.Date(2027, 1, 1, 0, 5, 0, 0, time.UTC), day(2026, 12, 31), day(2027, 1, 1)},
+		{"semana anterior desde sábado", models.BucketWeek, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), day(2026, 10, 5), day(2026, 10, 12)},
+		{"semana anterior desde lunes", models.BucketWeek, day(2026, 10, 12), day(2026, 10, 5), day(2026, 10, 12)},
+		{"semana anterior desde domingo", models.BucketWeek, time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), day(2026, 10, 5), day(2026, 10, 12)},
+		{"mes anterior", models.BucketMonth, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), day(2026, 9, 1), day(2026, 10, 1)},
+		{"mes anterior en enero", models.BucketMonth, day(2027, 1, 31), day(2026, 12, 1), day(2027, 1, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := previousPeriod(tt.period, tt.now)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("previousPeriod(%q, %s) = %s a %s, se esperaba %s a %s",
+					tt.period, tt.now, reportDate(start), reportDate(end), reportDate(tt.start), reportDate(tt.end))
+			}
+		})
+	}
+}
+
+func TestScheduleNextRun(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cron    string
+		enabled bool
+		want    *time.Time
+		err     error
+	}{
+		{"primero de cada mes", "0 6 1 * *", true, ptrTime(time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)), nil},
+		{"diario más tarde hoy", "0 18 * * *", true, ptrTime(time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC)), nil},
+		{"diario ya pasado", "0 7 * * *", true, ptrTime(time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)), nil},
+		{"lunes", "30 8 * * 1", true, ptrTime(time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)), nil},
+		{"deshabilitada", "0 6 1 * *", false, nil, nil},
+		{"expresión inválida", "bad", true, nil, ErrInvalidCron},
+		{"seis campos", "0 0 6 1 * *", true, nil, ErrInvalidCron},
+		{"inválida aunque esté deshabilitada", "bad", false, nil, ErrInvalidCron},
+		{"descriptor @every", "@every 1s", true, nil, ErrInvalidCron},
+		{"descriptor @hourly", "@hourly", true, nil, ErrInvalidCron},
+		{"prefijo de zona horaria", "TZ=America/Mexico_City 0 6 * * *", true, nil, ErrInvalidCron},
+		{"prefijo CRON_TZ", "CRON_TZ=UTC 0 6 * * *", true, nil, ErrInvalidCron},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &models.ReportSchedule{Cron: tt.cron, Enabled: tt.enabled}
+			err := scheduleNextRun(schedule, now)
+			if err != tt.err {
+				t.Fatalf("error = %v, se esperaba %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			switch {
+			case tt.want == nil && schedule.NextRunAt != nil:
+				t.Errorf("nextRunAt = %s, se esperaba vacío", schedule.NextRunAt)
+			case tt.want != nil && (schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(*tt.want)):
+				t.Errorf("nextRunAt = %v, se esperaba %s", schedule.NextRunAt, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
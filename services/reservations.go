@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrReservationNotFound indica que no existe la reservación
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrReservationOverlap indica que la habitación ya tiene una reservación confirmada en esas fechas
+	ErrReservationOverlap = errors.New("room is already reserved for those dates")
+	// ErrReservationClosed indica que la reservación ya no se puede modificar (p. ej. fue cancelada)
+	ErrReservationClosed = errors.New("reservation can no longer be modified")
+	// ErrReservationDates indica que la fecha de salida no es posterior a la de llegada
+	ErrReservationDates = errors.New("departure date must be after arrival date")
+	// ErrRoomNotFound indica que no existe la habitación indicada
+	ErrRoomNotFound = errors.New("room not found")
+	// ErrClientNotFound indica que no existe el cliente (huésped o inquilino) indicado
+	ErrClientNotFound = errors.New("client not found")
+)
+
+// blockingReservationStatuses son los estados en los que una reservación ocupa su habitación
+var blockingReservationStatuses = []string{models.ReservationConfirmed, models.ReservationCheckedIn}
+
+// ReservationChanges son los cambios que se pueden aplicar a una reservación; los campos nil no se modifican
+type ReservationChanges struct {
+	RoomNumber    *string
+	ArrivalDate   *time.Time
+	DepartureDate *time.Time
+	Notes         *string
+}
+
+// ReservationStore maneja las reservaciones y garantiza que no se traslapen en una misma habitación
+type ReservationStore struct {
+	Client *mongo.Client
+}
+
+// NewReservationStore crea una nueva instancia de ReservationStore
+func NewReservationStore(client *mongo.Client) *ReservationStore {
+	return &ReservationStore{Client: client}
+}
+
+func (s *ReservationStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionReservations)
+}
+
+func (s *ReservationStore) rooms() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRooms)
+}
+
+// Create guarda la reservación. Si trae RoomNumber se confirma sobre esa habitación verificando que no se
+// traslape; si solo trae RoomType queda pendiente hasta que se le asigne una habitación.
+func (s *ReservationStore) Create(ctx context.Context, reservation *models.Reservation) error {
+	if !reservation.DepartureDate.After(reservation.ArrivalDate) {
+		return ErrReservationDates
+	}
+
+	clients := s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionClients)
+	count, err := clients.CountDocuments(ctx, bson.M{"_id": reservation.ClientID})
+	if err != nil {
+		return fmt.Errorf("unable to look up client: %v", err)
+	}
+	if count == 0 {
+		return ErrClientNotFound
+	}
+
+	now := time.Now()
+	reservation.ID = primitive.NewObjectID()
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+	reservation.Status = models.ReservationPending
+
+	if reservation.RoomNumber == "" {
+		if _, err := s.collection().InsertOne(ctx, reservation); err != nil {
+			return fmt.Errorf("unable to create reservation: %v", err)
+		}
+		return nil
+	}
+
+	return runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		room, err := s.lockRoom(sc, reservation.RoomNumber)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOverlap(sc, room.ID, reservation.ID, reservation.ArrivalDate, reservation.DepartureDate); err != nil {
+			return err
+		}
+
+		reservation.RoomID = &room.ID
+		reservation.RoomType = room.RoomType
+		reservation.Status = models.ReservationConfirmed
+		if _, err := s.collection().InsertOne(sc, reservation); err != nil {
+			return fmt.Errorf("unable to create reservation: %v", err)
+		}
+		return nil
+	})
+}
+
+// Update aplica los cambios a la reservación y vuelve a verificar que no se traslape con otra de la misma habitación.
+// Un roomNumber vacío desasigna la habitación y devuelve la reservación a pendiente.
+func (s *ReservationStore) Update(ctx context.Context, id primitive.ObjectID, changes ReservationChanges) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		if err := s.collection().FindOne(sc, bson.M{"_id": id}).Decode(&reservation); err == mongo.ErrNoDocuments {
+			return ErrReservationNotFound
+		} else if err != nil {
+			return fmt.Errorf("unable to get reservation: %v", err)
+		}
+		if reservation.Status != models.ReservationPending && reservation.Status != models.ReservationConfirmed {
+			return ErrReservationClosed
+		}
+
+		if changes.ArrivalDate != nil {
+			reservation.ArrivalDate = *changes.ArrivalDate
+		}
+		if changes.DepartureDate != nil {
+			reservation.DepartureDate = *changes.DepartureDate
+		}
+		if changes.Notes != nil {
+			reservation.Notes = *changes.Notes
+		}
+		if changes.RoomNumber != nil {
+			reservation.RoomNumber = strings.TrimSpace(*changes.RoomNumber)
+			if reservation.RoomNumber == "" {
+				// Quitar la habitación desasigna la reservación; queda pendiente con su tipo de habitación
+				reservation.RoomID = nil
+				reservation.Status = models.ReservationPending
+			}
+		}
+		if !reservation.DepartureDate.After(reservation.ArrivalDate) {
+			return ErrReservationDates
+		}
+
+		// Mientras tenga habitación se vuelve a bloquear y a verificar el traslape, aunque solo cambien las fechas
+		if reservation.RoomNumber != "" {
+			room, err := s.lockRoom(sc, reservation.RoomNumber)
+			if err != nil {
+				return err
+			}
+			if err := s.checkOverlap(sc, room.ID, reservation.ID, reservation.ArrivalDate, reservation.DepartureDate); err != nil {
+				return err
+			}
+			reservation.RoomID = &room.ID
+			reservation.RoomType = room.RoomType
+			reservation.Status = models.ReservationConfirmed
+		}
+
+		reservation.UpdatedAt = time.Now()
+		if _, err := s.collection().ReplaceOne(sc, bson.M{"_id": id}, reservation); err != nil {
+			return fmt.Errorf("unable to update reservation: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Cancel cancela una reservación pendiente o confirmada y libera su habitación
+func (s *ReservationStore) Cancel(ctx context.Context, id primitive.ObjectID) (*models.Reservation, error) {
+	now := time.Now()
+	var reservation models.Reservation
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": []string{models.ReservationPending, models.ReservationConfirmed}}},
+		bson.M{"$set": bson.M{"status": models.ReservationCancelled, "cancelledAt": now, "updatedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrReservationClosed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to cancel reservation: %v", err)
+	}
+	return &reservation, nil
+}
+
+// Get devuelve la reservación con el ID dado
+func (s *ReservationStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get reservation: %v", err)
+	}
+	return &reservation, nil
+}
+
+// List devuelve una página de reservaciones que cumplen el filtro, ordenadas por fecha de llegada, y el total
+func (s *ReservationStore) List(ctx context.Context, filter bson.M, page, pageSize int) ([]models.Reservation, int64, error) {
+	total, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count reservations: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "arrivalDate", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list reservations: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []models.Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode reservations: %v", err)
+	}
+	return reservations, total, nil
+}
+
+// AvailableRooms devuelve las habitaciones sin reservaciones confirmadas que se traslapen con el rango dado,
+// opcionalmente filtradas por tipo de habitación
+func (s *ReservationStore) AvailableRooms(ctx context.Context, arrival, departure time.Time, roomType string) ([]models.Room, error) {
+	reserved, err := s.collection().Distinct(ctx, "roomId", overlapFilter(arrival, departure))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find reserved rooms: %v", err)
+	}
+
+	filter := activeRoomFilter(bson.M{"_id": bson.M{"$nin": reserved}})
+	if roomType != "" {
+		filter["roomType"] = roomType
+	}
+	cursor, err := s.rooms().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "roomNumber", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rooms: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	rooms := []models.Room{}
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, fmt.Errorf("unable to decode rooms: %v", err)
+	}
+	return rooms, nil
+}
+
+// lockRoom busca la habitación por número y escribe en su documento dentro de la transacción. Dos transacciones
+// que reserven la misma habitación chocan en esa escritura, y el driver reintenta la perdedora, que ya verá
+// la reservación de la ganadora; así dos reservaciones confirmadas nunca se traslapan aunque lleguen a la vez.
+func (s *ReservationStore) lockRoom(sc mongo.SessionContext, roomNumber string) (*models.Room, error) {
+	var room models.Room
+	err := s.rooms().FindOneAndUpdate(sc,
+		activeRoomFilter(bson.M{"roomNumber": roomNumber}),
+		bson.M{"$inc": bson.M{"reservationVersion": 1}},
+	).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock room: %v", err)
+	}
+	return &room, nil
+}
+
+// checkOverlap devuelve ErrReservationOverlap si otra reservación confirmada ocupa la habitación en el rango dado
+func (s *ReservationStore) checkOverlap(ctx context.Context, roomID, excludeID primitive.ObjectID, arrival, departure time.Time) error {
+	filter := overlapFilter(arrival, departure)
+	filter["roomId"] = roomID
+	filter["_id"] = bson.M{"$ne": excludeID}
+
+	count, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("unable to check overlapping reservations: %v", err)
+	}
+	if count > 0 {
+		return ErrReservationOverlap
+	}
+	return nil
+}
+
+// overlapFilter selecciona las reservaciones que bloquean su habitación y se traslapan con [arrival, departure)
+func overlapFilter(arrival, departure time.Time) bson.M {
+	return bson.M{
+		"status":        bson.M{"$in": blockingReservationStatuses},
+		"arrivalDate":   bson.M{"$lt": departure},
+		"departureDate": bson.M{"$gt": arrival},
+	}
+}
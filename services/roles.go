@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrRoleNotFound indica que no existe un rol con ese nombre
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists indica que ya existe un rol con ese nombre
+	ErrRoleExists = errors.New("role already exists")
+)
+
+// RoleStore maneja los roles y sus permisos guardados en MongoDB
+type RoleStore struct {
+	Client *mongo.Client
+}
+
+// NewRoleStore crea una nueva instancia de RoleStore
+func NewRoleStore(client *mongo.Client) *RoleStore {
+	return &RoleStore{Client: client}
+}
+
+func (s *RoleStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRoles)
+}
+
+// EnsureDefaults crea los roles del sistema que no existan y agrega a los existentes los permisos por defecto que
+// nunca se les hayan sembrado. Cada rol guarda en seededPermissions los permisos por defecto que ya recibió, para
+// que un permiso que un administrador quitó no regrese al reiniciar el servidor.
+func (s *RoleStore) EnsureDefaults(ctx context.Context) error {
+	for name, permissions := range auth.DefaultRolePermissions {
+		names := make([]string, len(permissions))
+		for i, p := range permissions {
+			names[i] = string(p)
+		}
+
+		now := time.Now()
+		var role models.Role
+		err := s.collection().FindOneAndUpdate(ctx,
+			bson.M{"name": name},
+			bson.M{
+				"$set": bson.M{"system": true},
+				"$setOnInsert": bson.M{
+					"name":              name,
+					"description":       "",
+					"permissions":       names,
+					"seededPermissions": names,
+					"createdAt":         now,
+					"updatedAt":         now,
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&role)
+		if err != nil {
+			return fmt.Errorf("unable to ensure role %s: %v", name, err)
+		}
+
+		// Solo se agregan los permisos por defecto nuevos, que el rol nunca ha recibido
+		var missing []string
+		for _, permission := range names {
+			if !containsPermission(role.SeededPermissions, permission) {
+				missing = append(missing, permission)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		granted := append([]string{}, role.Permissions...)
+		for _, permission := range missing {
+			if !containsPermission(granted, permission) {
+				granted = append(granted, permission)
+			}
+		}
+		_, err = s.collection().UpdateOne(ctx,
+			bson.M{"_id": role.ID},
+			bson.M{"$set": bson.M{
+				"permissions":       granted,
+				"seededPermissions": append(role.SeededPermissions, missing...),
+				"updatedAt":         now,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to seed permissions of role %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Get devuelve el rol con el nombre dado
+func (s *RoleStore) Get(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := s.collection().FindOne(ctx, bson.M{"name": name}).Decode(&role)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role: %v", err)
+	}
+	return &role, nil
+}
+
+// List devuelve todos los roles ordenados por nombre
+func (s *RoleStore) List(ctx context.Context) ([]models.Role, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := s.collection().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list roles: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	roles := []models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, fmt.Errorf("unable to decode roles: %v", err)
+	}
+	return roles, nil
+}
+
+// Create guarda un nuevo rol personalizado
+func (s *RoleStore) Create(ctx context.Context, role *models.Role) error {
+	count, err := s.collection().CountDocuments(ctx, bson.M{"name": role.Name})
+	if err != nil {
+		return fmt.Errorf("unable to check role: %v", err)
+	}
+	if count > 0 {
+		return ErrRoleExists
+	}
+
+	role.ID = primitive.NewObjectID()
+	role.System = false
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = role.CreatedAt
+	if _, err := s.collection().InsertOne(ctx, role); err != nil {
+		return fmt.Errorf("unable to create role: %v", err)
+	}
+	return nil
+}
+
+// Update reemplaza la descripción y los permisos de un rol; los permisos se guardan siempre como arreglo
+func (s *RoleStore) Update(ctx context.Context, name, description string, permissions []string) error {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"name": name},
+		bson.M{"$set": bson.M{
+			"description": description,
+			"permissions": permissions,
+			"updatedAt":   time.Now(),
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update role: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// Delete elimina un rol por nombre
+func (s *RoleStore) Delete(ctx context.Context, name string) error {
+	result, err := s.collection().DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return fmt.Errorf("unable to delete role: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+func containsPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
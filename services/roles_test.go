@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"hotelman-backend/auth"
+	"hotelman-backend/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEnsureDefaultsKeepsRemovedPermissions(t *testing.T) {
+	client := testMongoClient(t)
+	ctx := context.Background()
+	roles := NewRoleStore(client)
+	const name = auth.RoleAdministracion
+	removed := string(auth.DefaultRolePermissions[name][0])
+
+	if err := roles.EnsureDefaults(ctx); err != nil {
+		t.Fatalf("EnsureDefaults: %v", err)
+	}
+	role, err := roles.Get(ctx, name)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !role.HasPermission(removed) {
+		t.Fatalf("el rol nuevo no recibió el permiso %s", removed)
+	}
+
+	// Un permiso quitado por un administrador no regresa al reiniciar
+	var kept []string
+	for _, permission := range role.Permissions {
+		if permission != removed {
+			kept = append(kept, permission)
+		}
+	}
+	if err := roles.Update(ctx, name, role.Description, kept); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := roles.EnsureDefaults(ctx); err != nil {
+		t.Fatalf("EnsureDefaults tras quitar un permiso: %v", err)
+	}
+	if role, _ = roles.Get(ctx, name); role.HasPermission(removed) {
+		t.Fatalf("el permiso %s regresó al reiniciar", removed)
+	}
+
+	// Un permiso por defecto que el rol nunca recibió sí se agrega, una sola vez
+	_, err = client.Database(constants.MongoDBDatabase).Collection(constants.CollectionRoles).UpdateOne(ctx,
+		bson.M{"name": name}, bson.M{"$pull": bson.M{"seededPermissions": removed}})
+	if err != nil {
+		t.Fatalf("no se pudo simular un permiso nuevo: %v", err)
+	}
+	if err := roles.EnsureDefaults(ctx); err != nil {
+		t.Fatalf("EnsureDefaults con un permiso nuevo: %v", err)
+	}
+	if role, _ = roles.Get(ctx, name); !role.HasPermission(removed) {
+		t.Fatalf("el permiso nuevo %s no se sembró", removed)
+	}
+	count := 0
+	for _, permission := range role.Permissions {
+		if permission == removed {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("el permiso %s aparece %d veces", removed, count)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrInvalidRoomStatus indica que el estado no es uno de los definidos en models.RoomStatusTransitions
+	ErrInvalidRoomStatus = errors.New("invalid room status")
+	// ErrRoomStatusTransition indica que la habitación no puede pasar de su estado actual al estado pedido
+	ErrRoomStatusTransition = errors.New("room status transition not allowed")
+	// ErrRoomOccupancyStatus indica que el estado "occupied" solo se puede cambiar con check-in y check-out
+	ErrRoomOccupancyStatus = errors.New("occupied status is managed by check-in and check-out")
+	// ErrRoomBlocked indica que la habitación tiene tickets de mantenimiento bloqueantes abiertos; solo cerrar el
+	// último la regresa al servicio
+	ErrRoomBlocked = errors.New("room has open blocking maintenance tickets")
+)
+
+// RoomRef identifica una habitación por su ID o por su número
+type RoomRef struct {
+	ID     *primitive.ObjectID
+	Number string
+}
+
+// filter selecciona la habitación referida si no está eliminada
+func (ref RoomRef) filter() bson.M {
+	return activeRoomFilter(ref.withDeleted())
+}
+
+// withDeleted selecciona la habitación referida aunque esté eliminada
+func (ref RoomRef) withDeleted() bson.M {
+	if ref.ID != nil {
+		return bson.M{"_id": *ref.ID}
+	}
+	return bson.M{"roomNumber": ref.Number}
+}
+
+// RoomStatusService aplica la máquina de estados de las habitaciones y guarda su línea de tiempo
+type RoomStatusService struct {
+	Client *mongo.Client
+}
+
+// NewRoomStatusService crea una nueva instancia de RoomStatusService
+func NewRoomStatusService(client *mongo.Client) *RoomStatusService {
+	return &RoomStatusService{Client: client}
+}
+
+func (s *RoomStatusService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+// Change pasa la habitación al estado indicado si la transición está permitida y la registra en su línea de tiempo
+func (s *RoomStatusService) Change(ctx context.Context, ref RoomRef, to, user, note string) (*models.Room, error) {
+	if !models.IsValidRoomStatus(to) {
+		return nil, ErrInvalidRoomStatus
+	}
+	if to == models.RoomStatusOccupied {
+		return nil, ErrRoomOccupancyStatus
+	}
+
+	var room *models.Room
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		var err error
+		room, err = transitionRoomStatus(sc, s.db(), ref.filter(), to, user, note)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// History devuelve una página de la línea de tiempo de estados de la habitación, del cambio más reciente al más antiguo
+func (s *RoomStatusService) History(ctx context.Context, ref RoomRef, page, pageSize int) ([]models.RoomStatusChange, int64, error) {
+	var room models.Room
+	err := s.db().Collection(constants.CollectionRooms).FindOne(ctx, ref.withDeleted()).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, 0, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to get room: %v", err)
+	}
+
+	collection := s.db().Collection(constants.CollectionRoomStatusLog)
+	filter := bson.M{"roomId": room.ID}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count room status changes: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list room status changes: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.RoomStatusChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode room status changes: %v", err)
+	}
+	return changes, total, nil
+}
+
+// transitionRoomStatus pasa la habitación que cumple el filtro al estado to dentro de la transacción en curso,
+// validando la transición y registrándola en la línea de tiempo. No cambia el estado "occupied", que solo
+// manejan el check-in y el check-out, ni regresa al servicio una habitación con tickets bloqueantes abiertos.
+// Al sacar la habitación de servicio su tarea de limpieza abierta vuelve a "pending", porque la limpieza se
+// tendrá que repetir cuando la habitación regrese como "dirty".
+func transitionRoomStatus(sc mongo.SessionContext, db *mongo.Database, filter bson.M, to, user, note string) (*models.Room, error) {
+	var room models.Room
+	err := db.Collection(constants.CollectionRooms).FindOne(sc, filter).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get room: %v", err)
+	}
+	if room.Status == models.RoomStatusOccupied || room.OccupantID != nil {
+		return nil, ErrRoomOccupancyStatus
+	}
+	if !models.CanTransitionRoomStatus(room.Status, to) {
+		return nil, ErrRoomStatusTransition
+	}
+	if isOutOfService(room.Status) && !isOutOfService(to) {
+		blocking, err := db.Collection(constants.CollectionMaintenance).CountDocuments(sc, bson.M{
+			"roomId":   room.ID,
+			"blocking": true,
+			"status":   bson.M{"$ne": models.MaintenanceClosed},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to count blocking maintenance tickets: %v", err)
+		}
+		if blocking > 0 {
+			return nil, ErrRoomBlocked
+		}
+	}
+
+	from := room.Status
+	now := time.Now()
+	// El filtro por estado actual evita aplicar la transición sobre un estado que otro cambio ya modificó
+	result, err := db.Collection(constants.CollectionRooms).UpdateOne(sc,
+		bson.M{"_id": room.ID, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updatedAt": now}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to update room status: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrRoomStatusTransition
+	}
+
+	room.Status = to
+	room.UpdatedAt = now
+	if err := recordRoomStatusChange(sc, db, room, from, user, note); err != nil {
+		return nil, err
+	}
+	if isOutOfService(to) && !isOutOfService(from) {
+		if err := resetCleaningTasks(sc, db, room.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &room, nil
+}
+
+// isOutOfService indica si el estado saca a la habitación de servicio
+func isOutOfService(status string) bool {
+	return status == models.RoomStatusOutOfOrder || status == models.RoomStatusMaintenance
+}
+
+// recordRoomStatusChange agrega a la línea de tiempo el paso de la habitación desde el estado from a su estado actual
+func recordRoomStatusChange(ctx context.Context, db *mongo.Database, room models.Room, from, user, note string) error {
+	change := models.RoomStatusChange{
+		RoomID:     room.ID,
+		RoomNumber: room.RoomNumber,
+		From:       from,
+		To:         room.Status,
+		User:       user,
+		Note:       note,
+		CreatedAt:  room.UpdatedAt,
+	}
+	if _, err := db.Collection(constants.CollectionRoomStatusLog).InsertOne(ctx, change); err != nil {
+		return fmt.Errorf("unable to record room status change: %v", err)
+	}
+	return nil
+}
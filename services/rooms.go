@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrRoomNumberTaken indica que ya existe una habitación con ese número
+	ErrRoomNumberTaken = errors.New("room number already exists")
+	// ErrRoomNumberLocked indica que el número no se puede cambiar porque la habitación ya tiene historial
+	ErrRoomNumberLocked = errors.New("room number cannot change once the room has history")
+	// ErrRoomInUse indica que la habitación tiene reservaciones vigentes y no se puede eliminar
+	ErrRoomInUse = errors.New("room has active reservations")
+)
+
+// RoomChanges son los cambios que se pueden aplicar a la definición de una habitación; los campos nil no se modifican.
+// El estado y el ocupante no se cambian aquí sino con la máquina de estados y el check-in/check-out.
+type RoomChanges struct {
+	RoomType        *string
+	RoomNumber      *string
+	Name            *string
+	Description     *string
+	Category        *string
+	Floor           *int
+	Capacity        *int
+	Beds            *[]models.BedConfig
+	Amenities       *[]string
+	BaseNightlyRate *float64
+	BaseMonthlyRate *float64
+	Photos          *[]string
+}
+
+// RoomStore maneja el catálogo de habitaciones guardado en MongoDB
+type RoomStore struct {
+	Client *mongo.Client
+}
+
+// NewRoomStore crea una nueva instancia de RoomStore
+func NewRoomStore(client *mongo.Client) *RoomStore {
+	return &RoomStore{Client: client}
+}
+
+func (s *RoomStore) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *RoomStore) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionRooms)
+}
+
+// EnsureIndexes crea el índice único sobre roomNumber. Las habitaciones eliminadas conservan su número,
+// así que no se puede reutilizar mientras existan.
+func (s *RoomStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "roomNumber", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("roomNumber_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create room number index: %v", err)
+	}
+	return nil
+}
+
+// Create guarda una nueva habitación
+func (s *RoomStore) Create(ctx context.Context, room *models.Room) error {
+	room.ID = primitive.NewObjectID()
+	room.CreatedAt = time.Now()
+	room.UpdatedAt = room.CreatedAt
+	room.OccupantID = nil
+	room.Deleted = false
+	room.DeletedAt = nil
+	room.DeletedBy = ""
+	if room.Beds == nil {
+		room.Beds = []models.BedConfig{}
+	}
+	if room.Amenities == nil {
+		room.Amenities = []string{}
+	}
+	if room.Photos == nil {
+		room.Photos = []string{}
+	}
+
+	_, err := s.collection().InsertOne(ctx, room)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRoomNumberTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create room: %v", err)
+	}
+	return nil
+}
+
+// Get devuelve la habitación indicada si no está eliminada
+func (s *RoomStore) Get(ctx context.Context, ref RoomRef) (*models.Room, error) {
+	var room models.Room
+	err := s.collection().FindOne(ctx, ref.filter()).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get room: %v", err)
+	}
+	return &room, nil
+}
+
+// List devuelve las habitaciones que cumplen el filtro ordenadas por número; las eliminadas solo si includeDeleted
+func (s *RoomStore) List(ctx context.Context, filter bson.M, includeDeleted bool) ([]models.Room, error) {
+	if !includeDeleted {
+		filter = activeRoomFilter(filter)
+	}
+	cursor, err := s.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "roomNumber", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rooms: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	rooms := []models.Room{}
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, fmt.Errorf("unable to decode rooms: %v", err)
+	}
+	return rooms, nil
+}
+
+// Update aplica los cambios a la habitación. El número solo se puede cambiar mientras la habitación no tenga
+// historial, porque las reservaciones y los historiales de los clientes la referencian por número.
+func (s *RoomStore) Update(ctx context.Context, id primitive.ObjectID, changes RoomChanges) (*models.Room, error) {
+	var room models.Room
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		current, err := s.Get(sc, RoomRef{ID: &id})
+		if err != nil {
+			return err
+		}
+
+		set := bson.M{"updatedAt": time.Now()}
+		if changes.RoomNumber != nil && *changes.RoomNumber != current.RoomNumber {
+			history, err := roomHasHistory(sc, s.db(), *current)
+			if err != nil {
+				return err
+			}
+			if history {
+				return ErrRoomNumberLocked
+			}
+			set["roomNumber"] = *changes.RoomNumber
+		}
+		if changes.RoomType != nil {
+			set["roomType"] = *changes.RoomType
+		}
+		if changes.Name != nil {
+			set["name"] = *changes.Name
+		}
+		if changes.Description != nil {
+			set["description"] = *changes.Description
+		}
+		if changes.Category != nil {
+			set["category"] = *changes.Category
+		}
+		if changes.Floor != nil {
+			set["floor"] = *changes.Floor
+		}
+		if changes.Capacity != nil {
+			set["capacity"] = *changes.Capacity
+		}
+		if changes.Beds != nil {
+			set["beds"] = *changes.Beds
+		}
+		if changes.Amenities != nil {
+			set["amenities"] = *changes.Amenities
+		}
+		if changes.BaseNightlyRate != nil {
+			set["baseNightlyRate"] = *changes.BaseNightlyRate
+		}
+		if changes.BaseMonthlyRate != nil {
+			set["baseMonthlyRate"] = *changes.BaseMonthlyRate
+		}
+		if changes.Photos != nil {
+			set["photos"] = *changes.Photos
+		}
+
+		err = s.collection().FindOneAndUpdate(sc, bson.M{"_id": id}, bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&room)
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrRoomNumberTaken
+		}
+		if err != nil {
+			return fmt.Errorf("unable to update room: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// AddPhotos agrega las URLs de fotos ya subidas a la habitación
+func (s *RoomStore) AddPhotos(ctx context.Context, id primitive.ObjectID, urls []string) (*models.Room, error) {
+	var room models.Room
+	err := s.collection().FindOneAndUpdate(ctx,
+		RoomRef{ID: &id}.filter(),
+		bson.M{
+			"$push": bson.M{"photos": bson.M{"$each": urls}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to add photos: %v", err)
+	}
+	return &room, nil
+}
+
+// Delete elimina una habitación desocupada y sin reservaciones vigentes. Si tiene historial solo se marca
+// como eliminada para conservar las referencias; devuelve si el borrado fue lógico.
+func (s *RoomStore) Delete(ctx context.Context, id primitive.ObjectID, user string) (bool, error) {
+	var soft bool
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		room, err := s.Get(sc, RoomRef{ID: &id})
+		if err != nil {
+			return err
+		}
+		if room.OccupantID != nil || room.Status == models.RoomStatusOccupied {
+			return ErrRoomOccupied
+		}
+
+		active, err := s.db().Collection(constants.CollectionReservations).CountDocuments(sc, bson.M{
+			"roomId": id,
+			"status": bson.M{"$in": blockingReservationStatuses},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to look up reservations: %v", err)
+		}
+		if active > 0 {
+			return ErrRoomInUse
+		}
+
+		soft, err = roomHasHistory(sc, s.db(), *room)
+		if err != nil {
+			return err
+		}
+		if !soft {
+			if _, err := s.collection().DeleteOne(sc, bson.M{"_id": id}); err != nil {
+				return fmt.Errorf("unable to delete room: %v", err)
+			}
+			return nil
+		}
+
+		now := time.Now()
+		_, err = s.collection().UpdateOne(sc, bson.M{"_id": id}, bson.M{"$set": bson.M{
+			"deleted":   true,
+			"deletedAt": now,
+			"deletedBy": user,
+			"updatedAt": now,
+		}})
+		if err != nil {
+			return fmt.Errorf("unable to delete room: %v", err)
+		}
+		return nil
+	})
+	return soft, err
+}
+
+// roomHasHistory indica si la habitación aparece en reservaciones, historiales de clientes, su línea de tiempo
+// de estados, tareas de limpieza o tickets de mantenimiento
+func roomHasHistory(ctx context.Context, db *mongo.Database, room models.Room) (bool, error) {
+	lookups := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{constants.CollectionReservations, bson.M{"roomId": room.ID}},
+		{constants.CollectionClients, bson.M{"history.roomNumber": room.RoomNumber}},
+		{constants.CollectionRoomStatusLog, bson.M{"roomId": room.ID}},
+		{constants.CollectionHousekeeping, bson.M{"roomId": room.ID}},
+		{constants.CollectionMaintenance, bson.M{"roomId": room.ID}},
+	}
+	for _, lookup := range lookups {
+		count, err := db.Collection(lookup.collection).CountDocuments(ctx, lookup.filter, options.Count().SetLimit(1))
+		if err != nil {
+			return false, fmt.Errorf("unable to look up room history: %v", err)
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// activeRoomFilter agrega al filtro la condición que excluye las habitaciones eliminadas
+func activeRoomFilter(filter bson.M) bson.M {
+	filter["deleted"] = bson.M{"$ne": true}
+	return filter
+}
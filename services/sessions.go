@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SessionStore persiste las sesiones activas en MongoDB para poder revocarlas
+type SessionStore struct {
+	Client *mongo.Client
+}
+
+// NewSessionStore crea una nueva instancia de SessionStore
+func NewSessionStore(client *mongo.Client) *SessionStore {
+	return &SessionStore{Client: client}
+}
+
+func (s *SessionStore) collection() *mongo.Collection {
+	return s.Client.Database(constants.MongoDBDatabase).Collection(constants.CollectionSessions)
+}
+
+// Create guarda una nueva sesión
+func (s *SessionStore) Create(ctx context.Context, session *models.Session) error {
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	_, err := s.collection().InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("unable to create session: %v", err)
+	}
+	return nil
+}
+
+// IsActive indica si la sesión con el tokenID dado existe, no fue revocada y no ha expirado
+func (s *SessionStore) IsActive(ctx context.Context, tokenID string) (bool, error) {
+	filter := bson.M{
+		"tokenId":   tokenID,
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	count, err := s.collection().CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("unable to check session: %v", err)
+	}
+	return count > 0, nil
+}
+
+// ListActive devuelve las sesiones activas; si username está vacío devuelve las de todos los usuarios
+func (s *SessionStore) ListActive(ctx context.Context, username string) ([]models.Session, error) {
+	filter := bson.M{
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	if username != "" {
+		filter["username"] = username
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list sessions: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("unable to decode sessions: %v", err)
+	}
+	return sessions, nil
+}
+
+// Revoke revoca la sesión con el tokenID dado. Devuelve false si no había una sesión activa
+func (s *SessionStore) Revoke(ctx context.Context, tokenID string) (bool, error) {
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"tokenId": tokenID, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true, "revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("unable to revoke session: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RevokeAllForUser revoca todas las sesiones activas de un usuario y devuelve cuántas se revocaron
+func (s *SessionStore) RevokeAllForUser(ctx context.Context, username string) (int64, error) {
+	result, err := s.collection().UpdateMany(ctx,
+		bson.M{"username": username, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true, "revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to revoke sessions: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// RevokeOthersForUser revoca las sesiones activas del usuario excepto la indicada y devuelve cuántas se revocaron
+func (s *SessionStore) RevokeOthersForUser(ctx context.Context, username, keepTokenID string) (int64, error) {
+	result, err := s.collection().UpdateMany(ctx,
+		bson.M{"username": username, "revoked": false, "tokenId": bson.M{"$ne": keepTokenID}},
+		bson.M{"$set": bson.M{"revoked": true, "revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("unable to revoke sessions: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
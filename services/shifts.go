@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrShiftNotFound indica que no existe el turno
+	ErrShiftNotFound = errors.New("shift not found")
+	// ErrShiftAlreadyOpen indica que el usuario ya tiene un turno abierto
+	ErrShiftAlreadyOpen = errors.New("user already has an open shift")
+	// ErrNoOpenShift indica que el usuario no tiene un turno abierto, necesario para recibir efectivo
+	ErrNoOpenShift = errors.New("user has no open shift")
+)
+
+// ShiftService maneja los turnos de caja de recepción
+type ShiftService struct {
+	Client *mongo.Client
+}
+
+// NewShiftService crea una nueva instancia de ShiftService
+func NewShiftService(client *mongo.Client) *ShiftService {
+	return &ShiftService{Client: client}
+}
+
+func (s *ShiftService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+func (s *ShiftService) collection() *mongo.Collection {
+	return s.db().Collection(constants.CollectionShifts)
+}
+
+// EnsureIndexes crea el índice que impide que un usuario tenga dos turnos abiertos a la vez
+func (s *ShiftService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "openedBy", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetName("openedBy_open_unique").
+			SetPartialFilterExpression(bson.M{"status": models.ShiftOpen}),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create shift index: %v", err)
+	}
+	return nil
+}
+
+// Open abre un turno para el usuario con el fondo de caja indicado
+func (s *ShiftService) Open(ctx context.Context, user string, openingFloat float64, notes string) (*models.Shift, error) {
+	cashReceived := 0.0
+	shift := models.Shift{
+		ID:           primitive.NewObjectID(),
+		OpenedBy:     user,
+		OpeningFloat: roundMoney(openingFloat),
+		OpeningNotes: notes,
+		Status:       models.ShiftOpen,
+		OpenedAt:     time.Now(),
+		CashReceived: &cashReceived,
+	}
+	_, err := s.collection().InsertOne(ctx, shift)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrShiftAlreadyOpen
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open shift: %v", err)
+	}
+	return &shift, nil
+}
+
+// Current devuelve el turno abierto del usuario
+func (s *ShiftService) Current(ctx context.Context, user string) (*models.Shift, error) {
+	return openShift(ctx, s.collection(), user)
+}
+
+// Close cierra el turno abierto del usuario con el efectivo contado y guarda el esperado y la diferencia
+func (s *ShiftService) Close(ctx context.Context, user string, countedCash float64, notes string) (*models.ShiftReport, error) {
+	var report *models.ShiftReport
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		shift, err := openShift(sc, s.collection(), user)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		shift.Status = models.ShiftClosed
+		shift.ClosedAt = &now
+		shift.ClosingNotes = notes
+		// El corte se recalcula con los pagos y reemplaza el acumulado que lleva el registro de pagos
+		report, err = s.report(sc, *shift)
+		if err != nil {
+			return err
+		}
+		counted := roundMoney(countedCash)
+		discrepancy := roundMoney(counted - report.ExpectedCash)
+		shift.CashReceived = &report.CashReceived
+		shift.ExpectedCash = &report.ExpectedCash
+		shift.CountedCash = &counted
+		shift.Discrepancy = &discrepancy
+		report.Shift = *shift
+		report.Discrepancy = &discrepancy
+
+		_, err = s.collection().UpdateOne(sc, bson.M{"_id": shift.ID, "status": models.ShiftOpen}, bson.M{"$set": bson.M{
+			"status":       shift.Status,
+			"closedAt":     shift.ClosedAt,
+			"closingNotes": shift.ClosingNotes,
+			"cashReceived": shift.CashReceived,
+			"expectedCash": shift.ExpectedCash,
+			"countedCash":  shift.CountedCash,
+			"discrepancy":  shift.Discrepancy,
+		}})
+		if err != nil {
+			return fmt.Errorf("unable to close shift: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Report devuelve el corte del turno; en un turno cerrado los totales guardados al cerrar se conservan aunque
+// después se anule algún pago
+func (s *ShiftService) Report(ctx context.Context, id primitive.ObjectID) (*models.ShiftReport, error) {
+	var shift models.Shift
+	err := s.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&shift)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get shift: %v", err)
+	}
+
+	report, err := s.report(ctx, shift)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status == models.ShiftClosed {
+		report.CashReceived = *shift.CashReceived
+		report.ExpectedCash = *shift.ExpectedCash
+		report.Discrepancy = shift.Discrepancy
+	}
+	return report, nil
+}
+
+// report calcula el corte del turno con sus pagos en efectivo actuales
+func (s *ShiftService) report(ctx context.Context, shift models.Shift) (*models.ShiftReport, error) {
+	cursor, err := s.db().Collection(constants.CollectionPayments).Find(ctx,
+		bson.M{"shiftId": shift.ID},
+		options.Find().SetSort(bson.D{{Key: "receivedAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list shift payments: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	report := &models.ShiftReport{Shift: shift, CashPayments: []models.Payment{}}
+	if err := cursor.All(ctx, &report.CashPayments); err != nil {
+		return nil, fmt.Errorf("unable to decode shift payments: %v", err)
+	}
+	for _, payment := range report.CashPayments {
+		if payment.Status == models.PaymentVoided {
+			report.CashVoided = roundMoney(report.CashVoided + payment.Amount)
+		} else {
+			report.CashReceived = roundMoney(report.CashReceived + payment.Amount)
+		}
+	}
+	report.ExpectedCash = roundMoney(shift.OpeningFloat + report.CashReceived)
+	return report, nil
+}
+
+// List devuelve una página de turnos que cumplen el filtro, del más reciente al más antiguo, y el total
+func (s *ShiftService) List(ctx context.Context, filter bson.M, page, pageSize int) ([]models.Shift, int64, error) {
+	total, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to count shifts: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "openedAt", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list shifts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	shifts := []models.Shift{}
+	if err := cursor.All(ctx, &shifts); err != nil {
+		return nil, 0, fmt.Errorf("unable to decode shifts: %v", err)
+	}
+	return shifts, total, nil
+}
+
+// openShift busca el turno abierto del usuario; lo usan el cierre de turno y el registro de pagos en efectivo
+func openShift(ctx context.Context, collection *mongo.Collection, user string) (*models.Shift, error) {
+	var shift models.Shift
+	err := collection.FindOne(ctx, bson.M{"openedBy": user, "status": models.ShiftOpen}).Decode(&shift)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoOpenShift
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get open shift: %v", err)
+	}
+	return &shift, nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotelman-backend/constants"
+	"hotelman-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrRoomOccupied indica que la habitación ya tiene un ocupante
+	ErrRoomOccupied = errors.New("room is already occupied")
+	// ErrRoomOutOfService indica que la habitación está fuera de servicio o en mantenimiento
+	ErrRoomOutOfService = errors.New("room is out of service")
+	// ErrRoomNotReady indica que la habitación todavía no se limpia o inspecciona tras la última salida
+	ErrRoomNotReady = errors.New("room is not ready")
+	// ErrRoomNotOccupied indica que la habitación no tiene un ocupante al que hacer check-out
+	ErrRoomNotOccupied = errors.New("room is not occupied")
+	// ErrReservationMismatch indica que la reservación no está confirmada para esa habitación y ese cliente
+	ErrReservationMismatch = errors.New("reservation does not match this room and client")
+)
+
+// unavailableForCheckIn son los estados desde los que la máquina de estados no permite pasar a "occupied"
+var unavailableForCheckIn = func() []string {
+	statuses := []string{}
+	for status := range models.RoomStatusTransitions {
+		if !models.CanTransitionRoomStatus(status, models.RoomStatusOccupied) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}()
+
+// CheckIn son los datos de una entrada a una habitación
+type CheckIn struct {
+	RoomNumber    string
+	ClientID      primitive.ObjectID
+	ReservationID *primitive.ObjectID
+	User          string // Usuario del personal que registra la entrada
+}
+
+// StayService registra entradas y salidas de habitaciones; cada operación actualiza la habitación,
+// el historial del cliente y la reservación asociada en una sola transacción
+type StayService struct {
+	Client *mongo.Client
+}
+
+// NewStayService crea una nueva instancia de StayService
+func NewStayService(client *mongo.Client) *StayService {
+	return &StayService{Client: client}
+}
+
+func (s *StayService) db() *mongo.Database {
+	return s.Client.Database(constants.MongoDBDatabase)
+}
+
+// CheckIn asigna el cliente a la habitación, la marca como ocupada y agrega el registro "checkIn" a su historial
+func (s *StayService) CheckIn(ctx context.Context, checkIn CheckIn) (*models.Room, error) {
+	var room models.Room
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		now := time.Now()
+
+		// La condición del filtro hace que dos entradas simultáneas no puedan ocupar la misma habitación
+		err := s.db().Collection(constants.CollectionRooms).FindOneAndUpdate(sc,
+			activeRoomFilter(bson.M{
+				"roomNumber": checkIn.RoomNumber,
+				"occupantId": nil,
+				"status":     bson.M{"$nin": unavailableForCheckIn},
+			}),
+			bson.M{"$set": bson.M{"occupantId": checkIn.ClientID, "status": models.RoomStatusOccupied, "updatedAt": now}},
+		).Decode(&room)
+		if err == mongo.ErrNoDocuments {
+			return s.checkInRejection(sc, checkIn.RoomNumber)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to update room: %v", err)
+		}
+
+		if checkIn.ReservationID != nil {
+			result, err := s.db().Collection(constants.CollectionReservations).UpdateOne(sc,
+				bson.M{
+					"_id":      *checkIn.ReservationID,
+					"roomId":   room.ID,
+					"clientId": checkIn.ClientID,
+					"status":   models.ReservationConfirmed,
+				},
+				bson.M{"$set": bson.M{"status": models.ReservationCheckedIn, "updatedAt": now}},
+			)
+			if err != nil {
+				return fmt.Errorf("unable to update reservation: %v", err)
+			}
+			if result.MatchedCount == 0 {
+				return ErrReservationMismatch
+			}
+		}
+
+		record := models.HistoryRecord{
+			Action:        models.HistoryCheckIn,
+			DateTime:      now,
+			RoomNumber:    room.RoomNumber,
+			User:          checkIn.User,
+			ReservationID: checkIn.ReservationID,
+		}
+		if err := s.appendHistory(sc, checkIn.ClientID, record); err != nil {
+			return err
+		}
+
+		from := room.Status
+		room.OccupantID = &checkIn.ClientID
+		room.Status = models.RoomStatusOccupied
+		room.UpdatedAt = now
+		return recordRoomStatusChange(sc, s.db(), room, from, checkIn.User, "check-in")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// CheckOut libera la habitación dejándola pendiente de limpieza con una tarea de housekeeping, agrega el registro "checkOut" al historial del ocupante
+// y da por terminada su reservación en curso, si la hay
+func (s *StayService) CheckOut(ctx context.Context, roomNumber, user string) (*models.Room, error) {
+	var room models.Room
+	err := runInTransaction(ctx, s.Client, func(sc mongo.SessionContext) error {
+		now := time.Now()
+
+		err := s.db().Collection(constants.CollectionRooms).FindOneAndUpdate(sc,
+			bson.M{"roomNumber": roomNumber, "occupantId": bson.M{"$ne": nil}},
+			bson.M{
+				"$set":   bson.M{"status": models.RoomStatusDirty, "updatedAt": now},
+				"$unset": bson.M{"occupantId": ""},
+			},
+		).Decode(&room)
+		if err == mongo.ErrNoDocuments {
+			count, err := s.db().Collection(constants.CollectionRooms).CountDocuments(sc, bson.M{"roomNumber": roomNumber})
+			if err != nil {
+				return fmt.Errorf("unable to get room: %v", err)
+			}
+			if count == 0 {
+				return ErrRoomNotFound
+			}
+			return ErrRoomNotOccupied
+		}
+		if err != nil {
+			return fmt.Errorf("unable to update room: %v", err)
+		}
+		occupantID := *room.OccupantID
+
+		// Cerrar la reservación con la que entró el ocupante
+		var reservation models.Reservation
+		var reservationID *primitive.ObjectID
+		err = s.db().Collection(constants.CollectionReservations).FindOneAndUpdate(sc,
+			bson.M{"roomId": room.ID, "clientId": occupantID, "status": models.ReservationCheckedIn},
+			bson.M{"$set": bson.M{"status": models.ReservationCompleted, "updatedAt": now}},
+		).Decode(&reservation)
+		if err == nil {
+			reservationID = &reservation.ID
+		} else if err != mongo.ErrNoDocuments {
+			return fmt.Errorf("unable to update reservation: %v", err)
+		}
+
+		record := models.HistoryRecord{
+			Action:        models.HistoryCheckOut,
+			DateTime:      now,
+			RoomNumber:    room.RoomNumber,
+			User:          user,
+			ReservationID: reservationID,
+		}
+		if err := s.appendHistory(sc, occupantID, record); err != nil {
+			return err
+		}
+
+		from := room.Status
+		room.OccupantID = nil
+		room.Status = models.RoomStatusDirty
+		room.UpdatedAt = now
+		if err := recordRoomStatusChange(sc, s.db(), room, from, user, "check-out"); err != nil {
+			return err
+		}
+
+		// Si se reportó una falla bloqueante durante la estancia la habitación pasa a "out-of-order";
+		// si no, la habitación desocupada entra al tablero de housekeeping
+		blocked, err := syncMaintenanceBlock(sc, s.db(), room.ID, user)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			room.Status = models.RoomStatusOutOfOrder
+			return nil
+		}
+		_, err = createCleaningTask(sc, s.db(), room, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// appendHistory agrega el registro al historial del huésped o inquilino; tolera historiales nulos de clientes antiguos
+func (s *StayService) appendHistory(sc mongo.SessionContext, clientID primitive.ObjectID, record models.HistoryRecord) error {
+	result, err := s.db().Collection(constants.CollectionClients).UpdateOne(sc,
+		bson.M{"_id": clientID},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"history":   bson.M{"$concatArrays": bson.A{bson.M{"$ifNull": bson.A{"$history", bson.A{}}}, bson.M{"$literal": bson.A{record}}}},
+			"updatedAt": record.DateTime,
+		}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update client history: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+// checkInRejection explica por qué la habitación no admitió la entrada
+func (s *StayService) checkInRejection(sc mongo.SessionContext, roomNumber string) error {
+	var room models.Room
+	err := s.db().Collection(constants.CollectionRooms).FindOne(sc, activeRoomFilter(bson.M{"roomNumber": roomNumber})).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return ErrRoomNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get room: %v", err)
+	}
+	switch {
+	case room.OccupantID != nil || room.Status == models.RoomStatusOccupied:
+		return ErrRoomOccupied
+	case room.Status == models.RoomStatusOutOfOrder || room.Status == models.RoomStatusMaintenance:
+		return ErrRoomOutOfService
+	default:
+		return ErrRoomNotReady
+	}
+}
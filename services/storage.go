@@ -0,0 +1,34 @@
+package services
+
+import (
+	"bytes"
+	"mime/multipart"
+	"path/filepath"
+
+	"hotelman-backend/constants"
+)
+
+// memoryFile permite pasar un documento generado en memoria a los servicios de almacenamiento, que reciben
+// un multipart.File
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+// StoreDocument guarda el documento generado (PDF, XML o reporte CSV/XLSX) en el sistema de archivos local o,
+// según constants.StorageSelector, en Google Drive como los contratos, y devuelve su URL
+func StoreDocument(filename string, content []byte, local *LocalFileSystemService, drive *GoogleDriveService) (string, error) {
+	file := memoryFile{bytes.NewReader(content)}
+	header := &multipart.FileHeader{Filename: filename, Size: int64(len(content))}
+	if constants.StorageSelector == "local" {
+		switch filepath.Ext(filename) {
+		case ".xml":
+			return local.UploadFileXML(file, header)
+		case ".csv", ".xlsx":
+			return local.UploadFileReport(file, header)
+		}
+		return local.UploadFilePDF(file, header)
+	}
+	return drive.UploadFile(file, header)
+}
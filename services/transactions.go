@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// runInTransaction ejecuta fn dentro de una transacción de MongoDB; el driver la reintenta ante conflictos
+// de escritura transitorios, así que fn no debe tener efectos fuera de la base de datos.
+// Requiere que MongoDB corra como replica set.
+func runInTransaction(ctx context.Context, client *mongo.Client, fn func(sc mongo.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}